@@ -0,0 +1,276 @@
+package background
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/TicketsBot-cloud/archiverclient"
+	"github.com/TicketsBot-cloud/common/premium"
+	"github.com/TicketsBot-cloud/dashboard/botcontext"
+	dbclient "github.com/TicketsBot-cloud/dashboard/database"
+	"github.com/TicketsBot-cloud/dashboard/redis"
+	"github.com/TicketsBot-cloud/dashboard/rpc"
+	"github.com/TicketsBot-cloud/dashboard/s3"
+	"github.com/TicketsBot-cloud/dashboard/utils"
+	"github.com/minio/minio-go/v7"
+	"go.uber.org/zap"
+)
+
+const (
+	attachmentMirrorInterval     = time.Minute
+	attachmentMirrorBatchSize    = 10
+	attachmentMirrorStaleTimeout = time.Minute * 30
+	attachmentMirrorRetryAfter   = time.Minute * 10
+	attachmentMirrorMaxAttempts  = 3
+	attachmentMirrorFetchTimeout = time.Minute * 5
+
+	// attachmentMirrorPartSize is the size of each part of multipart uploads, which minio buffers in memory, used when
+	// the CDN doesn't report the attachment's size
+	attachmentMirrorPartSize = 16 * 1024 * 1024
+
+	// Discord CDN links expire about a day after they are issued, so older transcripts can't be mirrored
+	attachmentMirrorMaxAge = time.Hour * 24
+)
+
+// attachmentMirrorLimits is the total size of the attachments that are mirrored for each ticket, by premium tier.
+// Attachments that do not fit are left linking to the Discord CDN.
+var attachmentMirrorLimits = map[premium.PremiumTier]int64{
+	premium.None:       10 * 1024 * 1024,
+	premium.Premium:    100 * 1024 * 1024,
+	premium.Whitelabel: 500 * 1024 * 1024,
+}
+
+var attachmentMirrorHosts = []string{"cdn.discordapp.com", "media.discordapp.net"}
+
+var attachmentHttpClient = &http.Client{
+	Timeout: attachmentMirrorFetchTimeout,
+	// Attachment URLs come from archived transcripts, which may have been imported, so only fetch from the CDN itself
+	CheckRedirect: func(*http.Request, []*http.Request) error {
+		return http.ErrUseLastResponse
+	},
+}
+
+// RunAttachmentMirroring periodically copies the attachments of recently closed tickets' transcripts into our own
+// storage, before the Discord CDN links expire. It is safe to run on every replica, as each ticket is claimed by
+// exactly one replica.
+func RunAttachmentMirroring(ctx context.Context, logger *zap.Logger) {
+	ticker := time.NewTicker(attachmentMirrorInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := mirrorAttachments(ctx, logger); err != nil {
+				logger.Error("Failed to mirror transcript attachments", zap.Error(err))
+			}
+		}
+	}
+}
+
+func mirrorAttachments(ctx context.Context, logger *zap.Logger) error {
+	tickets, err := dbclient.Dashboard.AttachmentMirrors.ClaimTickets(
+		ctx,
+		attachmentMirrorMaxAge,
+		attachmentMirrorStaleTimeout,
+		attachmentMirrorRetryAfter,
+		attachmentMirrorMaxAttempts,
+		attachmentMirrorBatchSize,
+	)
+	if err != nil {
+		return err
+	}
+
+	for _, ticket := range tickets {
+		if err := mirrorTicketAttachments(ctx, ticket.GuildId, ticket.TicketId); err != nil {
+			logger.Error(
+				"Failed to mirror ticket attachments",
+				zap.Uint64("guild_id", ticket.GuildId),
+				zap.Int("ticket_id", ticket.TicketId),
+				zap.Int("attempt", ticket.Attempts),
+				zap.Error(err),
+			)
+
+			if err := dbclient.Dashboard.AttachmentMirrors.Fail(ctx, ticket.GuildId, ticket.TicketId); err != nil {
+				logger.Error("Failed to mark attachment mirroring as failed", zap.Error(err))
+			}
+		}
+	}
+
+	return nil
+}
+
+func mirrorTicketAttachments(ctx context.Context, guildId uint64, ticketId int) error {
+	botContext, err := botcontext.ContextForGuild(guildId)
+	if err != nil {
+		return err
+	}
+
+	tier, err := rpc.PremiumClient.GetTierByGuildId(ctx, guildId, true, botContext.Token, botContext.RateLimiter)
+	if err != nil {
+		return err
+	}
+
+	// Read the latest redaction before the transcript, so that any redaction made while mirroring is detected
+	redactionId, err := dbclient.Dashboard.AttachmentMirrors.GetRedactionId(ctx, guildId, ticketId)
+	if err != nil {
+		return err
+	}
+
+	transcript, err := utils.ArchiverClient.Get(ctx, guildId, ticketId)
+	if err != nil && !errors.Is(err, archiverclient.ErrNotFound) {
+		return err
+	}
+
+	remaining := attachmentMirrorLimits[tier]
+
+	var mirrors []dbclient.AttachmentMirror
+	for _, msg := range transcript.Messages {
+		for _, attachment := range msg.Attachments {
+			if int64(attachment.Size) > remaining {
+				continue
+			}
+
+			mirror, ok, err := mirrorAttachment(ctx, guildId, ticketId, attachment.Id, attachment.Url, remaining)
+			if err != nil {
+				deleteMirrorObjects(ctx, mirrors)
+				return err
+			}
+
+			if ok {
+				mirrors = append(mirrors, mirror)
+				remaining -= mirror.Size
+			}
+		}
+	}
+
+	completed, err := dbclient.Dashboard.AttachmentMirrors.Complete(ctx, guildId, ticketId, redactionId, mirrors)
+	if err != nil {
+		deleteMirrorObjects(ctx, mirrors)
+		return err
+	}
+
+	// The transcript was redacted while we were copying it, so start again from the redacted transcript
+	if !completed {
+		deleteMirrorObjects(ctx, mirrors)
+		return dbclient.Dashboard.AttachmentMirrors.Reset(ctx, guildId, ticketId)
+	}
+
+	if len(mirrors) > 0 {
+		// Re-render with the links to the mirrored attachments
+		return redis.Client.InvalidateRenderedTranscript(ctx, guildId, ticketId)
+	}
+
+	return nil
+}
+
+// mirrorAttachment copies an attachment into the attachment bucket, returning false if the attachment is no longer
+// available, or is larger than maxSize
+func mirrorAttachment(ctx context.Context, guildId uint64, ticketId int, attachmentId uint64, rawUrl string, maxSize int64) (dbclient.AttachmentMirror, bool, error) {
+	if !isAttachmentMirrorUrl(rawUrl) {
+		return dbclient.AttachmentMirror{}, false, nil
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, rawUrl, nil)
+	if err != nil {
+		return dbclient.AttachmentMirror{}, false, nil
+	}
+
+	res, err := attachmentHttpClient.Do(req)
+	if err != nil {
+		return dbclient.AttachmentMirror{}, false, err
+	}
+
+	defer res.Body.Close()
+
+	// Expired links and deleted attachments can't be recovered, so don't retry
+	if res.StatusCode != http.StatusOK {
+		return dbclient.AttachmentMirror{}, false, nil
+	}
+
+	if res.ContentLength > maxSize {
+		return dbclient.AttachmentMirror{}, false, nil
+	}
+
+	contentType := res.Header.Get("Content-Type")
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
+
+	mirror := dbclient.AttachmentMirror{
+		GuildId:      guildId,
+		TicketId:     ticketId,
+		AttachmentId: attachmentId,
+		ObjectKey:    s3.TranscriptAttachmentKey(guildId, ticketId, attachmentId),
+		ContentType:  contentType,
+	}
+
+	// Read one byte more than the limit, to detect attachments that are larger than their reported size. If the size
+	// is known, it is passed to minio so that the attachment is streamed rather than buffered.
+	body := io.LimitReader(res.Body, maxSize+1)
+	info, err := s3.S3Client.PutObject(ctx, s3.AttachmentBucket(), mirror.ObjectKey, body, res.ContentLength, minio.PutObjectOptions{
+		ContentType: contentType,
+		PartSize:    attachmentMirrorPartSize,
+	})
+	if err != nil {
+		return dbclient.AttachmentMirror{}, false, fmt.Errorf("failed to upload attachment %d: %w", attachmentId, err)
+	}
+
+	if info.Size > maxSize {
+		deleteMirrorObjects(ctx, []dbclient.AttachmentMirror{mirror})
+		return dbclient.AttachmentMirror{}, false, nil
+	}
+
+	mirror.Size = info.Size
+	return mirror, true, nil
+}
+
+func isAttachmentMirrorUrl(rawUrl string) bool {
+	parsed, err := url.Parse(rawUrl)
+	if err != nil {
+		return false
+	}
+
+	return parsed.Scheme == "https" && utils.Contains(attachmentMirrorHosts, parsed.Hostname())
+}
+
+// DeleteMirroredAttachments removes the ticket's mirrored attachments from storage, except for those in keep. Passing
+// a nil keep removes all of them.
+func DeleteMirroredAttachments(ctx context.Context, guildId uint64, ticketId int, keep []uint64) error {
+	mirrors, err := dbclient.Dashboard.AttachmentMirrors.GetByTicket(ctx, guildId, ticketId)
+	if err != nil {
+		return err
+	}
+
+	var attachmentIds []uint64
+	for _, mirror := range mirrors {
+		if utils.Contains(keep, mirror.AttachmentId) {
+			continue
+		}
+
+		if err := s3.S3Client.RemoveObject(ctx, s3.AttachmentBucket(), mirror.ObjectKey, minio.RemoveObjectOptions{}); err != nil {
+			return err
+		}
+
+		attachmentIds = append(attachmentIds, mirror.AttachmentId)
+	}
+
+	if len(attachmentIds) == 0 {
+		return nil
+	}
+
+	return dbclient.Dashboard.AttachmentMirrors.Delete(ctx, guildId, ticketId, attachmentIds)
+}
+
+// deleteMirrorObjects removes uploaded attachments that will not be recorded, on a best effort basis
+func deleteMirrorObjects(ctx context.Context, mirrors []dbclient.AttachmentMirror) {
+	for _, mirror := range mirrors {
+		_ = s3.S3Client.RemoveObject(ctx, s3.AttachmentBucket(), mirror.ObjectKey, minio.RemoveObjectOptions{})
+	}
+}
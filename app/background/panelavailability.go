@@ -0,0 +1,227 @@
+package background
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/TicketsBot-cloud/dashboard/botcontext"
+	dbclient "github.com/TicketsBot-cloud/dashboard/database"
+	"github.com/TicketsBot-cloud/database"
+	"github.com/rxdn/gdl/objects/channel/embed"
+	"github.com/rxdn/gdl/objects/interaction/component"
+	"github.com/rxdn/gdl/rest"
+	"github.com/rxdn/gdl/rest/request"
+	"go.uber.org/zap"
+)
+
+const (
+	panelAvailabilityInterval  = time.Second * 30
+	panelAvailabilityBatchSize = 50
+
+	// panelAvailabilityLease is how long a claimed schedule is hidden from other replicas. If it could not be applied,
+	// it is retried once the lease expires.
+	panelAvailabilityLease = time.Minute * 5
+)
+
+// panelAvailabilityAction is how a panel's availability schedule should be applied
+type panelAvailabilityAction struct {
+	Disabled         *bool // The panel's new disabled state, or nil to leave it as-is
+	ClosedBySchedule bool
+	UpdateMessages   bool
+}
+
+// RunPanelAvailability periodically opens and closes panels at the boundaries of their availability schedules. It is
+// safe to run on every replica, as each due schedule is claimed by exactly one replica.
+func RunPanelAvailability(ctx context.Context, logger *zap.Logger) {
+	ticker := time.NewTicker(panelAvailabilityInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := processPanelAvailability(ctx, logger); err != nil {
+				logger.Error("Failed to process panel availability schedules", zap.Error(err))
+			}
+		}
+	}
+}
+
+func processPanelAvailability(ctx context.Context, logger *zap.Logger) error {
+	ctx, cancel := context.WithTimeout(ctx, panelAvailabilityLease)
+	defer cancel()
+
+	due, err := dbclient.Dashboard.PanelAvailability.ClaimDue(ctx, panelAvailabilityBatchSize, panelAvailabilityLease)
+	if err != nil {
+		return err
+	}
+
+	for _, availability := range due {
+		if err := applyPanelAvailability(ctx, availability, time.Now()); err != nil {
+			logger.Error(
+				"Failed to apply panel availability schedule",
+				zap.Int("panel_id", availability.PanelId),
+				zap.Error(err),
+			)
+		}
+	}
+
+	return nil
+}
+
+func applyPanelAvailability(ctx context.Context, availability dbclient.PanelAvailability, now time.Time) error {
+	panel, err := dbclient.Client.Panel.GetById(ctx, availability.PanelId)
+	if err != nil {
+		return err
+	}
+
+	// The schedule is deleted along with the panel
+	if panel.PanelId == 0 {
+		return nil
+	}
+
+	nextTransition, _ := availability.Schedule.NextTransition(now)
+
+	// Panels disabled for lapsed premium must not be re-enabled by the schedule
+	if panel.ForceDisabled {
+		return dbclient.Dashboard.PanelAvailability.Transition(ctx, panel.PanelId, availability.Version, nil, availability.ClosedBySchedule, nextTransition)
+	}
+
+	action := decidePanelAvailability(panel.Disabled, availability.ClosedBySchedule, availability.Schedule.IsOpen(now))
+
+	if action.UpdateMessages {
+		if action.Disabled != nil {
+			panel.Disabled = *action.Disabled
+		}
+
+		botContext, err := botcontext.ContextForGuild(panel.GuildId)
+		if err != nil {
+			return err
+		}
+
+		if err := UpdatePanelMessages(ctx, botContext, panel, availability.ClosedMessage); err != nil {
+			return err
+		}
+	}
+
+	return dbclient.Dashboard.PanelAvailability.Transition(ctx, panel.PanelId, availability.Version, action.Disabled, action.ClosedBySchedule, nextTransition)
+}
+
+// decidePanelAvailability decides how to apply the schedule to a panel. Panels that were disabled manually are left
+// alone, both while the schedule is closed and when it opens again.
+func decidePanelAvailability(disabled, closedBySchedule, open bool) panelAvailabilityAction {
+	enable, disable := false, true
+
+	if open {
+		if closedBySchedule {
+			return panelAvailabilityAction{Disabled: &enable, ClosedBySchedule: false, UpdateMessages: true}
+		}
+
+		return panelAvailabilityAction{}
+	}
+
+	if !disabled {
+		return panelAvailabilityAction{Disabled: &disable, ClosedBySchedule: true, UpdateMessages: true}
+	}
+
+	// Already closed: refresh the messages in case the closed message has changed
+	if closedBySchedule {
+		return panelAvailabilityAction{ClosedBySchedule: true, UpdateMessages: true}
+	}
+
+	return panelAvailabilityAction{}
+}
+
+// UpdatePanelMessages enables or disables the panel's button, according to panel.Disabled, on the panel message and on
+// the messages of any multi-panels that use buttons and contain the panel. While the panel is disabled, the closed
+// message, if any, is shown above the panel message's embed. Messages that have been deleted, or that can no longer be
+// edited, are skipped.
+func UpdatePanelMessages(ctx context.Context, botContext *botcontext.BotContext, panel database.Panel, closedMessage *string) error {
+	var content string
+	if panel.Disabled && closedMessage != nil {
+		content = *closedMessage
+	}
+
+	if err := editPanelButton(ctx, botContext, panel.ChannelId, panel.MessageId, panel.CustomId, panel.Disabled, &content); err != nil {
+		return err
+	}
+
+	multiPanels, err := dbclient.Client.MultiPanelTargets.GetMultiPanels(ctx, panel.PanelId)
+	if err != nil {
+		return err
+	}
+
+	for _, multiPanel := range multiPanels {
+		// Select menu options can't be disabled individually
+		if multiPanel.SelectMenu {
+			continue
+		}
+
+		if err := editPanelButton(ctx, botContext, multiPanel.ChannelId, multiPanel.MessageId, panel.CustomId, panel.Disabled, nil); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// editPanelButton edits the message, keeping its embeds, with the button with the given custom ID enabled or disabled.
+// If content is not nil, the content of the message is also replaced.
+func editPanelButton(ctx context.Context, botContext *botcontext.BotContext, channelId, messageId uint64, customId string, disabled bool, content *string) error {
+	msg, err := rest.GetChannelMessage(ctx, botContext.Token, botContext.RateLimiter, channelId, messageId)
+	if err != nil {
+		return ignoreClientError(err)
+	}
+
+	embeds := make([]*embed.Embed, len(msg.Embeds))
+	for i := range msg.Embeds {
+		embeds[i] = &msg.Embeds[i]
+	}
+
+	data := rest.EditMessageData{
+		Content:    msg.Content,
+		Embeds:     embeds,
+		Components: setButtonDisabled(msg.Components, customId, disabled),
+	}
+
+	if content != nil {
+		data.Content = *content
+	}
+
+	_, err = rest.EditMessage(ctx, botContext.Token, botContext.RateLimiter, channelId, messageId, data)
+	return ignoreClientError(err)
+}
+
+// setButtonDisabled returns a copy of the components, with the button with the given custom ID enabled or disabled
+func setButtonDisabled(components []component.Component, customId string, disabled bool) []component.Component {
+	updated := make([]component.Component, len(components))
+	for i, c := range components {
+		switch data := c.ComponentData.(type) {
+		case component.ActionRow:
+			data.Components = setButtonDisabled(data.Components, customId, disabled)
+			c.ComponentData = data
+		case component.Button:
+			if data.CustomId == customId {
+				data.Disabled = disabled
+				c.ComponentData = data
+			}
+		}
+
+		updated[i] = c
+	}
+
+	return updated
+}
+
+// ignoreClientError returns nil for errors caused by the message or channel no longer existing, or the bot lacking
+// permission to edit it, which retrying won't fix
+func ignoreClientError(err error) error {
+	var unwrapped request.RestError
+	if errors.As(err, &unwrapped) && unwrapped.IsClientError() {
+		return nil
+	}
+
+	return err
+}
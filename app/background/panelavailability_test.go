@@ -0,0 +1,52 @@
+package background
+
+import (
+	"testing"
+
+	"github.com/rxdn/gdl/objects/interaction/component"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDecidePanelAvailability(t *testing.T) {
+	// Closing an enabled panel
+	action := decidePanelAvailability(false, false, false)
+	assert.True(t, *action.Disabled)
+	assert.True(t, action.ClosedBySchedule)
+	assert.True(t, action.UpdateMessages)
+
+	// Reopening a panel closed by the schedule
+	action = decidePanelAvailability(true, true, true)
+	assert.False(t, *action.Disabled)
+	assert.False(t, action.ClosedBySchedule)
+	assert.True(t, action.UpdateMessages)
+
+	// Refreshing the closed message of a panel closed by the schedule
+	action = decidePanelAvailability(true, true, false)
+	assert.Nil(t, action.Disabled)
+	assert.True(t, action.ClosedBySchedule)
+	assert.True(t, action.UpdateMessages)
+
+	// Manually disabled panels are left alone
+	assert.Equal(t, panelAvailabilityAction{}, decidePanelAvailability(true, false, false))
+	assert.Equal(t, panelAvailabilityAction{}, decidePanelAvailability(true, false, true))
+	assert.Equal(t, panelAvailabilityAction{}, decidePanelAvailability(false, false, true))
+}
+
+func TestSetButtonDisabled(t *testing.T) {
+	components := []component.Component{
+		component.BuildActionRow(
+			component.BuildButton(component.Button{CustomId: "panel_1", Style: component.ButtonStylePrimary}),
+			component.BuildButton(component.Button{CustomId: "panel_2", Style: component.ButtonStylePrimary}),
+		),
+	}
+
+	updated := setButtonDisabled(components, "panel_2", true)
+
+	buttons := updated[0].ComponentData.(component.ActionRow).Components
+	assert.False(t, buttons[0].ComponentData.(component.Button).Disabled)
+	assert.True(t, buttons[1].ComponentData.(component.Button).Disabled)
+
+	// The original components are not modified
+	original := components[0].ComponentData.(component.ActionRow).Components
+	assert.False(t, original[1].ComponentData.(component.Button).Disabled)
+}
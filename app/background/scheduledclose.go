@@ -0,0 +1,135 @@
+package background
+
+import (
+	"context"
+	"time"
+
+	"github.com/TicketsBot-cloud/common/chatrelay"
+	"github.com/TicketsBot-cloud/common/closerelay"
+	dbclient "github.com/TicketsBot-cloud/dashboard/database"
+	"github.com/TicketsBot-cloud/dashboard/redis"
+	"github.com/TicketsBot-cloud/dashboard/utils"
+	"go.uber.org/zap"
+)
+
+const (
+	scheduledCloseInterval  = time.Second * 15
+	scheduledCloseBatchSize = 100
+
+	// A close that fails is retried with exponential backoff, starting at scheduledCloseRetryDelay, until it has failed
+	// scheduledCloseMaxAttempts times, after which it is dropped
+	scheduledCloseRetryDelay  = time.Minute
+	scheduledCloseMaxAttempts = 5
+)
+
+// RunScheduledCloses periodically closes tickets whose scheduled close time has passed. It is safe to run on every
+// replica, as each due close is claimed by exactly one replica.
+func RunScheduledCloses(ctx context.Context, logger *zap.Logger) {
+	ticker := time.NewTicker(scheduledCloseInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := processScheduledCloses(ctx, logger); err != nil {
+				logger.Error("Failed to process scheduled closes", zap.Error(err))
+			}
+		}
+	}
+}
+
+func processScheduledCloses(ctx context.Context, logger *zap.Logger) error {
+	ctx, cancel := context.WithTimeout(ctx, scheduledCloseInterval)
+	defer cancel()
+
+	due, err := dbclient.Dashboard.ScheduledClose.ClaimDue(ctx, scheduledCloseBatchSize)
+	if err != nil {
+		return err
+	}
+
+	for _, scheduled := range due {
+		if err := performScheduledClose(ctx, scheduled); err != nil {
+			logger.Error(
+				"Failed to perform scheduled close",
+				zap.Uint64("guild_id", scheduled.GuildId),
+				zap.Int("ticket_id", scheduled.TicketId),
+				zap.Error(err),
+			)
+
+			if scheduled.Attempts+1 >= scheduledCloseMaxAttempts {
+				logger.Warn(
+					"Giving up on scheduled close",
+					zap.Uint64("guild_id", scheduled.GuildId),
+					zap.Int("ticket_id", scheduled.TicketId),
+					zap.Int("attempts", scheduled.Attempts+1),
+				)
+
+				continue
+			}
+
+			retryAt := time.Now().Add(scheduledCloseRetryDelay << scheduled.Attempts)
+			if err := dbclient.Dashboard.ScheduledClose.Retry(ctx, scheduled, retryAt); err != nil {
+				logger.Error("Failed to reschedule close", zap.Error(err))
+			}
+		}
+	}
+
+	return nil
+}
+
+func performScheduledClose(ctx context.Context, scheduled dbclient.ScheduledClose) error {
+	ticket, err := dbclient.Client.Tickets.Get(ctx, scheduled.TicketId, scheduled.GuildId)
+	if err != nil {
+		return err
+	}
+
+	// Ticket may have been closed or deleted by other means in the meantime
+	if ticket.UserId == 0 || !ticket.Open {
+		return nil
+	}
+
+	// The chat relay event may have been missed, so check whether the opener has responded since the close was scheduled
+	if scheduled.CancelOnReply {
+		lastMessage, err := dbclient.Client.TicketLastMessage.Get(ctx, scheduled.GuildId, scheduled.TicketId)
+		if err != nil {
+			return err
+		}
+
+		if lastMessage.LastMessageTime != nil && lastMessage.LastMessageTime.After(scheduled.CreatedAt) &&
+			utils.ValueOrZero(lastMessage.UserId) == ticket.UserId {
+			return nil
+		}
+	}
+
+	data := closerelay.TicketClose{
+		GuildId:  scheduled.GuildId,
+		TicketId: scheduled.TicketId,
+		UserId:   scheduled.UserId,
+		Reason:   utils.ValueOrZero(scheduled.Reason),
+	}
+
+	return closerelay.Publish(redis.Client.Client, data)
+}
+
+// IsOpenerReply returns whether the message was sent by the ticket opener, and so may cancel a scheduled close
+func IsOpenerReply(event chatrelay.MessageData) bool {
+	return event.Message.Author.Id != 0 && event.Message.Author.Id == event.Ticket.UserId
+}
+
+// CancelScheduledCloseOnReply cancels any scheduled close on the ticket if the message was sent by the ticket opener.
+// Every reply is checked, as only closes scheduled before the reply was sent are cancelled.
+func CancelScheduledCloseOnReply(ctx context.Context, event chatrelay.MessageData) error {
+	if !IsOpenerReply(event) {
+		return nil
+	}
+
+	repliedAt := event.Message.Timestamp
+	if repliedAt.IsZero() {
+		repliedAt = time.Now()
+	}
+
+	_, err := dbclient.Dashboard.ScheduledClose.CancelOnReply(ctx, event.Ticket.GuildId, event.Ticket.Id, repliedAt)
+	return err
+}
@@ -0,0 +1,266 @@
+package background
+
+import (
+	"archive/zip"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/TicketsBot-cloud/archiverclient"
+	"github.com/TicketsBot-cloud/dashboard/chatreplica"
+	dbclient "github.com/TicketsBot-cloud/dashboard/database"
+	"github.com/TicketsBot-cloud/dashboard/s3"
+	"github.com/TicketsBot-cloud/dashboard/utils"
+	"github.com/TicketsBot-cloud/database"
+	"github.com/minio/minio-go/v7"
+	"go.uber.org/zap"
+)
+
+const (
+	transcriptExportInterval         = time.Second * 15
+	transcriptExportStaleTimeout     = time.Minute * 10
+	transcriptExportProgressInterval = 10
+	transcriptExportFetchTimeout     = time.Second * 30
+	transcriptExportExpiryBatchSize  = 50
+
+	// transcriptExportPartSize is the size of each part of the multipart upload. The archive's size isn't known until
+	// it has been written, so each part is buffered in memory before it is uploaded.
+	transcriptExportPartSize = 16 * 1024 * 1024
+
+	// TranscriptExportRetention is how long completed exports are kept in the bucket before they are deleted
+	TranscriptExportRetention = time.Hour * 24 * 7
+
+	// TranscriptExportMaxTickets is the maximum number of transcripts that a single export may contain
+	TranscriptExportMaxTickets = 10000
+)
+
+// RunTranscriptExports periodically claims and runs pending bulk transcript exports. It is safe to run on every
+// replica, as each job is claimed by exactly one replica.
+func RunTranscriptExports(ctx context.Context, logger *zap.Logger) {
+	ticker := time.NewTicker(transcriptExportInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := processTranscriptExports(ctx, logger); err != nil {
+				logger.Error("Failed to process transcript exports", zap.Error(err))
+			}
+		}
+	}
+}
+
+func processTranscriptExports(ctx context.Context, logger *zap.Logger) error {
+	if err := dbclient.Dashboard.TranscriptExportJobs.FailStale(ctx, transcriptExportStaleTimeout); err != nil {
+		return err
+	}
+
+	if err := expireTranscriptExports(ctx); err != nil {
+		return err
+	}
+
+	job, ok, err := dbclient.Dashboard.TranscriptExportJobs.ClaimPending(ctx)
+	if err != nil {
+		return err
+	}
+
+	if !ok {
+		return nil
+	}
+
+	if err := runTranscriptExport(ctx, job); err != nil {
+		logger.Error(
+			"Failed to run transcript export",
+			zap.Uint64("guild_id", job.GuildId),
+			zap.Int("job_id", job.Id),
+			zap.Error(err),
+		)
+
+		if err := dbclient.Dashboard.TranscriptExportJobs.Fail(ctx, job.Id, err.Error()); err != nil {
+			logger.Error("Failed to mark transcript export as failed", zap.Error(err))
+		}
+	}
+
+	return nil
+}
+
+func runTranscriptExport(ctx context.Context, job dbclient.TranscriptExportJob) error {
+	format := chatreplica.Format(job.Format)
+	if !format.IsValid() {
+		return fmt.Errorf("unknown export format: %s", job.Format)
+	}
+
+	tickets, err := getTranscriptExportTickets(ctx, job)
+	if err != nil {
+		return err
+	}
+
+	if len(tickets) > TranscriptExportMaxTickets {
+		return fmt.Errorf("export matches more than %d transcripts, narrow the filter and try again", TranscriptExportMaxTickets)
+	}
+
+	// Record which transcripts the archive contains before fetching any, so that redacting or deleting one of them
+	// while the export is running fails it
+	ticketIds := make([]int, 0, len(tickets))
+	for _, ticket := range tickets {
+		if ticket.HasTranscript {
+			ticketIds = append(ticketIds, ticket.Id)
+		}
+	}
+
+	if err := dbclient.Dashboard.TranscriptExportJobs.Start(ctx, job.Id, len(tickets), ticketIds); err != nil {
+		return err
+	}
+
+	// Upload the archive in parts as it is written, rather than holding all of it in memory
+	reader, writer := io.Pipe()
+	go func() {
+		_ = writer.CloseWithError(writeTranscriptArchive(ctx, job, tickets, format, writer))
+	}()
+
+	key := s3.TranscriptExportKey(job.GuildId, job.Id)
+	if _, err := s3.S3Client.PutObject(ctx, s3.ExportBucket(), key, reader, -1, minio.PutObjectOptions{
+		ContentType: "application/zip",
+		PartSize:    transcriptExportPartSize,
+	}); err != nil {
+		_ = reader.CloseWithError(err)
+		return err
+	}
+
+	completed, err := dbclient.Dashboard.TranscriptExportJobs.Complete(ctx, job.Id, key)
+	if err != nil {
+		return err
+	}
+
+	// The job was failed while the archive was being uploaded, so nothing will ever serve or delete it
+	if !completed {
+		_ = s3.S3Client.RemoveObject(ctx, s3.ExportBucket(), key, minio.RemoveObjectOptions{})
+		return dbclient.ErrExportNotRunning
+	}
+
+	return nil
+}
+
+// DeleteTranscriptExports deletes the guild's stored export archives that contain the transcript of any of the given
+// tickets, e.g. because the transcripts have been redacted or deleted. Running exports that will contain one of the
+// transcripts are failed. The reason is shown to the user in place of the download link.
+func DeleteTranscriptExports(ctx context.Context, guildId uint64, ticketIds []int, reason string) error {
+	if err := dbclient.Dashboard.TranscriptExportJobs.FailContaining(ctx, guildId, ticketIds, reason); err != nil {
+		return err
+	}
+
+	jobs, err := dbclient.Dashboard.TranscriptExportJobs.GetStoredContaining(ctx, guildId, ticketIds)
+	if err != nil {
+		return err
+	}
+
+	for _, job := range jobs {
+		if err := deleteTranscriptExport(ctx, job, reason); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// expireTranscriptExports deletes archives of exports that completed more than TranscriptExportRetention ago
+func expireTranscriptExports(ctx context.Context) error {
+	jobs, err := dbclient.Dashboard.TranscriptExportJobs.GetExpired(ctx, time.Now().Add(-TranscriptExportRetention), transcriptExportExpiryBatchSize)
+	if err != nil {
+		return err
+	}
+
+	for _, job := range jobs {
+		if err := deleteTranscriptExport(ctx, job, "Export has expired"); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func deleteTranscriptExport(ctx context.Context, job dbclient.TranscriptExportJob, reason string) error {
+	if job.ObjectKey == nil {
+		return nil
+	}
+
+	// Removing an object that doesn't exist is not an error
+	if err := s3.S3Client.RemoveObject(ctx, s3.ExportBucket(), *job.ObjectKey, minio.RemoveObjectOptions{}); err != nil {
+		return err
+	}
+
+	return dbclient.Dashboard.TranscriptExportJobs.Expire(ctx, job.Id, reason)
+}
+
+func getTranscriptExportTickets(ctx context.Context, job dbclient.TranscriptExportJob) ([]database.Ticket, error) {
+	open := false
+	options := dbclient.TicketQueryOptions{
+		TicketQueryOptions: database.TicketQueryOptions{
+			GuildId: job.GuildId,
+			Open:    &open,
+			Order:   database.OrderTypeAscending,
+			Limit:   TranscriptExportMaxTickets + 1,
+		},
+		ClosedAfter:  job.Filter.ClosedAfter,
+		ClosedBefore: job.Filter.ClosedBefore,
+	}
+
+	if job.Filter.UserId != nil {
+		options.UserIds = []uint64{*job.Filter.UserId}
+	}
+
+	return dbclient.Dashboard.TicketQuery.GetByOptions(ctx, options)
+}
+
+func writeTranscriptArchive(ctx context.Context, job dbclient.TranscriptExportJob, tickets []database.Ticket, format chatreplica.Format, w io.Writer) error {
+	archive := zip.NewWriter(w)
+
+	for i, ticket := range tickets {
+		if ticket.HasTranscript {
+			if err := writeArchivedTranscript(ctx, archive, job.GuildId, ticket.Id, format); err != nil {
+				return err
+			}
+		}
+
+		if (i+1)%transcriptExportProgressInterval == 0 {
+			if err := dbclient.Dashboard.TranscriptExportJobs.UpdateProgress(ctx, job.Id, len(tickets), i+1); err != nil {
+				return err
+			}
+		}
+	}
+
+	return archive.Close()
+}
+
+func writeArchivedTranscript(ctx context.Context, archive *zip.Writer, guildId uint64, ticketId int, format chatreplica.Format) error {
+	transcript, err := fetchTranscript(ctx, guildId, ticketId)
+	if err != nil {
+		// The transcript may have been deleted since the ticket was closed
+		if errors.Is(err, archiverclient.ErrNotFound) {
+			return nil
+		}
+
+		return fmt.Errorf("failed to fetch transcript for ticket %d: %w", ticketId, err)
+	}
+
+	mirrors, err := dbclient.Dashboard.AttachmentMirrors.GetByTicket(ctx, guildId, ticketId)
+	if err != nil {
+		return err
+	}
+
+	// Always use the native renderer, to avoid flooding the render service. Attachments are copied into the archive,
+	// as links to them would expire long before the export is deleted.
+	name := fmt.Sprintf("ticket-%d.%s", ticketId, format)
+	return utils.WriteTranscriptBundle(ctx, archive, name, transcript, mirrors, guildId, ticketId, format)
+}
+
+func fetchTranscript(ctx context.Context, guildId uint64, ticketId int) (chatreplica.Transcript, error) {
+	ctx, cancel := context.WithTimeout(ctx, transcriptExportFetchTimeout)
+	defer cancel()
+
+	return utils.GetTranscript(ctx, guildId, ticketId)
+}
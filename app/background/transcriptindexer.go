@@ -0,0 +1,142 @@
+package background
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/TicketsBot-cloud/archiverclient"
+	dbclient "github.com/TicketsBot-cloud/dashboard/database"
+	"github.com/TicketsBot-cloud/dashboard/utils"
+	v2 "github.com/TicketsBot/logarchiver/pkg/model/v2"
+	"go.uber.org/zap"
+)
+
+const (
+	transcriptIndexInterval  = time.Second * 30
+	transcriptIndexBatchSize = 25
+
+	// transcriptIndexLease is how long a claimed ticket is hidden from other replicas
+	transcriptIndexLease = time.Minute * 5
+
+	// A ticket that fails to be indexed is retried with exponential backoff, starting at transcriptIndexRetryDelay,
+	// until it has failed transcriptIndexMaxAttempts times
+	transcriptIndexRetryDelay  = time.Minute
+	transcriptIndexMaxAttempts = 5
+)
+
+// RunTranscriptIndexer periodically adds newly archived transcripts, from guilds that have enabled transcript search,
+// to the search index. It is safe to run on every replica.
+func RunTranscriptIndexer(ctx context.Context, logger *zap.Logger) {
+	ticker := time.NewTicker(transcriptIndexInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := indexTranscripts(ctx, logger); err != nil {
+				logger.Error("Failed to index transcripts", zap.Error(err))
+			}
+		}
+	}
+}
+
+func indexTranscripts(ctx context.Context, logger *zap.Logger) error {
+	ctx, cancel := context.WithTimeout(ctx, transcriptIndexInterval)
+	defer cancel()
+
+	claimed, err := dbclient.Dashboard.TranscriptSearch.ClaimUnindexed(ctx, transcriptIndexBatchSize, transcriptIndexMaxAttempts, transcriptIndexLease)
+	if err != nil {
+		return err
+	}
+
+	for _, ticket := range claimed {
+		if err := indexTranscript(ctx, ticket.GuildId, ticket.TicketId); err != nil {
+			logger.Error(
+				"Failed to index transcript",
+				zap.Uint64("guild_id", ticket.GuildId),
+				zap.Int("ticket_id", ticket.TicketId),
+				zap.Error(err),
+			)
+
+			if err := dbclient.Dashboard.TranscriptSearch.Fail(ctx, ticket.GuildId, ticket.TicketId, transcriptIndexRetryDelay); err != nil {
+				logger.Error("Failed to record transcript indexing failure", zap.Error(err))
+			}
+		}
+	}
+
+	return nil
+}
+
+func indexTranscript(ctx context.Context, guildId uint64, ticketId int) error {
+	transcript, err := utils.ArchiverClient.Get(ctx, guildId, ticketId)
+	if err != nil {
+		// Mark the ticket as indexed, as there is nothing to index
+		if errors.Is(err, archiverclient.ErrNotFound) {
+			return dbclient.Dashboard.TranscriptSearch.Complete(ctx, guildId, ticketId, nil)
+		}
+
+		return err
+	}
+
+	return dbclient.Dashboard.TranscriptSearch.Complete(ctx, guildId, ticketId, searchMessages(transcript))
+}
+
+// searchMessages returns the searchable text of each message in the transcript, including the text of its embeds
+func searchMessages(transcript v2.Transcript) []dbclient.TranscriptSearchMessage {
+	seen := make(map[uint64]bool)
+
+	messages := make([]dbclient.TranscriptSearchMessage, 0, len(transcript.Messages))
+	for _, message := range transcript.Messages {
+		if seen[message.Id] {
+			continue
+		}
+
+		var content strings.Builder
+		appendText(&content, message.Content)
+
+		for _, embed := range message.Embeds {
+			appendText(&content, embed.Title)
+			appendText(&content, embed.Description)
+
+			for _, field := range embed.Fields {
+				appendText(&content, field.Name)
+				appendText(&content, field.Value)
+			}
+
+			if embed.Footer != nil {
+				appendText(&content, embed.Footer.Text)
+			}
+		}
+
+		if content.Len() == 0 {
+			continue
+		}
+
+		seen[message.Id] = true
+		messages = append(messages, dbclient.TranscriptSearchMessage{
+			MessageId: message.Id,
+			AuthorId:  message.AuthorId,
+			Content:   content.String(),
+			Timestamp: message.Timestamp,
+		})
+	}
+
+	return messages
+}
+
+func appendText(builder *strings.Builder, text string) {
+	text = strings.TrimSpace(text)
+	if text == "" {
+		return
+	}
+
+	if builder.Len() > 0 {
+		builder.WriteString("\n")
+	}
+
+	builder.WriteString(text)
+}
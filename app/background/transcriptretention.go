@@ -0,0 +1,120 @@
+package background
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/TicketsBot-cloud/archiverclient"
+	dbclient "github.com/TicketsBot-cloud/dashboard/database"
+	"github.com/TicketsBot-cloud/dashboard/redis"
+	"github.com/TicketsBot-cloud/dashboard/utils"
+	"go.uber.org/zap"
+)
+
+const (
+	transcriptRetentionInterval    = time.Minute * 10
+	transcriptRetentionBatchSize   = 100
+	transcriptRetentionLease       = time.Minute * 15
+	transcriptRetentionRetryDelay  = time.Minute * 10
+	transcriptRetentionMaxAttempts = 5
+
+	// TranscriptRetentionGracePeriod is how long after a policy is changed that it begins to be enforced, giving admins
+	// time to review what would be deleted
+	TranscriptRetentionGracePeriod = time.Hour * 24
+)
+
+// RunTranscriptRetention periodically deletes transcripts that have outlived their guild's retention policy. It is
+// safe to run on every replica, as each transcript is claimed by exactly one replica.
+func RunTranscriptRetention(ctx context.Context, logger *zap.Logger) {
+	ticker := time.NewTicker(transcriptRetentionInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := sweepExpiredTranscripts(ctx, logger); err != nil {
+				logger.Error("Failed to sweep expired transcripts", zap.Error(err))
+			}
+		}
+	}
+}
+
+func sweepExpiredTranscripts(ctx context.Context, logger *zap.Logger) error {
+	ctx, cancel := context.WithTimeout(ctx, transcriptRetentionInterval)
+	defer cancel()
+
+	claimed, err := dbclient.Dashboard.TranscriptRetention.ClaimExpired(
+		ctx,
+		TranscriptRetentionGracePeriod,
+		transcriptRetentionLease,
+		transcriptRetentionMaxAttempts,
+		transcriptRetentionBatchSize,
+	)
+	if err != nil {
+		return err
+	}
+
+	var deleted int
+
+	deletedTickets := make(map[uint64][]int)
+	for _, transcript := range claimed {
+		if err := deleteTranscript(ctx, transcript.GuildId, transcript.TicketId); err != nil {
+			logger.Error(
+				"Failed to delete expired transcript",
+				zap.Uint64("guild_id", transcript.GuildId),
+				zap.Int("ticket_id", transcript.TicketId),
+				zap.Error(err),
+			)
+
+			if err := dbclient.Dashboard.TranscriptRetention.Fail(ctx, transcript.GuildId, transcript.TicketId, transcriptRetentionRetryDelay); err != nil {
+				logger.Error("Failed to mark expired transcript deletion as failed", zap.Error(err))
+			}
+
+			continue
+		}
+
+		deleted++
+		deletedTickets[transcript.GuildId] = append(deletedTickets[transcript.GuildId], transcript.TicketId)
+	}
+
+	// Exports created before the transcripts were deleted still contain them
+	for guildId, ticketIds := range deletedTickets {
+		if err := DeleteTranscriptExports(ctx, guildId, ticketIds, "Transcripts in this export have since been deleted"); err != nil {
+			logger.Error("Failed to delete transcript exports", zap.Uint64("guild_id", guildId), zap.Error(err))
+		}
+	}
+
+	if deleted > 0 {
+		logger.Info("Deleted expired transcripts", zap.Int("count", deleted), zap.Int("failed", len(claimed)-deleted))
+	}
+
+	return nil
+}
+
+func deleteTranscript(ctx context.Context, guildId uint64, ticketId int) error {
+	if err := utils.ArchiveRetriever.DeleteTicket(ctx, guildId, ticketId); err != nil {
+		// Treat transcripts that were already removed from the archive as deleted, rather than retrying forever
+		if _, getErr := utils.ArchiverClient.Get(ctx, guildId, ticketId); !errors.Is(getErr, archiverclient.ErrNotFound) {
+			return err
+		}
+	}
+
+	if err := DeleteMirroredAttachments(ctx, guildId, ticketId, nil); err != nil {
+		return err
+	}
+
+	if err := redis.Client.InvalidateRenderedTranscript(ctx, guildId, ticketId); err != nil {
+		return err
+	}
+
+	if err := dbclient.Dashboard.TranscriptSearch.Invalidate(ctx, guildId, ticketId); err != nil {
+		return err
+	}
+
+	// Only mark the ticket once everything else has been deleted, as nothing revisits tickets without a transcript. The
+	// indexer skips them, so it will not be indexed again.
+	return dbclient.Dashboard.TranscriptRetention.Complete(ctx, guildId, ticketId)
+}
@@ -0,0 +1,108 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/TicketsBot-cloud/dashboard/app"
+	dbclient "github.com/TicketsBot-cloud/dashboard/database"
+	"github.com/TicketsBot-cloud/dashboard/utils"
+	"github.com/TicketsBot-cloud/dashboard/utils/types"
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+)
+
+const labelLimit = 50
+
+type labelBody struct {
+	Name   string       `json:"name" validate:"required,min=1,max=32"`
+	Colour types.Colour `json:"colour" validate:"gte=0,lte=16777215"`
+}
+
+var validate = validator.New()
+
+func CreateLabel(c *gin.Context) {
+	guildId := c.Keys["guildid"].(uint64)
+
+	var data labelBody
+	if err := c.BindJSON(&data); err != nil {
+		c.JSON(400, utils.ErrorStr("Invalid request body"))
+		return
+	}
+
+	if ok := data.validate(c); !ok {
+		return
+	}
+
+	count, err := dbclient.Dashboard.TicketLabels.GetCount(c, guildId)
+	if err != nil {
+		_ = c.AbortWithError(http.StatusInternalServerError, app.NewServerError(err))
+		return
+	}
+
+	if count >= labelLimit {
+		c.JSON(400, utils.ErrorStr("Label limit (%d) reached", labelLimit))
+		return
+	}
+
+	if ok := ensureNameUnique(c, guildId, 0, data.Name); !ok {
+		return
+	}
+
+	id, err := dbclient.Dashboard.TicketLabels.Create(c, guildId, data.Name, data.Colour.Uint32())
+	if err != nil {
+		// Another label with the same name was created concurrently
+		if errors.Is(err, dbclient.ErrLabelNameTaken) {
+			c.JSON(400, utils.ErrorStr("A label with this name already exists"))
+			return
+		}
+
+		_ = c.AbortWithError(http.StatusInternalServerError, app.NewServerError(err))
+		return
+	}
+
+	c.JSON(200, dbclient.TicketLabel{
+		Id:      id,
+		GuildId: guildId,
+		Name:    data.Name,
+		Colour:  data.Colour.Uint32(),
+	})
+}
+
+// validate writes the error response itself, returning false if the body is invalid
+func (b *labelBody) validate(c *gin.Context) bool {
+	b.Name = strings.TrimSpace(b.Name)
+
+	if err := validate.Struct(b); err != nil {
+		var validationErrors validator.ValidationErrors
+		if !errors.As(err, &validationErrors) {
+			c.JSON(500, utils.ErrorStr("An error occurred while validating the label"))
+			return false
+		}
+
+		formatted := "Your input contained the following errors:\n" + utils.FormatValidationErrors(validationErrors)
+		c.JSON(400, utils.ErrorStr(formatted))
+		return false
+	}
+
+	return true
+}
+
+// ensureNameUnique writes the error response itself, returning false if another label already uses the name
+func ensureNameUnique(c *gin.Context, guildId uint64, labelId int, name string) bool {
+	labels, err := dbclient.Dashboard.TicketLabels.GetByGuild(c, guildId)
+	if err != nil {
+		_ = c.AbortWithError(http.StatusInternalServerError, app.NewServerError(err))
+		return false
+	}
+
+	for _, label := range labels {
+		if label.Id != labelId && strings.EqualFold(label.Name, name) {
+			c.JSON(400, utils.ErrorStr("A label with this name already exists"))
+			return false
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,35 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/TicketsBot-cloud/dashboard/app"
+	dbclient "github.com/TicketsBot-cloud/dashboard/database"
+	"github.com/TicketsBot-cloud/dashboard/utils"
+	"github.com/gin-gonic/gin"
+)
+
+// DeleteLabel also removes the label from any tickets it is assigned to
+func DeleteLabel(c *gin.Context) {
+	guildId := c.Keys["guildid"].(uint64)
+
+	labelId, err := strconv.Atoi(c.Param("labelid"))
+	if err != nil {
+		c.JSON(400, utils.ErrorStr("Invalid label ID"))
+		return
+	}
+
+	ok, err := dbclient.Dashboard.TicketLabels.Delete(c, guildId, labelId)
+	if err != nil {
+		_ = c.AbortWithError(http.StatusInternalServerError, app.NewServerError(err))
+		return
+	}
+
+	if !ok {
+		c.JSON(404, utils.ErrorStr("Label not found"))
+		return
+	}
+
+	c.Status(204)
+}
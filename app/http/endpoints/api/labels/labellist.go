@@ -0,0 +1,21 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/TicketsBot-cloud/dashboard/app"
+	dbclient "github.com/TicketsBot-cloud/dashboard/database"
+	"github.com/gin-gonic/gin"
+)
+
+func ListLabels(c *gin.Context) {
+	guildId := c.Keys["guildid"].(uint64)
+
+	labels, err := dbclient.Dashboard.TicketLabels.GetByGuild(c, guildId)
+	if err != nil {
+		_ = c.AbortWithError(http.StatusInternalServerError, app.NewServerError(err))
+		return
+	}
+
+	c.JSON(200, labels)
+}
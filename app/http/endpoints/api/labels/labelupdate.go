@@ -0,0 +1,66 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/TicketsBot-cloud/dashboard/app"
+	dbclient "github.com/TicketsBot-cloud/dashboard/database"
+	"github.com/TicketsBot-cloud/dashboard/utils"
+	"github.com/gin-gonic/gin"
+)
+
+func UpdateLabel(c *gin.Context) {
+	guildId := c.Keys["guildid"].(uint64)
+
+	labelId, err := strconv.Atoi(c.Param("labelid"))
+	if err != nil {
+		c.JSON(400, utils.ErrorStr("Invalid label ID"))
+		return
+	}
+
+	var data labelBody
+	if err := c.BindJSON(&data); err != nil {
+		c.JSON(400, utils.ErrorStr("Invalid request body"))
+		return
+	}
+
+	if ok := data.validate(c); !ok {
+		return
+	}
+
+	_, ok, err := dbclient.Dashboard.TicketLabels.Get(c, guildId, labelId)
+	if err != nil {
+		_ = c.AbortWithError(http.StatusInternalServerError, app.NewServerError(err))
+		return
+	}
+
+	if !ok {
+		c.JSON(404, utils.ErrorStr("Label not found"))
+		return
+	}
+
+	if ok := ensureNameUnique(c, guildId, labelId, data.Name); !ok {
+		return
+	}
+
+	label := dbclient.TicketLabel{
+		Id:      labelId,
+		GuildId: guildId,
+		Name:    data.Name,
+		Colour:  data.Colour.Uint32(),
+	}
+
+	if err := dbclient.Dashboard.TicketLabels.Update(c, label); err != nil {
+		if errors.Is(err, dbclient.ErrLabelNameTaken) {
+			c.JSON(400, utils.ErrorStr("A label with this name already exists"))
+			return
+		}
+
+		_ = c.AbortWithError(http.StatusInternalServerError, app.NewServerError(err))
+		return
+	}
+
+	c.JSON(200, label)
+}
@@ -37,6 +37,18 @@ func multiPanelIntoMessageData(panel database.MultiPanel, isPremium bool) multiP
 }
 
 func (d *multiPanelMessageData) send(ctx *botcontext.BotContext, panels []database.Panel) (uint64, error) {
+	// TODO: Use proper context
+	msg, err := rest.CreateMessage(context.Background(), ctx.Token, ctx.RateLimiter, d.ChannelId, d.messageData(panels))
+	if err != nil {
+		return 0, err
+	}
+
+	return msg.Id, nil
+}
+
+// messageData builds the message that is sent to the multi-panel channel, with a button or select menu option for
+// each of the panels
+func (d *multiPanelMessageData) messageData(panels []database.Panel) rest.CreateMessageData {
 	if !d.IsPremium {
 		// TODO: Don't harcode
 		d.Embed.SetFooter("Powered by ticketsbot.cloud", "https://ticketsbot.cloud/assets/img/logo.png")
@@ -109,16 +121,8 @@ func (d *multiPanelMessageData) send(ctx *botcontext.BotContext, panels []databa
 		components = rows
 	}
 
-	data := rest.CreateMessageData{
+	return rest.CreateMessageData{
 		Embeds:     []*embed.Embed{d.Embed},
 		Components: components,
 	}
-
-	// TODO: Use proper context
-	msg, err := rest.CreateMessage(context.Background(), ctx.Token, ctx.RateLimiter, d.ChannelId, data)
-	if err != nil {
-		return 0, err
-	}
-
-	return msg.Id, nil
 }
@@ -0,0 +1,174 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+	"unicode/utf8"
+
+	"github.com/TicketsBot-cloud/dashboard/app"
+	"github.com/TicketsBot-cloud/dashboard/app/background"
+	"github.com/TicketsBot-cloud/dashboard/botcontext"
+	"github.com/TicketsBot-cloud/dashboard/database"
+	"github.com/TicketsBot-cloud/dashboard/log"
+	"github.com/TicketsBot-cloud/dashboard/utils"
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+type panelAvailabilityBody struct {
+	Timezone      string                         `json:"timezone"`
+	Windows       []database.AvailabilityWindow  `json:"windows"`
+	Holidays      []database.AvailabilityHoliday `json:"holidays"`
+	ClosedMessage *string                        `json:"closed_message"`
+}
+
+type panelAvailabilityResponse struct {
+	panelAvailabilityBody
+	Enabled        bool       `json:"enabled"`
+	Open           bool       `json:"open"`
+	NextTransition *time.Time `json:"next_transition"`
+}
+
+// Discord's limit on message content
+const maxClosedMessageLength = 2000
+
+func GetPanelAvailability(c *gin.Context) {
+	panelId, ok := getGuildPanelId(c)
+	if !ok {
+		return
+	}
+
+	availability, ok, err := database.Dashboard.PanelAvailability.Get(c, panelId)
+	if err != nil {
+		_ = c.AbortWithError(http.StatusInternalServerError, app.NewServerError(err))
+		return
+	}
+
+	if !ok {
+		c.JSON(200, panelAvailabilityResponse{
+			panelAvailabilityBody: panelAvailabilityBody{
+				Windows:  []database.AvailabilityWindow{},
+				Holidays: []database.AvailabilityHoliday{},
+			},
+			Open: true,
+		})
+		return
+	}
+
+	c.JSON(200, buildPanelAvailabilityResponse(availability))
+}
+
+func SetPanelAvailability(c *gin.Context) {
+	panelId, ok := getGuildPanelId(c)
+	if !ok {
+		return
+	}
+
+	var body panelAvailabilityBody
+	if err := c.BindJSON(&body); err != nil {
+		c.JSON(400, utils.ErrorStr("Invalid request body"))
+		return
+	}
+
+	if body.ClosedMessage != nil && *body.ClosedMessage == "" {
+		body.ClosedMessage = nil
+	}
+
+	if body.ClosedMessage != nil && utf8.RuneCountInString(*body.ClosedMessage) > maxClosedMessageLength {
+		c.JSON(400, utils.ErrorStr(fmt.Sprintf("Closed message must be %d characters or less", maxClosedMessageLength)))
+		return
+	}
+
+	if body.Windows == nil {
+		body.Windows = []database.AvailabilityWindow{}
+	}
+
+	if body.Holidays == nil {
+		body.Holidays = []database.AvailabilityHoliday{}
+	}
+
+	availability := database.PanelAvailability{
+		PanelId: panelId,
+		Schedule: database.PanelAvailabilitySchedule{
+			Timezone: body.Timezone,
+			Windows:  body.Windows,
+			Holidays: body.Holidays,
+		},
+		ClosedMessage: body.ClosedMessage,
+	}
+
+	if err := availability.Schedule.Validate(); err != nil {
+		c.JSON(400, utils.ErrorJson(err))
+		return
+	}
+
+	// The scheduler applies the new schedule on its next run
+	if err := database.Dashboard.PanelAvailability.Set(c, availability); err != nil {
+		_ = c.AbortWithError(http.StatusInternalServerError, app.NewServerError(err))
+		return
+	}
+
+	c.JSON(200, buildPanelAvailabilityResponse(availability))
+}
+
+func DeletePanelAvailability(c *gin.Context) {
+	guildId := c.Keys["guildid"].(uint64)
+
+	panelId, ok := getGuildPanelId(c)
+	if !ok {
+		return
+	}
+
+	reopened, err := database.Dashboard.PanelAvailability.Delete(c, panelId)
+	if err != nil {
+		_ = c.AbortWithError(http.StatusInternalServerError, app.NewServerError(err))
+		return
+	}
+
+	// Without a schedule, nothing else will re-enable the panel's button. The panel has already been re-enabled, so a
+	// failure to edit the messages is logged rather than reported, and is fixed by the next resend or edit of the panel.
+	if reopened {
+		if err := refreshPanelMessages(c, guildId, panelId); err != nil {
+			log.Logger.Error("Failed to update panel messages", zap.Int("panel_id", panelId), zap.Error(err))
+		}
+	}
+
+	c.JSON(200, utils.SuccessResponse)
+}
+
+func refreshPanelMessages(ctx context.Context, guildId uint64, panelId int) error {
+	panel, err := database.Client.Panel.GetById(ctx, panelId)
+	if err != nil {
+		return err
+	}
+
+	botContext, err := botcontext.ContextForGuild(guildId)
+	if err != nil {
+		return err
+	}
+
+	return background.UpdatePanelMessages(ctx, botContext, panel, nil)
+}
+
+func buildPanelAvailabilityResponse(availability database.PanelAvailability) panelAvailabilityResponse {
+	now := time.Now()
+
+	response := panelAvailabilityResponse{
+		panelAvailabilityBody: panelAvailabilityBody{
+			Timezone:      availability.Schedule.Timezone,
+			Windows:       availability.Schedule.Windows,
+			Holidays:      availability.Schedule.Holidays,
+			ClosedMessage: availability.ClosedMessage,
+		},
+		Enabled: true,
+		Open:    availability.Schedule.IsOpen(now),
+	}
+
+	if next, ok := availability.Schedule.NextTransition(now); ok {
+		response.NextTransition = &next
+	}
+
+	return response
+}
@@ -0,0 +1,299 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/TicketsBot-cloud/common/permission"
+	"github.com/TicketsBot-cloud/common/premium"
+	"github.com/TicketsBot-cloud/dashboard/app"
+	"github.com/TicketsBot-cloud/dashboard/app/http/validation"
+	"github.com/TicketsBot-cloud/dashboard/botcontext"
+	dbclient "github.com/TicketsBot-cloud/dashboard/database"
+	"github.com/TicketsBot-cloud/dashboard/rpc"
+	"github.com/TicketsBot-cloud/dashboard/rpc/cache"
+	"github.com/TicketsBot-cloud/dashboard/utils"
+	"github.com/TicketsBot-cloud/dashboard/utils/types"
+	"github.com/TicketsBot-cloud/database"
+	"github.com/gin-gonic/gin"
+	cache2 "github.com/rxdn/gdl/cache"
+	"github.com/rxdn/gdl/objects/interaction/component"
+	"golang.org/x/sync/errgroup"
+)
+
+type panelCloneBody struct {
+	GuildId    *uint64 `json:"guild_id,string"`    // Defaults to the panel's own guild
+	ChannelId  *uint64 `json:"channel_id,string"`  // Overrides the mapped panel channel
+	CategoryId *uint64 `json:"category_id,string"` // Overrides the mapped ticket category
+}
+
+// ClonePanel creates a copy of a panel, including its mentions, teams, forms, access control list, welcome message and
+// SLA, and sends its message. The copy can be created in another guild that the user administers, in which case
+// channels, roles, support teams, forms and custom emojis are mapped to those with the same name in that guild.
+// References that can't be mapped are left out of the copy and listed in the response.
+func ClonePanel(c *gin.Context) {
+	guildId := c.Keys["guildid"].(uint64)
+	userId := c.Keys["userid"].(uint64)
+
+	panelId, err := strconv.Atoi(c.Param("panelid"))
+	if err != nil {
+		c.JSON(400, utils.ErrorStr("Missing panel ID"))
+		return
+	}
+
+	var body panelCloneBody
+	if err := c.BindJSON(&body); err != nil {
+		c.JSON(400, utils.ErrorStr("Invalid request body"))
+		return
+	}
+
+	source, err := dbclient.Client.Panel.GetById(c, panelId)
+	if err != nil {
+		_ = c.AbortWithError(http.StatusInternalServerError, app.NewServerError(err))
+		return
+	}
+
+	if source.PanelId == 0 || source.GuildId != guildId {
+		c.JSON(404, utils.ErrorStr("Panel not found"))
+		return
+	}
+
+	targetGuildId := guildId
+	if body.GuildId != nil && *body.GuildId != guildId {
+		targetGuildId = *body.GuildId
+
+		if _, err := cache.Instance.GetGuildOwner(c, targetGuildId); err != nil {
+			if errors.Is(err, cache2.ErrNotFound) {
+				c.JSON(404, utils.ErrorStr("Guild not found"))
+			} else {
+				_ = c.AbortWithError(http.StatusInternalServerError, app.NewServerError(err))
+			}
+
+			return
+		}
+
+		permissionLevel, err := utils.GetPermissionLevel(c, targetGuildId, userId)
+		if err != nil {
+			_ = c.AbortWithError(http.StatusInternalServerError, app.NewServerError(err))
+			return
+		}
+
+		if permissionLevel < permission.Admin {
+			c.JSON(403, utils.ErrorStr("You must be an administrator of the server that you are copying the panel to"))
+			return
+		}
+	}
+
+	botContext, err := botcontext.ContextForGuild(targetGuildId)
+	if err != nil {
+		_ = c.AbortWithError(http.StatusInternalServerError, app.NewServerError(err))
+		return
+	}
+
+	premiumTier, err := rpc.PremiumClient.GetTierByGuildId(c, targetGuildId, false, botContext.Token, botContext.RateLimiter)
+	if err != nil {
+		_ = c.AbortWithError(http.StatusInternalServerError, app.NewServerError(err))
+		return
+	}
+
+	exceeded, err := exceedsPanelQuota(c, targetGuildId, premiumTier, 1)
+	if err != nil {
+		_ = c.AbortWithError(http.StatusInternalServerError, app.NewServerError(err))
+		return
+	}
+
+	if exceeded {
+		c.JSON(402, utils.ErrorStr("You have exceeded your panel quota. Purchase premium to unlock more panels."))
+		return
+	}
+
+	data, err := loadPanelBody(c, source)
+	if err != nil {
+		_ = c.AbortWithError(http.StatusInternalServerError, app.NewServerError(err))
+		return
+	}
+
+	unmapped := make([]unmappedField, 0)
+	if targetGuildId != guildId {
+		sourceBotContext, err := botcontext.ContextForGuild(guildId)
+		if err != nil {
+			_ = c.AbortWithError(http.StatusInternalServerError, app.NewServerError(err))
+			return
+		}
+
+		ctx, cancel := app.DefaultContext()
+		defer cancel()
+
+		sourceData, err := loadPanelGuildData(ctx, sourceBotContext, guildId)
+		if err != nil {
+			_ = c.AbortWithError(http.StatusInternalServerError, app.NewServerError(err))
+			return
+		}
+
+		targetData, err := loadPanelGuildData(ctx, botContext, targetGuildId)
+		if err != nil {
+			_ = c.AbortWithError(http.StatusInternalServerError, app.NewServerError(err))
+			return
+		}
+
+		remapper := newPanelRemapper(sourceData, targetData)
+		data = remapper.remap(data)
+		unmapped = remapper.unmapped
+	}
+
+	if body.ChannelId != nil {
+		data.ChannelId = *body.ChannelId
+		unmapped = withoutUnmappedField(unmapped, "channel_id")
+	}
+
+	if body.CategoryId != nil {
+		data.CategoryId = *body.CategoryId
+		unmapped = withoutUnmappedField(unmapped, "category_id")
+	}
+
+	// A panel can't be created without a channel or category, so they must be chosen by the user instead
+	if data.ChannelId == 0 || data.CategoryId == 0 {
+		c.JSON(400, gin.H{
+			"success":  false,
+			"error":    "The panel channel and ticket category could not be found in the target server, please choose them",
+			"unmapped": unmapped,
+		})
+		return
+	}
+
+	clonedId, err := createPanel(c, botContext, targetGuildId, premiumTier > premium.None, data)
+	if err != nil {
+		var validationError *validation.InvalidInputError
+		if errors.As(err, &validationError) {
+			c.JSON(400, gin.H{
+				"success":  false,
+				"error":    validationError.Error(),
+				"unmapped": unmapped,
+			})
+		} else {
+			_ = c.AbortWithError(http.StatusInternalServerError, app.NewServerError(err))
+		}
+
+		return
+	}
+
+	sla, ok, err := dbclient.Dashboard.PanelSla.Get(c, panelId)
+	if err != nil {
+		_ = c.AbortWithError(http.StatusInternalServerError, app.NewServerError(err))
+		return
+	}
+
+	if ok {
+		sla.PanelId = clonedId
+		if err := dbclient.Dashboard.PanelSla.Set(c, sla); err != nil {
+			_ = c.AbortWithError(http.StatusInternalServerError, app.NewServerError(err))
+			return
+		}
+	}
+
+	c.JSON(200, gin.H{
+		"success":  true,
+		"guild_id": strconv.FormatUint(targetGuildId, 10),
+		"panel_id": clonedId,
+		"unmapped": unmapped,
+	})
+}
+
+// loadPanelBody reads a stored panel and all of its relations back into the form that it was created from
+func loadPanelBody(ctx context.Context, panel database.Panel) (panelBody, error) {
+	data := panelBody{
+		ChannelId:        panel.ChannelId,
+		Title:            panel.Title,
+		Content:          panel.Content,
+		Colour:           uint32(panel.Colour),
+		CategoryId:       panel.TargetCategory,
+		Emoji:            types.NewEmoji(panel.EmojiName, panel.EmojiId),
+		WithDefaultTeam:  panel.WithDefaultTeam,
+		ImageUrl:         panel.ImageUrl,
+		ThumbnailUrl:     panel.ThumbnailUrl,
+		ButtonStyle:      component.ButtonStyle(panel.ButtonStyle),
+		ButtonLabel:      panel.ButtonLabel,
+		FormId:           panel.FormId,
+		NamingScheme:     panel.NamingScheme,
+		Disabled:         panel.Disabled,
+		ExitSurveyFormId: panel.ExitSurveyFormId,
+		PendingCategory:  panel.PendingCategory,
+	}
+
+	group, _ := errgroup.WithContext(ctx)
+
+	var mentionUser, mentionHere bool
+	group.Go(func() (err error) {
+		mentionUser, err = dbclient.Client.PanelUserMention.ShouldMentionUser(ctx, panel.PanelId)
+		return
+	})
+
+	group.Go(func() (err error) {
+		mentionHere, err = dbclient.Client.PanelHereMention.ShouldMentionHere(ctx, panel.PanelId)
+		return
+	})
+
+	var roleMentions []uint64
+	group.Go(func() (err error) {
+		roleMentions, err = dbclient.Client.PanelRoleMentions.GetRoles(ctx, panel.PanelId)
+		return
+	})
+
+	group.Go(func() (err error) {
+		data.Teams, err = dbclient.Client.PanelTeams.GetTeamIds(ctx, panel.PanelId)
+		return
+	})
+
+	group.Go(func() (err error) {
+		data.AccessControlList, err = dbclient.Client.PanelAccessControlRules.GetAll(ctx, panel.PanelId)
+		return
+	})
+
+	if panel.WelcomeMessageEmbed != nil {
+		group.Go(func() error {
+			embed, err := dbclient.Client.Embeds.GetEmbed(ctx, *panel.WelcomeMessageEmbed)
+			if err != nil {
+				return err
+			}
+
+			fields, err := dbclient.Client.EmbedFields.GetFieldsForEmbed(ctx, *panel.WelcomeMessageEmbed)
+			if err != nil {
+				return err
+			}
+
+			data.WelcomeMessage = types.NewCustomEmbed(&embed, fields)
+			return nil
+		})
+	}
+
+	if err := group.Wait(); err != nil {
+		return panelBody{}, err
+	}
+
+	if mentionUser {
+		data.Mentions = append(data.Mentions, "user")
+	}
+
+	if mentionHere {
+		data.Mentions = append(data.Mentions, "here")
+	}
+
+	for _, roleId := range roleMentions {
+		data.Mentions = append(data.Mentions, strconv.FormatUint(roleId, 10))
+	}
+
+	return data, nil
+}
+
+func withoutUnmappedField(unmapped []unmappedField, field string) []unmappedField {
+	filtered := make([]unmappedField, 0, len(unmapped))
+	for _, f := range unmapped {
+		if f.Field != field {
+			filtered = append(filtered, f)
+		}
+	}
+
+	return filtered
+}
@@ -93,48 +93,19 @@ func CreatePanel(c *gin.Context) {
 		return
 	}
 
-	if premiumTier == premium.None {
-		panels, err := dbclient.Client.Panel.GetByGuild(c, guildId)
-		if err != nil {
-			_ = c.AbortWithError(http.StatusInternalServerError, app.NewServerError(err))
-			return
-		}
-
-		if len(panels) >= freePanelLimit {
-			c.JSON(402, utils.ErrorStr("You have exceeded your panel quota. Purchase premium to unlock more panels."))
-			return
-		}
-	}
-
-	// Apply defaults
-	ApplyPanelDefaults(&data)
-
-	ctx, cancel := app.DefaultContext()
-	defer cancel()
-
-	channels, err := botContext.GetGuildChannels(ctx, guildId)
+	exceeded, err := exceedsPanelQuota(c, guildId, premiumTier, 1)
 	if err != nil {
 		_ = c.AbortWithError(http.StatusInternalServerError, app.NewServerError(err))
 		return
 	}
 
-	roles, err := botContext.GetGuildRoles(ctx, guildId)
-	if err != nil {
-		_ = c.AbortWithError(http.StatusInternalServerError, app.NewServerError(err))
+	if exceeded {
+		c.JSON(402, utils.ErrorStr("You have exceeded your panel quota. Purchase premium to unlock more panels."))
 		return
 	}
 
-	// Do custom validation
-	validationContext := PanelValidationContext{
-		Data:       data,
-		GuildId:    guildId,
-		IsPremium:  premiumTier > premium.None,
-		BotContext: botContext,
-		Channels:   channels,
-		Roles:      roles,
-	}
-
-	if err := ValidatePanelBody(validationContext); err != nil {
+	panelId, err := createPanel(c, botContext, guildId, premiumTier > premium.None, data)
+	if err != nil {
 		var validationError *validation.InvalidInputError
 		if errors.As(err, &validationError) {
 			c.JSON(400, utils.ErrorStr(validationError.Error()))
@@ -145,53 +116,64 @@ func CreatePanel(c *gin.Context) {
 		return
 	}
 
-	// Do tag validation
-	if err := validate.Struct(data); err != nil {
-		var validationErrors validator.ValidationErrors
-		if ok := errors.As(err, &validationErrors); !ok {
-			c.JSON(500, utils.ErrorStr("An error occurred while validating the panel"))
-			return
-		}
+	c.JSON(200, gin.H{
+		"success":  true,
+		"panel_id": panelId,
+	})
+}
 
-		formatted := "Your input contained the following errors:\n" + utils.FormatValidationErrors(validationErrors)
-		c.JSON(400, utils.ErrorStr(formatted))
-		return
+// exceedsPanelQuota returns whether creating count more panels would take the guild over the number of panels that its
+// premium tier allows
+func exceedsPanelQuota(ctx context.Context, guildId uint64, tier premium.PremiumTier, count int) (bool, error) {
+	if tier > premium.None {
+		return false, nil
 	}
 
-	customId, err := utils.RandString(30)
+	panels, err := dbclient.Client.Panel.GetByGuild(ctx, guildId)
 	if err != nil {
-		_ = c.AbortWithError(http.StatusInternalServerError, app.NewServerError(err))
-		return
+		return false, err
 	}
 
-	messageData := data.IntoPanelMessageData(customId, premiumTier > premium.None)
-	msgId, err := messageData.send(botContext)
+	return len(panels)+count > freePanelLimit, nil
+}
+
+// createPanel applies defaults to and validates the panel, sends the panel message, and then stores the panel.
+// Problems with the panel, including Discord refusing to send the message, are returned as a
+// *validation.InvalidInputError.
+func createPanel(c context.Context, botContext *botcontext.BotContext, guildId uint64, isPremium bool, data panelBody) (int, error) {
+	ctx, cancel := app.DefaultContext()
+	defer cancel()
+
+	channels, err := botContext.GetGuildChannels(ctx, guildId)
 	if err != nil {
-		var unwrapped request.RestError
-		if errors.As(err, &unwrapped) {
-			if unwrapped.StatusCode == http.StatusForbidden {
-				c.JSON(400, utils.ErrorStr("I do not have permission to send messages in the specified channel"))
-			} else {
-				c.JSON(400, utils.ErrorStr("Error sending panel message: "+unwrapped.ApiError.Message))
-			}
-		} else {
-			_ = c.AbortWithError(http.StatusInternalServerError, app.NewServerError(err))
-		}
+		return 0, err
+	}
 
-		return
+	roles, err := botContext.GetGuildRoles(ctx, guildId)
+	if err != nil {
+		return 0, err
 	}
 
-	var emojiId *uint64
-	var emojiName *string
-	{
-		emoji := data.getEmoji()
-		if emoji != nil {
-			emojiName = &emoji.Name
+	data, createOptions, err := preparePanel(PanelValidationContext{
+		Data:       data,
+		GuildId:    guildId,
+		IsPremium:  isPremium,
+		BotContext: botContext,
+		Channels:   channels,
+		Roles:      roles,
+	})
+	if err != nil {
+		return 0, err
+	}
 
-			if emoji.Id.Value != 0 {
-				emojiId = &emoji.Id.Value
-			}
-		}
+	customId, err := utils.RandString(30)
+	if err != nil {
+		return 0, err
+	}
+
+	msgId, err := sendPanelMessage(botContext, data, customId, isPremium)
+	if err != nil {
+		return 0, err
 	}
 
 	// Store welcome message embed first
@@ -202,37 +184,38 @@ func CreatePanel(c *gin.Context) {
 
 		id, err := dbclient.Client.Embeds.CreateWithFields(c, embed, fields)
 		if err != nil {
-			_ = c.AbortWithError(http.StatusInternalServerError, app.NewServerError(err))
-			return
+			return 0, err
 		}
 
 		welcomeMessageEmbed = &id
 	}
 
 	// Store in DB
-	panel := database.Panel{
-		MessageId:           msgId,
-		ChannelId:           data.ChannelId,
-		GuildId:             guildId,
-		Title:               data.Title,
-		Content:             data.Content,
-		Colour:              int32(data.Colour),
-		TargetCategory:      data.CategoryId,
-		EmojiId:             emojiId,
-		EmojiName:           emojiName,
-		WelcomeMessageEmbed: welcomeMessageEmbed,
-		WithDefaultTeam:     data.WithDefaultTeam,
-		CustomId:            customId,
-		ImageUrl:            data.ImageUrl,
-		ThumbnailUrl:        data.ThumbnailUrl,
-		ButtonStyle:         int(data.ButtonStyle),
-		ButtonLabel:         data.ButtonLabel,
-		FormId:              data.FormId,
-		NamingScheme:        data.NamingScheme,
-		ForceDisabled:       false,
-		Disabled:            data.Disabled,
-		ExitSurveyFormId:    data.ExitSurveyFormId,
-		PendingCategory:     data.PendingCategory,
+	panel := data.intoDatabasePanel(guildId, customId, msgId, welcomeMessageEmbed)
+	return storePanel(c, panel, createOptions)
+}
+
+// preparePanel applies defaults to and validates validationContext.Data, and resolves its mentions. The panel with
+// defaults applied is returned.
+func preparePanel(validationContext PanelValidationContext) (panelBody, panelCreateOptions, error) {
+	// Apply defaults
+	ApplyPanelDefaults(&validationContext.Data)
+	data := validationContext.Data
+
+	// Do custom validation
+	if err := ValidatePanelBody(validationContext); err != nil {
+		return panelBody{}, panelCreateOptions{}, err
+	}
+
+	// Do tag validation
+	if err := validate.Struct(data); err != nil {
+		var validationErrors validator.ValidationErrors
+		if ok := errors.As(err, &validationErrors); !ok {
+			return panelBody{}, panelCreateOptions{}, app.NewError(err, "An error occurred while validating the panel")
+		}
+
+		formatted := "Your input contained the following errors:\n" + utils.FormatValidationErrors(validationErrors)
+		return panelBody{}, panelCreateOptions{}, validation.NewInvalidInputError(formatted)
 	}
 
 	createOptions := panelCreateOptions{
@@ -242,9 +225,8 @@ func CreatePanel(c *gin.Context) {
 
 	// insert role mention data
 	// string is role ID or "user" to mention the ticket opener or "here" to mention @here
-	validRoles := utils.ToSet(utils.Map(roles, utils.RoleToId))
+	validRoles := utils.ToSet(utils.Map(validationContext.Roles, utils.RoleToId))
 
-	var roleMentions []uint64
 	for _, mention := range data.Mentions {
 		if mention == "user" {
 			createOptions.ShouldMentionUser = true
@@ -253,26 +235,78 @@ func CreatePanel(c *gin.Context) {
 		} else {
 			roleId, err := strconv.ParseUint(mention, 10, 64)
 			if err != nil {
-				c.JSON(400, utils.ErrorStr("Invalid role ID"))
-				return
+				return panelBody{}, panelCreateOptions{}, validation.NewInvalidInputError("Invalid role ID")
 			}
 
 			if validRoles.Contains(roleId) {
-				createOptions.RoleMentions = append(roleMentions, roleId)
+				createOptions.RoleMentions = append(createOptions.RoleMentions, roleId)
 			}
 		}
 	}
 
-	panelId, err := storePanel(c, panel, createOptions)
+	return data, createOptions, nil
+}
+
+// sendPanelMessage sends the message for a validated panel, returning its ID. Discord refusing to send the message
+// is returned as a *validation.InvalidInputError.
+func sendPanelMessage(botContext *botcontext.BotContext, data panelBody, customId string, isPremium bool) (uint64, error) {
+	messageData := data.IntoPanelMessageData(customId, isPremium)
+	msgId, err := messageData.send(botContext)
 	if err != nil {
-		_ = c.AbortWithError(http.StatusInternalServerError, app.NewServerError(err))
-		return
+		var unwrapped request.RestError
+		if errors.As(err, &unwrapped) {
+			if unwrapped.StatusCode == http.StatusForbidden {
+				return 0, validation.NewInvalidInputError("I do not have permission to send messages in the specified channel")
+			} else {
+				return 0, validation.NewInvalidInputError("Error sending panel message: " + unwrapped.ApiError.Message)
+			}
+		}
+
+		return 0, err
 	}
 
-	c.JSON(200, gin.H{
-		"success":  true,
-		"panel_id": panelId,
-	})
+	return msgId, nil
+}
+
+// Data must be validated before calling this function
+func (p *panelBody) intoDatabasePanel(guildId uint64, customId string, messageId uint64, welcomeMessageEmbed *int) database.Panel {
+	var emojiId *uint64
+	var emojiName *string
+	{
+		emoji := p.getEmoji()
+		if emoji != nil {
+			emojiName = &emoji.Name
+
+			if emoji.Id.Value != 0 {
+				emojiId = &emoji.Id.Value
+			}
+		}
+	}
+
+	return database.Panel{
+		MessageId:           messageId,
+		ChannelId:           p.ChannelId,
+		GuildId:             guildId,
+		Title:               p.Title,
+		Content:             p.Content,
+		Colour:              int32(p.Colour),
+		TargetCategory:      p.CategoryId,
+		EmojiId:             emojiId,
+		EmojiName:           emojiName,
+		WelcomeMessageEmbed: welcomeMessageEmbed,
+		WithDefaultTeam:     p.WithDefaultTeam,
+		CustomId:            customId,
+		ImageUrl:            p.ImageUrl,
+		ThumbnailUrl:        p.ThumbnailUrl,
+		ButtonStyle:         int(p.ButtonStyle),
+		ButtonLabel:         p.ButtonLabel,
+		FormId:              p.FormId,
+		NamingScheme:        p.NamingScheme,
+		ForceDisabled:       false,
+		Disabled:            p.Disabled,
+		ExitSurveyFormId:    p.ExitSurveyFormId,
+		PendingCategory:     p.PendingCategory,
+	}
 }
 
 // DB functions
@@ -287,38 +321,42 @@ type panelCreateOptions struct {
 
 func storePanel(ctx context.Context, panel database.Panel, options panelCreateOptions) (int, error) {
 	var panelId int
-	err := dbclient.Client.Panel.BeginFunc(ctx, func(tx pgx.Tx) error {
-		var err error
-		panelId, err = dbclient.Client.Panel.CreateWithTx(ctx, tx, panel)
-		if err != nil {
-			return err
-		}
+	err := dbclient.Client.Panel.BeginFunc(ctx, func(tx pgx.Tx) (err error) {
+		panelId, err = storePanelWithTx(ctx, tx, panel, options)
+		return
+	})
 
-		if err := dbclient.Client.PanelUserMention.SetWithTx(ctx, tx, panelId, options.ShouldMentionUser); err != nil {
-			return err
-		}
+	if err != nil {
+		return 0, err
+	}
 
-		if err := dbclient.Client.PanelHereMention.SetWithTx(ctx, tx, panelId, options.ShouldMentionHere); err != nil {
-			return err
-		}
+	return panelId, nil
+}
 
-		if err := dbclient.Client.PanelRoleMentions.ReplaceWithTx(ctx, tx, panelId, options.RoleMentions); err != nil {
-			return err
-		}
+func storePanelWithTx(ctx context.Context, tx pgx.Tx, panel database.Panel, options panelCreateOptions) (int, error) {
+	panelId, err := dbclient.Client.Panel.CreateWithTx(ctx, tx, panel)
+	if err != nil {
+		return 0, err
+	}
 
-		// Already validated, we are safe to insert
-		if err := dbclient.Client.PanelTeams.ReplaceWithTx(ctx, tx, panelId, options.TeamIds); err != nil {
-			return err
-		}
+	if err := dbclient.Client.PanelUserMention.SetWithTx(ctx, tx, panelId, options.ShouldMentionUser); err != nil {
+		return 0, err
+	}
 
-		if err := dbclient.Client.PanelAccessControlRules.ReplaceWithTx(ctx, tx, panelId, options.AccessControlRules); err != nil {
-			return err
-		}
+	if err := dbclient.Client.PanelHereMention.SetWithTx(ctx, tx, panelId, options.ShouldMentionHere); err != nil {
+		return 0, err
+	}
 
-		return nil
-	})
+	if err := dbclient.Client.PanelRoleMentions.ReplaceWithTx(ctx, tx, panelId, options.RoleMentions); err != nil {
+		return 0, err
+	}
 
-	if err != nil {
+	// Already validated, we are safe to insert
+	if err := dbclient.Client.PanelTeams.ReplaceWithTx(ctx, tx, panelId, options.TeamIds); err != nil {
+		return 0, err
+	}
+
+	if err := dbclient.Client.PanelAccessControlRules.ReplaceWithTx(ctx, tx, panelId, options.AccessControlRules); err != nil {
 		return 0, err
 	}
 
@@ -56,6 +56,19 @@ func panelIntoMessageData(panel database.Panel, isPremium bool) panelMessageData
 }
 
 func (p *panelMessageData) send(c *botcontext.BotContext) (uint64, error) {
+	ctx, cancel := app.DefaultContext()
+	defer cancel()
+
+	msg, err := rest.CreateMessage(ctx, c.Token, c.RateLimiter, p.ChannelId, p.messageData())
+	if err != nil {
+		return 0, err
+	}
+
+	return msg.Id, nil
+}
+
+// messageData builds the message that is sent to the panel channel
+func (p *panelMessageData) messageData() rest.CreateMessageData {
 	e := embed.NewEmbed().
 		SetTitle(p.Title).
 		SetDescription(p.Content).
@@ -73,7 +86,7 @@ func (p *panelMessageData) send(c *botcontext.BotContext) (uint64, error) {
 		e.SetFooter("Powered by ticketsbot.cloud", "https://ticketsbot.cloud/assets/img/logo.png")
 	}
 
-	data := rest.CreateMessageData{
+	return rest.CreateMessageData{
 		Embeds: []*embed.Embed{e},
 		Components: []component.Component{
 			component.BuildActionRow(component.BuildButton(component.Button{
@@ -86,14 +99,4 @@ func (p *panelMessageData) send(c *botcontext.BotContext) (uint64, error) {
 			})),
 		},
 	}
-
-	ctx, cancel := app.DefaultContext()
-	defer cancel()
-
-	msg, err := rest.CreateMessage(ctx, c.Token, c.RateLimiter, p.ChannelId, data)
-	if err != nil {
-		return 0, err
-	}
-
-	return msg.Id, nil
 }
@@ -0,0 +1,72 @@
+package api
+
+import (
+	"testing"
+
+	"github.com/TicketsBot-cloud/database"
+	"github.com/rxdn/gdl/objects/channel/embed"
+	"github.com/rxdn/gdl/objects/interaction/component"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPanelMessageData(t *testing.T) {
+	body := panelBody{
+		Title:       "Support",
+		Content:     "Open a ticket",
+		Colour:      0x2ECC71,
+		ButtonStyle: component.ButtonStyleSuccess,
+		ButtonLabel: "Open",
+	}
+
+	data := body.IntoPanelMessageData(previewCustomId, false)
+	message := data.messageData()
+
+	assert.Len(t, message.Embeds, 1)
+	assert.Equal(t, "Support", message.Embeds[0].Title)
+	assert.Equal(t, "Open a ticket", message.Embeds[0].Description)
+	assert.NotNil(t, message.Embeds[0].Footer)
+
+	assert.Len(t, message.Components, 1)
+	row := message.Components[0].ComponentData.(component.ActionRow)
+	button := row.Components[0].ComponentData.(component.Button)
+	assert.Equal(t, "Open", button.Label)
+	assert.Equal(t, previewCustomId, button.CustomId)
+	assert.Equal(t, component.ButtonStyleSuccess, button.Style)
+
+	// Premium servers don't have the footer
+	data = body.IntoPanelMessageData(previewCustomId, true)
+	assert.Nil(t, data.messageData().Embeds[0].Footer)
+}
+
+func TestMultiPanelMessageDataButtons(t *testing.T) {
+	panels := make([]database.Panel, 7)
+	for i := range panels {
+		panels[i] = database.Panel{ButtonLabel: "Panel", CustomId: "panel", ButtonStyle: int(component.ButtonStylePrimary)}
+	}
+
+	data := multiPanelMessageData{IsPremium: true, Embed: embed.NewEmbed()}
+	message := data.messageData(panels)
+
+	// Action rows hold at most 5 buttons
+	assert.Len(t, message.Components, 2)
+	assert.Len(t, message.Components[0].ComponentData.(component.ActionRow).Components, 5)
+	assert.Len(t, message.Components[1].ComponentData.(component.ActionRow).Components, 2)
+}
+
+func TestMultiPanelMessageDataSelectMenu(t *testing.T) {
+	panels := []database.Panel{
+		{ButtonLabel: "Billing", CustomId: "billing"},
+		{ButtonLabel: "Support", CustomId: "support"},
+	}
+
+	data := multiPanelMessageData{SelectMenu: true, Embed: embed.NewEmbed()}
+	message := data.messageData(panels)
+
+	assert.NotNil(t, message.Embeds[0].Footer)
+	assert.Len(t, message.Components, 1)
+
+	menu := message.Components[0].ComponentData.(component.ActionRow).Components[0].ComponentData.(component.SelectMenu)
+	assert.Equal(t, "Select a topic...", menu.Placeholder)
+	assert.Len(t, menu.Options, 2)
+	assert.Equal(t, "billing", menu.Options[0].Value)
+}
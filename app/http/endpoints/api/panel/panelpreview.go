@@ -0,0 +1,140 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/TicketsBot-cloud/common/premium"
+	"github.com/TicketsBot-cloud/dashboard/app"
+	"github.com/TicketsBot-cloud/dashboard/app/http/validation"
+	"github.com/TicketsBot-cloud/dashboard/botcontext"
+	"github.com/TicketsBot-cloud/dashboard/rpc"
+	"github.com/TicketsBot-cloud/dashboard/utils"
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+	"github.com/rxdn/gdl/objects/channel/embed"
+	"github.com/rxdn/gdl/objects/interaction/component"
+	"github.com/rxdn/gdl/rest"
+)
+
+type panelPreviewResponse struct {
+	Embeds     []*embed.Embed        `json:"embeds"`
+	Components []component.Component `json:"components"`
+}
+
+// previewCustomId is used in place of the random custom ID that a panel is given when it is created, as the preview
+// can't be interacted with
+const previewCustomId = "preview"
+
+func newPanelPreviewResponse(data rest.CreateMessageData) panelPreviewResponse {
+	return panelPreviewResponse{
+		Embeds:     data.Embeds,
+		Components: data.Components,
+	}
+}
+
+// PreviewPanel validates a panel in the same way as CreatePanel and UpdatePanel, and returns the message that would
+// be sent to the panel channel, without sending it
+func PreviewPanel(c *gin.Context) {
+	guildId := c.Keys["guildid"].(uint64)
+
+	botContext, err := botcontext.ContextForGuild(guildId)
+	if err != nil {
+		_ = c.AbortWithError(http.StatusInternalServerError, app.NewServerError(err))
+		return
+	}
+
+	var data panelBody
+	if err := c.BindJSON(&data); err != nil {
+		c.JSON(400, utils.ErrorStr("Invalid request body"))
+		return
+	}
+
+	premiumTier, err := rpc.PremiumClient.GetTierByGuildId(c, guildId, false, botContext.Token, botContext.RateLimiter)
+	if err != nil {
+		_ = c.AbortWithError(http.StatusInternalServerError, app.NewServerError(err))
+		return
+	}
+
+	ctx, cancel := app.DefaultContext()
+	defer cancel()
+
+	channels, err := botContext.GetGuildChannels(ctx, guildId)
+	if err != nil {
+		_ = c.AbortWithError(http.StatusInternalServerError, app.NewServerError(err))
+		return
+	}
+
+	roles, err := botContext.GetGuildRoles(ctx, guildId)
+	if err != nil {
+		_ = c.AbortWithError(http.StatusInternalServerError, app.NewServerError(err))
+		return
+	}
+
+	data, _, err = preparePanel(PanelValidationContext{
+		Data:       data,
+		GuildId:    guildId,
+		IsPremium:  premiumTier > premium.None,
+		BotContext: botContext,
+		Channels:   channels,
+		Roles:      roles,
+	})
+	if err != nil {
+		var validationError *validation.InvalidInputError
+		if errors.As(err, &validationError) {
+			c.JSON(400, utils.ErrorStr(validationError.Error()))
+		} else {
+			_ = c.AbortWithError(http.StatusInternalServerError, app.NewServerError(err))
+		}
+
+		return
+	}
+
+	messageData := data.IntoPanelMessageData(previewCustomId, premiumTier > premium.None)
+	c.JSON(200, newPanelPreviewResponse(messageData.messageData()))
+}
+
+// PreviewMultiPanel validates a multi-panel in the same way as MultiPanelCreate and MultiPanelUpdate, and returns the
+// message that would be sent to the multi-panel channel, without sending it
+func PreviewMultiPanel(c *gin.Context) {
+	guildId := c.Keys["guildid"].(uint64)
+
+	var data multiPanelCreateData
+	if err := c.ShouldBindJSON(&data); err != nil {
+		c.JSON(400, utils.ErrorJson(err))
+		return
+	}
+
+	if err := validate.Struct(data); err != nil {
+		var validationErrors validator.ValidationErrors
+		if ok := errors.As(err, &validationErrors); !ok {
+			_ = c.AbortWithError(http.StatusInternalServerError, app.NewError(err, "An error occurred while validating the panel"))
+			return
+		}
+
+		formatted := "Your input contained the following errors:\n" + utils.FormatValidationErrors(validationErrors)
+		c.JSON(400, utils.ErrorStr(formatted))
+		return
+	}
+
+	panels, err := data.doValidations(guildId)
+	if err != nil {
+		c.JSON(400, utils.ErrorJson(err))
+		return
+	}
+
+	botContext, err := botcontext.ContextForGuild(guildId)
+	if err != nil {
+		_ = c.AbortWithError(http.StatusInternalServerError, app.NewServerError(err))
+		return
+	}
+
+	premiumTier, err := rpc.PremiumClient.GetTierByGuildId(c, guildId, true, botContext.Token, botContext.RateLimiter)
+	if err != nil {
+		_ = c.AbortWithError(http.StatusInternalServerError, app.NewServerError(err))
+		return
+	}
+
+	messageData := data.IntoMessageData(premiumTier > premium.None)
+	c.JSON(200, newPanelPreviewResponse(messageData.messageData(panels)))
+}
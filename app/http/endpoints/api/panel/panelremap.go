@@ -0,0 +1,262 @@
+package api
+
+import (
+	"context"
+	"strconv"
+
+	"github.com/TicketsBot-cloud/dashboard/botcontext"
+	dbclient "github.com/TicketsBot-cloud/dashboard/database"
+	"github.com/TicketsBot-cloud/dashboard/utils/types"
+	"github.com/TicketsBot-cloud/database"
+	"github.com/rxdn/gdl/objects/channel"
+	"github.com/rxdn/gdl/objects/guild"
+	"github.com/rxdn/gdl/objects/guild/emoji"
+	"golang.org/x/sync/errgroup"
+)
+
+type (
+	// panelGuildData is everything in a guild that a panel can reference
+	panelGuildData struct {
+		GuildId  uint64
+		Channels []channel.Channel
+		Roles    []guild.Role
+		Teams    []database.SupportTeam
+		Forms    []database.Form
+		Emojis   []emoji.Emoji
+	}
+
+	// unmappedField is a reference in a panel to something that has no equivalent in the guild that the panel is
+	// being copied to. The reference is dropped from the copy.
+	unmappedField struct {
+		Field    string `json:"field"`
+		SourceId string `json:"source_id"`
+		Name     string `json:"name"` // Empty if the source no longer exists either
+	}
+
+	// panelRemapper maps the channels, roles, support teams, forms and custom emojis referenced by a panel in one
+	// guild to those with the same name in another guild
+	panelRemapper struct {
+		source   panelGuildData
+		target   panelGuildData
+		unmapped []unmappedField
+	}
+)
+
+// defaultPanelEmoji is used in place of custom emojis that aren't in the target guild, as panels must have an emoji
+var defaultPanelEmoji = types.Emoji{Name: "📩"}
+
+func loadPanelGuildData(ctx context.Context, botContext *botcontext.BotContext, guildId uint64) (panelGuildData, error) {
+	data := panelGuildData{
+		GuildId: guildId,
+	}
+
+	group, _ := errgroup.WithContext(ctx)
+
+	group.Go(func() (err error) {
+		data.Channels, err = botContext.GetGuildChannels(ctx, guildId)
+		return
+	})
+
+	group.Go(func() (err error) {
+		data.Roles, err = botContext.GetGuildRoles(ctx, guildId)
+		return
+	})
+
+	group.Go(func() (err error) {
+		data.Emojis, err = botContext.GetGuildEmojis(ctx, guildId)
+		return
+	})
+
+	group.Go(func() (err error) {
+		data.Teams, err = dbclient.Client.SupportTeam.Get(ctx, guildId)
+		return
+	})
+
+	group.Go(func() (err error) {
+		data.Forms, err = dbclient.Client.Forms.GetForms(ctx, guildId)
+		return
+	})
+
+	if err := group.Wait(); err != nil {
+		return panelGuildData{}, err
+	}
+
+	return data, nil
+}
+
+func newPanelRemapper(source, target panelGuildData) *panelRemapper {
+	return &panelRemapper{
+		source:   source,
+		target:   target,
+		unmapped: make([]unmappedField, 0),
+	}
+}
+
+// remap returns a copy of the panel that references the target guild. References that can't be mapped are dropped,
+// and recorded in r.unmapped. The channel and category are left as 0 if they can't be mapped.
+func (r *panelRemapper) remap(data panelBody) panelBody {
+	data.ChannelId, _ = r.channel("channel_id", data.ChannelId)
+	data.CategoryId, _ = r.channel("category_id", data.CategoryId)
+
+	if data.PendingCategory != nil {
+		if categoryId, ok := r.channel("pending_category", *data.PendingCategory); ok {
+			data.PendingCategory = &categoryId
+		} else {
+			data.PendingCategory = nil
+		}
+	}
+
+	mentions := make([]string, 0, len(data.Mentions))
+	for _, mention := range data.Mentions {
+		if mention == "user" || mention == "here" {
+			mentions = append(mentions, mention)
+			continue
+		}
+
+		roleId, err := strconv.ParseUint(mention, 10, 64)
+		if err != nil {
+			continue
+		}
+
+		if mapped, ok := r.role("mentions", roleId); ok {
+			mentions = append(mentions, strconv.FormatUint(mapped, 10))
+		}
+	}
+	data.Mentions = mentions
+
+	teams := make([]int, 0, len(data.Teams))
+	for _, teamId := range data.Teams {
+		if mapped, ok := r.team(teamId); ok {
+			teams = append(teams, mapped)
+		}
+	}
+	data.Teams = teams
+
+	data.FormId = r.form("form_id", data.FormId)
+	data.ExitSurveyFormId = r.form("exit_survey_form_id", data.ExitSurveyFormId)
+
+	acl := make([]database.PanelAccessControlRule, 0, len(data.AccessControlList))
+	for _, rule := range data.AccessControlList {
+		if mapped, ok := r.role("access_control_list", rule.RoleId); ok {
+			acl = append(acl, database.PanelAccessControlRule{RoleId: mapped, Action: rule.Action})
+		}
+	}
+	data.AccessControlList = acl
+
+	data.Emoji = r.emoji(data.Emoji)
+
+	return data
+}
+
+func (r *panelRemapper) channel(field string, channelId uint64) (uint64, bool) {
+	for _, source := range r.source.Channels {
+		if source.Id != channelId {
+			continue
+		}
+
+		for _, target := range r.target.Channels {
+			if target.Name == source.Name && target.Type == source.Type {
+				return target.Id, true
+			}
+		}
+
+		r.addUnmapped(field, strconv.FormatUint(channelId, 10), source.Name)
+		return 0, false
+	}
+
+	r.addUnmapped(field, strconv.FormatUint(channelId, 10), "")
+	return 0, false
+}
+
+func (r *panelRemapper) role(field string, roleId uint64) (uint64, bool) {
+	// The @everyone role's ID is the guild ID
+	if roleId == r.source.GuildId {
+		return r.target.GuildId, true
+	}
+
+	for _, source := range r.source.Roles {
+		if source.Id != roleId {
+			continue
+		}
+
+		for _, target := range r.target.Roles {
+			if target.Name == source.Name && target.Id != r.target.GuildId {
+				return target.Id, true
+			}
+		}
+
+		r.addUnmapped(field, strconv.FormatUint(roleId, 10), source.Name)
+		return 0, false
+	}
+
+	r.addUnmapped(field, strconv.FormatUint(roleId, 10), "")
+	return 0, false
+}
+
+func (r *panelRemapper) team(teamId int) (int, bool) {
+	for _, source := range r.source.Teams {
+		if source.Id != teamId {
+			continue
+		}
+
+		for _, target := range r.target.Teams {
+			if target.Name == source.Name {
+				return target.Id, true
+			}
+		}
+
+		r.addUnmapped("teams", strconv.Itoa(teamId), source.Name)
+		return 0, false
+	}
+
+	r.addUnmapped("teams", strconv.Itoa(teamId), "")
+	return 0, false
+}
+
+func (r *panelRemapper) form(field string, formId *int) *int {
+	if formId == nil {
+		return nil
+	}
+
+	for _, source := range r.source.Forms {
+		if source.Id != *formId {
+			continue
+		}
+
+		for _, target := range r.target.Forms {
+			if target.Title == source.Title {
+				return &target.Id
+			}
+		}
+
+		r.addUnmapped(field, strconv.Itoa(*formId), source.Title)
+		return nil
+	}
+
+	r.addUnmapped(field, strconv.Itoa(*formId), "")
+	return nil
+}
+
+func (r *panelRemapper) emoji(e types.Emoji) types.Emoji {
+	if !e.IsCustomEmoji || e.Id == nil {
+		return e
+	}
+
+	for _, target := range r.target.Emojis {
+		if target.Name == e.Name && target.Id.Value != 0 {
+			id := target.Id.Value
+			return types.Emoji{IsCustomEmoji: true, Name: target.Name, Id: &id}
+		}
+	}
+
+	r.addUnmapped("emote", strconv.FormatUint(*e.Id, 10), e.Name)
+	return defaultPanelEmoji
+}
+
+func (r *panelRemapper) addUnmapped(field, sourceId, name string) {
+	r.unmapped = append(r.unmapped, unmappedField{
+		Field:    field,
+		SourceId: sourceId,
+		Name:     name,
+	})
+}
@@ -0,0 +1,116 @@
+package api
+
+import (
+	"testing"
+
+	"github.com/TicketsBot-cloud/dashboard/utils/types"
+	"github.com/TicketsBot-cloud/database"
+	"github.com/rxdn/gdl/objects"
+	"github.com/rxdn/gdl/objects/channel"
+	"github.com/rxdn/gdl/objects/guild"
+	"github.com/rxdn/gdl/objects/guild/emoji"
+	"github.com/stretchr/testify/assert"
+)
+
+func testRemapGuilds() (panelGuildData, panelGuildData) {
+	source := panelGuildData{
+		GuildId: 1,
+		Channels: []channel.Channel{
+			{Id: 10, Name: "support", Type: channel.ChannelTypeGuildText},
+			{Id: 11, Name: "Tickets", Type: channel.ChannelTypeGuildCategory},
+			{Id: 12, Name: "Pending", Type: channel.ChannelTypeGuildCategory},
+		},
+		Roles: []guild.Role{
+			{Id: 1, Name: "@everyone"},
+			{Id: 20, Name: "Staff"},
+			{Id: 21, Name: "Muted"},
+		},
+		Teams: []database.SupportTeam{{Id: 30, Name: "Billing"}, {Id: 31, Name: "Moderators"}},
+		Forms: []database.Form{{Id: 40, Title: "Intake"}, {Id: 41, Title: "Exit survey"}},
+	}
+
+	target := panelGuildData{
+		GuildId: 2,
+		Channels: []channel.Channel{
+			// Same name, but not a category
+			{Id: 111, Name: "Tickets", Type: channel.ChannelTypeGuildText},
+			{Id: 110, Name: "support", Type: channel.ChannelTypeGuildText},
+			{Id: 112, Name: "Tickets", Type: channel.ChannelTypeGuildCategory},
+		},
+		Roles: []guild.Role{
+			{Id: 2, Name: "@everyone"},
+			{Id: 120, Name: "Staff"},
+		},
+		Teams:  []database.SupportTeam{{Id: 130, Name: "Billing"}},
+		Forms:  []database.Form{{Id: 140, Title: "Intake"}},
+		Emojis: []emoji.Emoji{{Id: objects.NewNullableSnowflake(150), Name: "ticket"}},
+	}
+
+	return source, target
+}
+
+func TestRemapPanel(t *testing.T) {
+	source, target := testRemapGuilds()
+
+	pendingCategory := uint64(12)
+	formId, exitSurveyFormId := 40, 41
+	emojiId := uint64(50)
+
+	remapper := newPanelRemapper(source, target)
+	data := remapper.remap(panelBody{
+		ChannelId:        10,
+		CategoryId:       11,
+		PendingCategory:  &pendingCategory,
+		Mentions:         []string{"user", "20", "21"},
+		Teams:            []int{30, 31},
+		FormId:           &formId,
+		ExitSurveyFormId: &exitSurveyFormId,
+		AccessControlList: []database.PanelAccessControlRule{
+			{RoleId: 21, Action: database.AccessControlActionDeny},
+			{RoleId: 1, Action: database.AccessControlActionAllow},
+		},
+		Emoji: types.Emoji{IsCustomEmoji: true, Name: "ticket", Id: &emojiId},
+	})
+
+	assert.Equal(t, uint64(110), data.ChannelId)
+	assert.Equal(t, uint64(112), data.CategoryId)
+	assert.Nil(t, data.PendingCategory)
+	assert.Equal(t, []string{"user", "120"}, data.Mentions)
+	assert.Equal(t, []int{130}, data.Teams)
+	assert.Equal(t, 140, *data.FormId)
+	assert.Nil(t, data.ExitSurveyFormId)
+	assert.Equal(t, []database.PanelAccessControlRule{{RoleId: 2, Action: database.AccessControlActionAllow}}, data.AccessControlList)
+	assert.Equal(t, uint64(150), *data.Emoji.Id)
+
+	assert.Equal(t, []unmappedField{
+		{Field: "pending_category", SourceId: "12", Name: "Pending"},
+		{Field: "mentions", SourceId: "21", Name: "Muted"},
+		{Field: "teams", SourceId: "31", Name: "Moderators"},
+		{Field: "exit_survey_form_id", SourceId: "41", Name: "Exit survey"},
+		{Field: "access_control_list", SourceId: "21", Name: "Muted"},
+	}, remapper.unmapped)
+}
+
+func TestRemapPanelMissingChannelAndEmoji(t *testing.T) {
+	source, target := testRemapGuilds()
+	target.Channels = nil
+	target.Emojis = nil
+
+	emojiId := uint64(50)
+
+	remapper := newPanelRemapper(source, target)
+	data := remapper.remap(panelBody{
+		ChannelId:  10,
+		CategoryId: 99, // Deleted from the source guild
+		Emoji:      types.Emoji{IsCustomEmoji: true, Name: "ticket", Id: &emojiId},
+	})
+
+	assert.Zero(t, data.ChannelId)
+	assert.Zero(t, data.CategoryId)
+	assert.Equal(t, defaultPanelEmoji, data.Emoji)
+	assert.Equal(t, []unmappedField{
+		{Field: "channel_id", SourceId: "10", Name: "support"},
+		{Field: "category_id", SourceId: "99", Name: ""},
+		{Field: "emote", SourceId: "50", Name: "ticket"},
+	}, remapper.unmapped)
+}
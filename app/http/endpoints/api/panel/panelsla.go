@@ -0,0 +1,141 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/TicketsBot-cloud/dashboard/app"
+	"github.com/TicketsBot-cloud/dashboard/database"
+	"github.com/TicketsBot-cloud/dashboard/utils"
+	"github.com/gin-gonic/gin"
+)
+
+type panelSlaBody struct {
+	// Both values are in seconds
+	FirstResponseTime *int `json:"first_response_time"`
+	FollowUpTime      *int `json:"follow_up_time"`
+}
+
+const (
+	minSlaTime = time.Minute
+	maxSlaTime = time.Hour * 24 * 30
+)
+
+func GetPanelSla(c *gin.Context) {
+	panelId, ok := getGuildPanelId(c)
+	if !ok {
+		return
+	}
+
+	sla, _, err := database.Dashboard.PanelSla.Get(c, panelId)
+	if err != nil {
+		_ = c.AbortWithError(http.StatusInternalServerError, app.NewServerError(err))
+		return
+	}
+
+	c.JSON(200, panelSlaBody{
+		FirstResponseTime: durationToSeconds(sla.FirstResponseTime),
+		FollowUpTime:      durationToSeconds(sla.FollowUpTime),
+	})
+}
+
+func SetPanelSla(c *gin.Context) {
+	panelId, ok := getGuildPanelId(c)
+	if !ok {
+		return
+	}
+
+	var body panelSlaBody
+	if err := c.BindJSON(&body); err != nil {
+		c.JSON(400, utils.ErrorStr("Invalid request body"))
+		return
+	}
+
+	firstResponseTime, ok := parseSlaTime(c, body.FirstResponseTime, "First response time")
+	if !ok {
+		return
+	}
+
+	followUpTime, ok := parseSlaTime(c, body.FollowUpTime, "Follow up time")
+	if !ok {
+		return
+	}
+
+	// No targets set, so there is nothing to store
+	if firstResponseTime == nil && followUpTime == nil {
+		if err := database.Dashboard.PanelSla.Delete(c, panelId); err != nil {
+			_ = c.AbortWithError(http.StatusInternalServerError, app.NewServerError(err))
+			return
+		}
+
+		c.JSON(200, body)
+		return
+	}
+
+	sla := database.PanelSla{
+		PanelId:           panelId,
+		FirstResponseTime: firstResponseTime,
+		FollowUpTime:      followUpTime,
+	}
+
+	if err := database.Dashboard.PanelSla.Set(c, sla); err != nil {
+		_ = c.AbortWithError(http.StatusInternalServerError, app.NewServerError(err))
+		return
+	}
+
+	c.JSON(200, body)
+}
+
+// getGuildPanelId parses the panel ID from the URL, and verifies that the panel belongs to the guild. If false is
+// returned, an error response has already been written.
+func getGuildPanelId(c *gin.Context) (int, bool) {
+	guildId := c.Keys["guildid"].(uint64)
+
+	panelId, err := strconv.Atoi(c.Param("panelid"))
+	if err != nil {
+		c.JSON(400, utils.ErrorStr("Missing panel ID"))
+		return 0, false
+	}
+
+	panel, err := database.Client.Panel.GetById(c, panelId)
+	if err != nil {
+		_ = c.AbortWithError(http.StatusInternalServerError, app.NewServerError(err))
+		return 0, false
+	}
+
+	if panel.PanelId == 0 {
+		c.JSON(404, utils.ErrorStr("Panel not found"))
+		return 0, false
+	}
+
+	// verify panel belongs to guild
+	if panel.GuildId != guildId {
+		c.JSON(403, utils.ErrorStr("Guild ID doesn't match"))
+		return 0, false
+	}
+
+	return panelId, true
+}
+
+func parseSlaTime(c *gin.Context, seconds *int, name string) (*time.Duration, bool) {
+	if seconds == nil {
+		return nil, true
+	}
+
+	duration := time.Duration(*seconds) * time.Second
+	if duration < minSlaTime || duration > maxSlaTime {
+		c.JSON(400, utils.ErrorStr("%s must be between 1 minute and 30 days", name))
+		return nil, false
+	}
+
+	return &duration, true
+}
+
+func durationToSeconds(duration *time.Duration) *int {
+	if duration == nil {
+		return nil
+	}
+
+	return utils.Ptr(int(duration.Seconds()))
+}
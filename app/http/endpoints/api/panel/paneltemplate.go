@@ -0,0 +1,537 @@
+package api
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+
+	"github.com/TicketsBot-cloud/dashboard/app/http/validation"
+	"github.com/TicketsBot-cloud/dashboard/utils"
+	"github.com/TicketsBot-cloud/dashboard/utils/types"
+	"github.com/TicketsBot-cloud/database"
+	"github.com/rxdn/gdl/objects/channel"
+	"github.com/rxdn/gdl/objects/interaction/component"
+)
+
+// panelTemplateVersion is the version of the template format produced by the export endpoints. Importing a template
+// of any other version is refused.
+const panelTemplateVersion = 1
+
+// everyoneRoleKey refers to the @everyone role of the guild that the template is imported into, which does not need to
+// be mapped by the importer
+const everyoneRoleKey = "everyone"
+
+type (
+	// panelTemplate is a portable definition of a panel, or of a multi-panel and the panels that it contains. Channels
+	// and roles are referred to by placeholder keys, which the importer maps to channels and roles in their own
+	// server. Forms are included in the template, and are created alongside the panels. Support teams are not
+	// included.
+	panelTemplate struct {
+		Version    int                   `json:"version"`
+		Channels   []templatePlaceholder `json:"channels"`
+		Roles      []templatePlaceholder `json:"roles"`
+		Forms      []templateForm        `json:"forms" validate:"max=30,dive"`
+		Panels     []templatePanel       `json:"panels" validate:"min=1,max=15"`
+		MultiPanel *templateMultiPanel   `json:"multi_panel,omitempty"`
+	}
+
+	// templatePlaceholder is a channel or role that must be mapped by the importer. The name and type are those in
+	// the server that the template was exported from, and are only a hint.
+	templatePlaceholder struct {
+		Key  string               `json:"key"`
+		Name string               `json:"name"`
+		Type *channel.ChannelType `json:"type,omitempty"`
+	}
+
+	templateForm struct {
+		Key    string              `json:"key" validate:"required,max=100"`
+		Title  string              `json:"title" validate:"max=45"`
+		Inputs []templateFormInput `json:"inputs" validate:"min=1,max=5,dive"`
+	}
+
+	// templateFormInput is a form input. Custom IDs are generated when the template is imported, so are not exported.
+	templateFormInput struct {
+		Key         string                   `json:"key" validate:"required,max=100"`
+		Position    int                      `json:"position" validate:"required,min=1,max=5"`
+		Style       component.TextStyleTypes `json:"style" validate:"required,min=1,max=2"`
+		Label       string                   `json:"label" validate:"required,min=1,max=45"`
+		Placeholder *string                  `json:"placeholder,omitempty" validate:"omitempty,min=1,max=100"`
+		Required    bool                     `json:"required"`
+		MinLength   *uint16                  `json:"min_length,omitempty" validate:"omitempty,max=1024"`
+		MaxLength   *uint16                  `json:"max_length,omitempty" validate:"omitempty,max=1024"`
+	}
+
+	// templatePanel mirrors panelBody, with channels, roles and forms referred to by key
+	templatePanel struct {
+		Key               string                      `json:"key"`
+		Channel           string                      `json:"channel"`
+		Title             string                      `json:"title"`
+		Content           string                      `json:"content"`
+		Colour            uint32                      `json:"colour"`
+		Category          string                      `json:"category"`
+		Emoji             types.Emoji                 `json:"emote"`
+		WelcomeMessage    *types.CustomEmbed          `json:"welcome_message"`
+		Mentions          []string                    `json:"mentions"`
+		WithDefaultTeam   bool                        `json:"default_team"`
+		ImageUrl          *string                     `json:"image_url,omitempty"`
+		ThumbnailUrl      *string                     `json:"thumbnail_url,omitempty"`
+		ButtonStyle       component.ButtonStyle       `json:"button_style,string"`
+		ButtonLabel       string                      `json:"button_label"`
+		Form              *string                     `json:"form,omitempty"`
+		NamingScheme      *string                     `json:"naming_scheme"`
+		Disabled          bool                        `json:"disabled"`
+		ExitSurveyForm    *string                     `json:"exit_survey_form,omitempty"`
+		AccessControlList []templateAccessControlRule `json:"access_control_list"`
+		PendingCategory   *string                     `json:"pending_category,omitempty"`
+	}
+
+	templateAccessControlRule struct {
+		Role   string                       `json:"role"`
+		Action database.AccessControlAction `json:"action"`
+	}
+
+	templateMultiPanel struct {
+		Channel               string             `json:"channel"`
+		SelectMenu            bool               `json:"select_menu"`
+		SelectMenuPlaceholder *string            `json:"select_menu_placeholder,omitempty"`
+		Embed                 *types.CustomEmbed `json:"embed"`
+		Panels                []string           `json:"panels"`
+	}
+)
+
+// panelTemplateBuilder builds a template from panels in the guild that they are exported from, assigning a key to each
+// channel, role and form that they refer to
+type panelTemplateBuilder struct {
+	source   panelGuildData
+	template panelTemplate
+	channels map[uint64]string
+	roles    map[uint64]string
+	forms    map[int]string
+}
+
+func newPanelTemplateBuilder(source panelGuildData) *panelTemplateBuilder {
+	return &panelTemplateBuilder{
+		source: source,
+		template: panelTemplate{
+			Version:  panelTemplateVersion,
+			Channels: make([]templatePlaceholder, 0),
+			Roles:    make([]templatePlaceholder, 0),
+			Forms:    make([]templateForm, 0),
+			Panels:   make([]templatePanel, 0),
+		},
+		channels: make(map[uint64]string),
+		roles:    make(map[uint64]string),
+		forms:    make(map[int]string),
+	}
+}
+
+// addForm must be called for each form that the panels refer to before the panels are added
+func (b *panelTemplateBuilder) addForm(form database.Form, inputs []database.FormInput) {
+	if _, ok := b.forms[form.Id]; ok {
+		return
+	}
+
+	key := fmt.Sprintf("form_%d", len(b.template.Forms)+1)
+	b.forms[form.Id] = key
+
+	templateInputs := make([]templateFormInput, len(inputs))
+	for i, input := range inputs {
+		templateInputs[i] = templateFormInput{
+			Key:         fmt.Sprintf("%s_input_%d", key, input.Position),
+			Position:    input.Position,
+			Style:       component.TextStyleTypes(input.Style),
+			Label:       input.Label,
+			Placeholder: input.Placeholder,
+			Required:    input.Required,
+			MinLength:   input.MinLength,
+			MaxLength:   input.MaxLength,
+		}
+	}
+
+	sort.Slice(templateInputs, func(i, j int) bool {
+		return templateInputs[i].Position < templateInputs[j].Position
+	})
+
+	b.template.Forms = append(b.template.Forms, templateForm{
+		Key:    key,
+		Title:  form.Title,
+		Inputs: templateInputs,
+	})
+}
+
+// addPanel adds the panel to the template, returning its key
+func (b *panelTemplateBuilder) addPanel(data panelBody) string {
+	panel := templatePanel{
+		Key:               fmt.Sprintf("panel_%d", len(b.template.Panels)+1),
+		Channel:           b.channel(data.ChannelId),
+		Title:             data.Title,
+		Content:           data.Content,
+		Colour:            data.Colour,
+		Category:          b.channel(data.CategoryId),
+		Emoji:             data.Emoji,
+		WelcomeMessage:    data.WelcomeMessage,
+		Mentions:          make([]string, 0, len(data.Mentions)),
+		WithDefaultTeam:   data.WithDefaultTeam,
+		ImageUrl:          data.ImageUrl,
+		ThumbnailUrl:      data.ThumbnailUrl,
+		ButtonStyle:       data.ButtonStyle,
+		ButtonLabel:       data.ButtonLabel,
+		Form:              b.form(data.FormId),
+		NamingScheme:      data.NamingScheme,
+		Disabled:          data.Disabled,
+		ExitSurveyForm:    b.form(data.ExitSurveyFormId),
+		AccessControlList: make([]templateAccessControlRule, len(data.AccessControlList)),
+	}
+
+	for _, mention := range data.Mentions {
+		if mention == "user" || mention == "here" {
+			panel.Mentions = append(panel.Mentions, mention)
+		} else if roleId, err := strconv.ParseUint(mention, 10, 64); err == nil {
+			panel.Mentions = append(panel.Mentions, b.role(roleId))
+		}
+	}
+
+	for i, rule := range data.AccessControlList {
+		panel.AccessControlList[i] = templateAccessControlRule{
+			Role:   b.role(rule.RoleId),
+			Action: rule.Action,
+		}
+	}
+
+	if data.PendingCategory != nil {
+		panel.PendingCategory = utils.Ptr(b.channel(*data.PendingCategory))
+	}
+
+	b.template.Panels = append(b.template.Panels, panel)
+	return panel.Key
+}
+
+func (b *panelTemplateBuilder) setMultiPanel(multiPanel database.MultiPanel, panelKeys []string) {
+	var embed *types.CustomEmbed
+	if multiPanel.Embed != nil {
+		embed = types.NewCustomEmbed(multiPanel.Embed.CustomEmbed, multiPanel.Embed.Fields)
+	}
+
+	b.template.MultiPanel = &templateMultiPanel{
+		Channel:               b.channel(multiPanel.ChannelId),
+		SelectMenu:            multiPanel.SelectMenu,
+		SelectMenuPlaceholder: multiPanel.SelectMenuPlaceholder,
+		Embed:                 embed,
+		Panels:                panelKeys,
+	}
+}
+
+func (b *panelTemplateBuilder) channel(channelId uint64) string {
+	if key, ok := b.channels[channelId]; ok {
+		return key
+	}
+
+	placeholder := templatePlaceholder{
+		Key: fmt.Sprintf("channel_%d", len(b.template.Channels)+1),
+	}
+
+	for _, ch := range b.source.Channels {
+		if ch.Id == channelId {
+			placeholder.Name = ch.Name
+			placeholder.Type = utils.Ptr(ch.Type)
+			break
+		}
+	}
+
+	b.channels[channelId] = placeholder.Key
+	b.template.Channels = append(b.template.Channels, placeholder)
+	return placeholder.Key
+}
+
+func (b *panelTemplateBuilder) role(roleId uint64) string {
+	if roleId == b.source.GuildId {
+		return everyoneRoleKey
+	}
+
+	if key, ok := b.roles[roleId]; ok {
+		return key
+	}
+
+	placeholder := templatePlaceholder{
+		Key: fmt.Sprintf("role_%d", len(b.template.Roles)+1),
+	}
+
+	for _, role := range b.source.Roles {
+		if role.Id == roleId {
+			placeholder.Name = role.Name
+			break
+		}
+	}
+
+	b.roles[roleId] = placeholder.Key
+	b.template.Roles = append(b.template.Roles, placeholder)
+	return placeholder.Key
+}
+
+func (b *panelTemplateBuilder) form(formId *int) *string {
+	if formId == nil {
+		return nil
+	}
+
+	key, ok := b.forms[*formId]
+	if !ok {
+		return nil
+	}
+
+	return &key
+}
+
+// validateTemplateStructure checks that the keys in the template are unique, and that every key that is referred to
+// is defined
+func validateTemplateStructure(template panelTemplate) error {
+	if template.Version != panelTemplateVersion {
+		return validation.NewInvalidInputErrorf("Unsupported template version %d", template.Version)
+	}
+
+	keys := make(map[string]bool)
+	for _, group := range [][]templatePlaceholder{template.Channels, template.Roles} {
+		for _, placeholder := range group {
+			if placeholder.Key == "" || placeholder.Key == everyoneRoleKey || keys[placeholder.Key] {
+				return validation.NewInvalidInputErrorf("Invalid or duplicate placeholder \"%s\"", placeholder.Key)
+			}
+
+			keys[placeholder.Key] = true
+		}
+	}
+
+	for _, form := range template.Forms {
+		if keys[form.Key] {
+			return validation.NewInvalidInputErrorf("Duplicate form \"%s\"", form.Key)
+		}
+
+		keys[form.Key] = true
+
+		positions := make([]int, len(form.Inputs))
+		for i, input := range form.Inputs {
+			if keys[input.Key] {
+				return validation.NewInvalidInputErrorf("Duplicate form input \"%s\"", input.Key)
+			}
+
+			keys[input.Key] = true
+			positions[i] = input.Position
+		}
+
+		sort.Ints(positions)
+		for i, position := range positions {
+			if i+1 != position {
+				return validation.NewInvalidInputErrorf("Positions of the inputs on form \"%s\" must be unique and in ascending order", form.Key)
+			}
+		}
+	}
+
+	for _, panel := range template.Panels {
+		if panel.Key == "" || keys[panel.Key] {
+			return validation.NewInvalidInputErrorf("Invalid or duplicate panel \"%s\"", panel.Key)
+		}
+
+		keys[panel.Key] = true
+	}
+
+	if template.MultiPanel == nil {
+		if len(template.Panels) != 1 {
+			return validation.NewInvalidInputError("A template without a multi-panel must contain exactly 1 panel")
+		}
+
+		return nil
+	}
+
+	if len(template.MultiPanel.Panels) < 2 {
+		return validation.NewInvalidInputError("A multi-panel must contain at least 2 sub-panels")
+	}
+
+	if len(template.MultiPanel.Panels) > 15 {
+		return validation.NewInvalidInputError("Multi-panels cannot contain more than 15 sub-panels")
+	}
+
+	for _, key := range template.MultiPanel.Panels {
+		if !utils.ExistsMap(template.Panels, key, func(panel templatePanel) string { return panel.Key }) {
+			return validation.NewInvalidInputErrorf("Multi-panel refers to unknown panel \"%s\"", key)
+		}
+	}
+
+	return nil
+}
+
+// missingPlaceholders returns the keys of the channel and role placeholders that the importer has not mapped
+func missingPlaceholders(template panelTemplate, channels, roles map[string]string) []string {
+	missing := make([]string, 0)
+	for _, placeholder := range template.Channels {
+		if _, ok := channels[placeholder.Key]; !ok {
+			missing = append(missing, placeholder.Key)
+		}
+	}
+
+	for _, placeholder := range template.Roles {
+		if _, ok := roles[placeholder.Key]; !ok {
+			missing = append(missing, placeholder.Key)
+		}
+	}
+
+	return missing
+}
+
+// templateResolver replaces the keys in a template with the IDs chosen by the importer. Forms are not stored until the
+// panels have been validated, so they are given IDs by their position in the template.
+type templateResolver struct {
+	guildId  uint64
+	channels map[string]uint64
+	roles    map[string]uint64
+	forms    map[string]int
+}
+
+func newTemplateResolver(guildId uint64, template panelTemplate, channels, roles map[string]string) (*templateResolver, error) {
+	resolver := &templateResolver{
+		guildId:  guildId,
+		channels: make(map[string]uint64, len(channels)),
+		roles:    make(map[string]uint64, len(roles)),
+		forms:    make(map[string]int, len(template.Forms)),
+	}
+
+	for key, raw := range channels {
+		channelId, err := strconv.ParseUint(raw, 10, 64)
+		if err != nil {
+			return nil, validation.NewInvalidInputErrorf("Invalid channel ID for \"%s\"", key)
+		}
+
+		resolver.channels[key] = channelId
+	}
+
+	for key, raw := range roles {
+		roleId, err := strconv.ParseUint(raw, 10, 64)
+		if err != nil {
+			return nil, validation.NewInvalidInputErrorf("Invalid role ID for \"%s\"", key)
+		}
+
+		resolver.roles[key] = roleId
+	}
+
+	for i, form := range template.Forms {
+		resolver.forms[form.Key] = i + 1
+	}
+
+	return resolver, nil
+}
+
+// templateForms returns the IDs that the forms in the template are resolved to
+func (r *templateResolver) templateForms(template panelTemplate) []int {
+	forms := make([]int, len(template.Forms))
+	for i, form := range template.Forms {
+		forms[i] = r.forms[form.Key]
+	}
+
+	return forms
+}
+
+func (r *templateResolver) panel(panel templatePanel) (panelBody, error) {
+	data := panelBody{
+		Title:             panel.Title,
+		Content:           panel.Content,
+		Colour:            panel.Colour,
+		Emoji:             panel.Emoji,
+		WelcomeMessage:    panel.WelcomeMessage,
+		Mentions:          make([]string, 0, len(panel.Mentions)),
+		WithDefaultTeam:   panel.WithDefaultTeam,
+		Teams:             make([]int, 0),
+		ImageUrl:          panel.ImageUrl,
+		ThumbnailUrl:      panel.ThumbnailUrl,
+		ButtonStyle:       panel.ButtonStyle,
+		ButtonLabel:       panel.ButtonLabel,
+		NamingScheme:      panel.NamingScheme,
+		Disabled:          panel.Disabled,
+		AccessControlList: make([]database.PanelAccessControlRule, len(panel.AccessControlList)),
+	}
+
+	var err error
+	if data.ChannelId, err = r.channel(panel.Channel); err != nil {
+		return panelBody{}, err
+	}
+
+	if data.CategoryId, err = r.channel(panel.Category); err != nil {
+		return panelBody{}, err
+	}
+
+	if panel.PendingCategory != nil {
+		categoryId, err := r.channel(*panel.PendingCategory)
+		if err != nil {
+			return panelBody{}, err
+		}
+
+		data.PendingCategory = &categoryId
+	}
+
+	for _, mention := range panel.Mentions {
+		if mention == "user" || mention == "here" {
+			data.Mentions = append(data.Mentions, mention)
+			continue
+		}
+
+		roleId, err := r.role(mention)
+		if err != nil {
+			return panelBody{}, err
+		}
+
+		data.Mentions = append(data.Mentions, strconv.FormatUint(roleId, 10))
+	}
+
+	for i, rule := range panel.AccessControlList {
+		roleId, err := r.role(rule.Role)
+		if err != nil {
+			return panelBody{}, err
+		}
+
+		data.AccessControlList[i] = database.PanelAccessControlRule{
+			RoleId: roleId,
+			Action: rule.Action,
+		}
+	}
+
+	if data.FormId, err = r.form(panel.Form); err != nil {
+		return panelBody{}, err
+	}
+
+	if data.ExitSurveyFormId, err = r.form(panel.ExitSurveyForm); err != nil {
+		return panelBody{}, err
+	}
+
+	return data, nil
+}
+
+func (r *templateResolver) channel(key string) (uint64, error) {
+	channelId, ok := r.channels[key]
+	if !ok {
+		return 0, validation.NewInvalidInputErrorf("Unknown channel placeholder \"%s\"", key)
+	}
+
+	return channelId, nil
+}
+
+func (r *templateResolver) role(key string) (uint64, error) {
+	if key == everyoneRoleKey {
+		return r.guildId, nil
+	}
+
+	roleId, ok := r.roles[key]
+	if !ok {
+		return 0, validation.NewInvalidInputErrorf("Unknown role placeholder \"%s\"", key)
+	}
+
+	return roleId, nil
+}
+
+func (r *templateResolver) form(key *string) (*int, error) {
+	if key == nil {
+		return nil, nil
+	}
+
+	formId, ok := r.forms[*key]
+	if !ok {
+		return nil, validation.NewInvalidInputErrorf("Unknown form \"%s\"", *key)
+	}
+
+	return &formId, nil
+}
@@ -0,0 +1,196 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/TicketsBot-cloud/dashboard/app/http/validation"
+	"github.com/TicketsBot-cloud/dashboard/utils"
+	"github.com/TicketsBot-cloud/dashboard/utils/types"
+	"github.com/TicketsBot-cloud/database"
+	"github.com/rxdn/gdl/objects/channel"
+	"github.com/rxdn/gdl/objects/guild"
+	"github.com/stretchr/testify/assert"
+)
+
+func testTemplateSource() panelGuildData {
+	return panelGuildData{
+		GuildId: 1,
+		Channels: []channel.Channel{
+			{Id: 10, Name: "support", Type: channel.ChannelTypeGuildText},
+			{Id: 11, Name: "Tickets", Type: channel.ChannelTypeGuildCategory},
+		},
+		Roles: []guild.Role{
+			{Id: 1, Name: "@everyone"},
+			{Id: 20, Name: "Staff"},
+		},
+	}
+}
+
+func TestPanelTemplateRoundTrip(t *testing.T) {
+	builder := newPanelTemplateBuilder(testTemplateSource())
+	builder.addForm(database.Form{Id: 40, Title: "Intake"}, []database.FormInput{
+		{Id: 2, FormId: 40, Position: 2, CustomId: "second", Style: 2, Label: "Details"},
+		{Id: 1, FormId: 40, Position: 1, CustomId: "first", Style: 1, Label: "Subject"},
+	})
+
+	formId := 40
+	panelKey := builder.addPanel(panelBody{
+		ChannelId:  10,
+		Title:      "Support",
+		CategoryId: 11,
+		Emoji:      types.Emoji{Name: "📩"},
+		WelcomeMessage: &types.CustomEmbed{
+			Description: utils.Ptr("Subject: {answer:first}, opened by {user}"),
+		},
+		Mentions: []string{"user", "20"},
+		FormId:   &formId,
+		AccessControlList: []database.PanelAccessControlRule{
+			{RoleId: 20, Action: database.AccessControlActionAllow},
+			{RoleId: 1, Action: database.AccessControlActionDeny},
+		},
+	})
+
+	template := builder.template
+	assert.Equal(t, "panel_1", panelKey)
+	assert.Equal(t, panelTemplateVersion, template.Version)
+	assert.Equal(t, []templatePlaceholder{
+		{Key: "channel_1", Name: "support", Type: utils.Ptr(channel.ChannelTypeGuildText)},
+		{Key: "channel_2", Name: "Tickets", Type: utils.Ptr(channel.ChannelTypeGuildCategory)},
+	}, template.Channels)
+	assert.Equal(t, []templatePlaceholder{{Key: "role_1", Name: "Staff"}}, template.Roles)
+
+	// Inputs are ordered by position, and answers refer to them by key
+	assert.Len(t, template.Forms, 1)
+	assert.Equal(t, "form_1_input_1", template.Forms[0].Inputs[0].Key)
+	assert.Equal(t, "form_1_input_2", template.Forms[0].Inputs[1].Key)
+	assert.Equal(t, "Subject: {answer:first}, opened by {user}", *template.Panels[0].WelcomeMessage.Description)
+	assert.Equal(t, []string{"user", "role_1"}, template.Panels[0].Mentions)
+	assert.Equal(t, []templateAccessControlRule{
+		{Role: "role_1", Action: database.AccessControlActionAllow},
+		{Role: everyoneRoleKey, Action: database.AccessControlActionDeny},
+	}, template.Panels[0].AccessControlList)
+
+	// The template must survive being shared as JSON
+	encoded, err := json.Marshal(template)
+	assert.NoError(t, err)
+
+	var decoded panelTemplate
+	assert.NoError(t, json.Unmarshal(encoded, &decoded))
+	assert.NoError(t, validate.Struct(decoded))
+	assert.NoError(t, validateTemplateStructure(decoded))
+
+	channels := map[string]string{"channel_1": "110", "channel_2": "111"}
+	roles := map[string]string{"role_1": "120"}
+	assert.Empty(t, missingPlaceholders(decoded, channels, roles))
+
+	resolver, err := newTemplateResolver(2, decoded, channels, roles)
+	assert.NoError(t, err)
+
+	data, err := resolver.panel(decoded.Panels[0])
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(110), data.ChannelId)
+	assert.Equal(t, uint64(111), data.CategoryId)
+	assert.Equal(t, []string{"user", "120"}, data.Mentions)
+	assert.Equal(t, 1, *data.FormId)
+	assert.Equal(t, []database.PanelAccessControlRule{
+		{RoleId: 120, Action: database.AccessControlActionAllow},
+		{RoleId: 2, Action: database.AccessControlActionDeny},
+	}, data.AccessControlList)
+	assert.Equal(t, []int{1}, resolver.templateForms(decoded))
+}
+
+func TestMissingPlaceholders(t *testing.T) {
+	template := panelTemplate{
+		Channels: []templatePlaceholder{{Key: "channel_1"}, {Key: "channel_2"}},
+		Roles:    []templatePlaceholder{{Key: "role_1"}},
+	}
+
+	missing := missingPlaceholders(template, map[string]string{"channel_1": "1"}, nil)
+	assert.Equal(t, []string{"channel_2", "role_1"}, missing)
+}
+
+func TestValidateTemplateStructure(t *testing.T) {
+	valid := func() panelTemplate {
+		return panelTemplate{
+			Version:  panelTemplateVersion,
+			Channels: []templatePlaceholder{{Key: "channel_1"}},
+			Panels:   []templatePanel{{Key: "panel_1"}, {Key: "panel_2"}},
+			MultiPanel: &templateMultiPanel{
+				Channel: "channel_1",
+				Panels:  []string{"panel_1", "panel_2"},
+			},
+		}
+	}
+
+	assert.NoError(t, validateTemplateStructure(valid()))
+
+	tests := map[string]func(*panelTemplate){
+		"unsupported version": func(template *panelTemplate) {
+			template.Version = panelTemplateVersion + 1
+		},
+		"duplicate key": func(template *panelTemplate) {
+			template.Roles = []templatePlaceholder{{Key: "channel_1"}}
+		},
+		"reserved key": func(template *panelTemplate) {
+			template.Roles = []templatePlaceholder{{Key: everyoneRoleKey}}
+		},
+		"unknown panel": func(template *panelTemplate) {
+			template.MultiPanel.Panels = []string{"panel_1", "panel_3"}
+		},
+		"too few sub-panels": func(template *panelTemplate) {
+			template.MultiPanel.Panels = []string{"panel_1"}
+		},
+		"several panels without multi-panel": func(template *panelTemplate) {
+			template.MultiPanel = nil
+		},
+		"input positions": func(template *panelTemplate) {
+			template.Forms = []templateForm{{
+				Key: "form_1",
+				Inputs: []templateFormInput{
+					{Key: "form_1_input_1", Position: 1},
+					{Key: "form_1_input_3", Position: 3},
+				},
+			}}
+		},
+	}
+
+	for name, modify := range tests {
+		t.Run(name, func(t *testing.T) {
+			template := valid()
+			modify(&template)
+
+			var validationError *validation.InvalidInputError
+			assert.True(t, errors.As(validateTemplateStructure(template), &validationError))
+		})
+	}
+}
+
+func TestTemplateResolverUnknownKeys(t *testing.T) {
+	template := panelTemplate{}
+	resolver, err := newTemplateResolver(2, template, map[string]string{"channel_1": "110"}, nil)
+	assert.NoError(t, err)
+
+	_, err = resolver.panel(templatePanel{Channel: "channel_1", Category: "channel_2"})
+	assert.Error(t, err)
+
+	_, err = resolver.panel(templatePanel{Channel: "channel_1", Category: "channel_1", Form: utils.Ptr("form_1")})
+	assert.Error(t, err)
+
+	_, err = newTemplateResolver(2, template, map[string]string{"channel_1": "general"}, nil)
+	assert.Error(t, err)
+}
+
+func TestValidateTemplateForms(t *testing.T) {
+	formId := 1
+	ctx := PanelValidationContext{
+		Data:          panelBody{FormId: &formId},
+		TemplateForms: []int{1},
+	}
+
+	assert.NoError(t, validateFormId(ctx)())
+
+	formId = 2
+	assert.Error(t, validateFormId(ctx)())
+}
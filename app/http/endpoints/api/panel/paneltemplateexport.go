@@ -0,0 +1,137 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+
+	"github.com/TicketsBot-cloud/dashboard/app"
+	"github.com/TicketsBot-cloud/dashboard/botcontext"
+	dbclient "github.com/TicketsBot-cloud/dashboard/database"
+	"github.com/TicketsBot-cloud/dashboard/utils"
+	"github.com/TicketsBot-cloud/database"
+	"github.com/gin-gonic/gin"
+)
+
+// ExportPanelTemplate exports a panel, along with its forms and welcome message, as a template that can be imported
+// into any server
+func ExportPanelTemplate(c *gin.Context) {
+	guildId := c.Keys["guildid"].(uint64)
+
+	panelId, err := strconv.Atoi(c.Param("panelid"))
+	if err != nil {
+		c.JSON(400, utils.ErrorStr("Missing panel ID"))
+		return
+	}
+
+	panel, err := dbclient.Client.Panel.GetById(c, panelId)
+	if err != nil {
+		_ = c.AbortWithError(http.StatusInternalServerError, app.NewServerError(err))
+		return
+	}
+
+	if panel.PanelId == 0 || panel.GuildId != guildId {
+		c.JSON(404, utils.ErrorStr("Panel not found"))
+		return
+	}
+
+	template, err := buildPanelTemplate(c, guildId, []database.Panel{panel}, nil)
+	if err != nil {
+		_ = c.AbortWithError(http.StatusInternalServerError, app.NewServerError(err))
+		return
+	}
+
+	c.JSON(200, template)
+}
+
+// ExportMultiPanelTemplate exports a multi-panel, along with the panels it contains and their forms and welcome
+// messages, as a template that can be imported into any server
+func ExportMultiPanelTemplate(c *gin.Context) {
+	guildId := c.Keys["guildid"].(uint64)
+
+	multiPanelId, err := strconv.Atoi(c.Param("panelid"))
+	if err != nil {
+		c.JSON(400, utils.ErrorStr("Missing panel ID"))
+		return
+	}
+
+	multiPanel, ok, err := dbclient.Client.MultiPanels.Get(c, multiPanelId)
+	if err != nil {
+		_ = c.AbortWithError(http.StatusInternalServerError, app.NewServerError(err))
+		return
+	}
+
+	if !ok || multiPanel.GuildId != guildId {
+		c.JSON(404, utils.ErrorStr("Multi-panel not found"))
+		return
+	}
+
+	panels, err := dbclient.Client.MultiPanelTargets.GetPanels(c, multiPanelId)
+	if err != nil {
+		_ = c.AbortWithError(http.StatusInternalServerError, app.NewServerError(err))
+		return
+	}
+
+	template, err := buildPanelTemplate(c, guildId, panels, &multiPanel)
+	if err != nil {
+		_ = c.AbortWithError(http.StatusInternalServerError, app.NewServerError(err))
+		return
+	}
+
+	c.JSON(200, template)
+}
+
+func buildPanelTemplate(c context.Context, guildId uint64, panels []database.Panel, multiPanel *database.MultiPanel) (panelTemplate, error) {
+	botContext, err := botcontext.ContextForGuild(guildId)
+	if err != nil {
+		return panelTemplate{}, err
+	}
+
+	ctx, cancel := app.DefaultContext()
+	defer cancel()
+
+	guildData, err := loadPanelGuildData(ctx, botContext, guildId)
+	if err != nil {
+		return panelTemplate{}, err
+	}
+
+	builder := newPanelTemplateBuilder(guildData)
+
+	bodies := make([]panelBody, len(panels))
+	for i, panel := range panels {
+		bodies[i], err = loadPanelBody(c, panel)
+		if err != nil {
+			return panelTemplate{}, err
+		}
+
+		for _, formId := range []*int{panel.FormId, panel.ExitSurveyFormId} {
+			if formId == nil {
+				continue
+			}
+
+			for _, form := range guildData.Forms {
+				if form.Id != *formId {
+					continue
+				}
+
+				inputs, err := dbclient.Client.FormInput.GetInputs(c, form.Id)
+				if err != nil {
+					return panelTemplate{}, err
+				}
+
+				builder.addForm(form, inputs)
+			}
+		}
+	}
+
+	panelKeys := make([]string, len(bodies))
+	for i, data := range bodies {
+		panelKeys[i] = builder.addPanel(data)
+	}
+
+	if multiPanel != nil {
+		builder.setMultiPanel(*multiPanel, panelKeys)
+	}
+
+	return builder.template, nil
+}
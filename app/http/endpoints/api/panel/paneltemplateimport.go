@@ -0,0 +1,395 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/TicketsBot-cloud/common/premium"
+	"github.com/TicketsBot-cloud/dashboard/app"
+	"github.com/TicketsBot-cloud/dashboard/app/http/validation"
+	"github.com/TicketsBot-cloud/dashboard/botcontext"
+	dbclient "github.com/TicketsBot-cloud/dashboard/database"
+	"github.com/TicketsBot-cloud/dashboard/rpc"
+	"github.com/TicketsBot-cloud/dashboard/utils"
+	"github.com/TicketsBot-cloud/database"
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+	"github.com/jackc/pgx/v4"
+	"github.com/rxdn/gdl/objects/channel"
+	"github.com/rxdn/gdl/rest"
+	"github.com/rxdn/gdl/rest/request"
+)
+
+type (
+	panelTemplateImportBody struct {
+		Template panelTemplate     `json:"template"`
+		Channels map[string]string `json:"channels"` // Placeholder key -> channel ID
+		Roles    map[string]string `json:"roles"`    // Placeholder key -> role ID
+	}
+
+	// importedPanel is a panel from a template that has been resolved and validated
+	importedPanel struct {
+		data      panelBody
+		options   panelCreateOptions
+		customId  string
+		messageId uint64
+	}
+)
+
+// ImportPanelTemplate creates the panels, forms and multi-panel in a template exported by ExportPanelTemplate or
+// ExportMultiPanelTemplate. Every channel and role placeholder in the template must be mapped to a channel or role in
+// this guild. Custom emojis are mapped to those with the same name in this guild, and are otherwise replaced.
+//
+// All panels are validated before any messages are sent, and everything is stored in a single transaction. If the
+// import fails after messages have been sent, they are deleted again.
+func ImportPanelTemplate(c *gin.Context) {
+	guildId := c.Keys["guildid"].(uint64)
+
+	var body panelTemplateImportBody
+	if err := c.BindJSON(&body); err != nil {
+		c.JSON(400, utils.ErrorStr("Invalid request body"))
+		return
+	}
+
+	template := body.Template
+	if err := validate.Struct(template); err != nil {
+		var validationErrors validator.ValidationErrors
+		if ok := errors.As(err, &validationErrors); !ok {
+			_ = c.AbortWithError(http.StatusInternalServerError, app.NewError(err, "An error occurred while validating the template"))
+			return
+		}
+
+		formatted := "Your input contained the following errors:\n" + utils.FormatValidationErrors(validationErrors)
+		c.JSON(400, utils.ErrorStr(formatted))
+		return
+	}
+
+	if err := validateTemplateStructure(template); err != nil {
+		c.JSON(400, utils.ErrorStr(err.Error()))
+		return
+	}
+
+	if missing := missingPlaceholders(template, body.Channels, body.Roles); len(missing) > 0 {
+		c.JSON(400, gin.H{
+			"success": false,
+			"error":   "Every channel and role in the template must be chosen",
+			"missing": missing,
+		})
+		return
+	}
+
+	resolver, err := newTemplateResolver(guildId, template, body.Channels, body.Roles)
+	if err != nil {
+		c.JSON(400, utils.ErrorStr(err.Error()))
+		return
+	}
+
+	botContext, err := botcontext.ContextForGuild(guildId)
+	if err != nil {
+		_ = c.AbortWithError(http.StatusInternalServerError, app.NewServerError(err))
+		return
+	}
+
+	premiumTier, err := rpc.PremiumClient.GetTierByGuildId(c, guildId, false, botContext.Token, botContext.RateLimiter)
+	if err != nil {
+		_ = c.AbortWithError(http.StatusInternalServerError, app.NewServerError(err))
+		return
+	}
+
+	isPremium := premiumTier > premium.None
+
+	exceeded, err := exceedsPanelQuota(c, guildId, premiumTier, len(template.Panels))
+	if err != nil {
+		_ = c.AbortWithError(http.StatusInternalServerError, app.NewServerError(err))
+		return
+	}
+
+	if exceeded {
+		c.JSON(402, utils.ErrorStr("Importing this template would exceed your panel quota. Purchase premium to unlock more panels."))
+		return
+	}
+
+	ctx, cancel := app.DefaultContext()
+	defer cancel()
+
+	guildData, err := loadPanelGuildData(ctx, botContext, guildId)
+	if err != nil {
+		_ = c.AbortWithError(http.StatusInternalServerError, app.NewServerError(err))
+		return
+	}
+
+	// Only the target guild is needed to map emojis, as they are matched by the name stored in the template
+	emojiRemapper := newPanelRemapper(panelGuildData{}, guildData)
+	templateForms := resolver.templateForms(template)
+
+	panels := make([]importedPanel, len(template.Panels))
+	panelIndexes := make(map[string]int, len(template.Panels))
+	for i, templatePanel := range template.Panels {
+		data, err := resolver.panel(templatePanel)
+		if err == nil {
+			data.Emoji = emojiRemapper.emoji(data.Emoji)
+
+			panels[i].data, panels[i].options, err = preparePanel(PanelValidationContext{
+				Data:          data,
+				GuildId:       guildId,
+				IsPremium:     isPremium,
+				BotContext:    botContext,
+				Channels:      guildData.Channels,
+				Roles:         guildData.Roles,
+				TemplateForms: templateForms,
+			})
+		}
+
+		if err != nil {
+			var validationError *validation.InvalidInputError
+			if errors.As(err, &validationError) {
+				c.JSON(400, gin.H{
+					"success": false,
+					"error":   validationError.Error(),
+					"panel":   templatePanel.Key,
+				})
+			} else {
+				_ = c.AbortWithError(http.StatusInternalServerError, app.NewServerError(err))
+			}
+
+			return
+		}
+
+		panels[i].customId, err = utils.RandString(30)
+		if err != nil {
+			_ = c.AbortWithError(http.StatusInternalServerError, app.NewServerError(err))
+			return
+		}
+
+		panelIndexes[templatePanel.Key] = i
+	}
+
+	var multiPanelData *multiPanelCreateData
+	if template.MultiPanel != nil {
+		multiPanelData, err = resolveTemplateMultiPanel(resolver, *template.MultiPanel, guildData.Channels)
+		if err != nil {
+			var validationError *validation.InvalidInputError
+			if errors.As(err, &validationError) {
+				c.JSON(400, utils.ErrorStr(validationError.Error()))
+			} else {
+				_ = c.AbortWithError(http.StatusInternalServerError, app.NewServerError(err))
+			}
+
+			return
+		}
+	}
+
+	// Everything has been validated, so send the messages
+	for i := range panels {
+		panels[i].messageId, err = sendPanelMessage(botContext, panels[i].data, panels[i].customId, isPremium)
+		if err != nil {
+			deleteImportedMessages(botContext, panels[:i], nil, 0)
+
+			var validationError *validation.InvalidInputError
+			if errors.As(err, &validationError) {
+				c.JSON(400, utils.ErrorStr(validationError.Error()))
+			} else {
+				_ = c.AbortWithError(http.StatusInternalServerError, app.NewServerError(err))
+			}
+
+			return
+		}
+	}
+
+	var multiPanelMessageId uint64
+	if multiPanelData != nil {
+		targets := make([]database.Panel, len(template.MultiPanel.Panels))
+		for i, key := range template.MultiPanel.Panels {
+			panel := panels[panelIndexes[key]]
+			targets[i] = panel.data.intoDatabasePanel(guildId, panel.customId, panel.messageId, nil)
+		}
+
+		messageData := multiPanelData.IntoMessageData(isPremium)
+		multiPanelMessageId, err = messageData.send(botContext, targets)
+		if err != nil {
+			deleteImportedMessages(botContext, panels, nil, 0)
+
+			var unwrapped request.RestError
+			if errors.As(err, &unwrapped) && unwrapped.StatusCode == 403 {
+				c.JSON(http.StatusBadRequest, utils.ErrorJson(errors.New("I do not have permission to send messages in the provided channel")))
+			} else {
+				_ = c.AbortWithError(http.StatusInternalServerError, app.NewServerError(err))
+			}
+
+			return
+		}
+	}
+
+	panelIds := make([]int, len(panels))
+	var multiPanelId *int
+	err = dbclient.Client.Panel.BeginFunc(c, func(tx pgx.Tx) error {
+		formIds := make(map[int]int, len(template.Forms))
+
+		for i, form := range template.Forms {
+			customId, err := utils.RandString(30)
+			if err != nil {
+				return err
+			}
+
+			formId, err := dbclient.Dashboard.PanelTemplates.CreateFormWithTx(c, tx, guildId, form.Title, customId)
+			if err != nil {
+				return err
+			}
+
+			formIds[i+1] = formId
+
+			for _, input := range form.Inputs {
+				inputCustomId, err := utils.RandString(30)
+				if err != nil {
+					return err
+				}
+
+				if _, err := dbclient.Client.FormInput.CreateTx(c,
+					tx,
+					formId,
+					inputCustomId,
+					input.Position,
+					uint8(input.Style),
+					input.Label,
+					input.Placeholder,
+					input.Required,
+					input.MinLength,
+					input.MaxLength,
+				); err != nil {
+					return err
+				}
+			}
+		}
+
+		for i, panel := range panels {
+			data := panel.data
+			if data.FormId != nil {
+				data.FormId = utils.Ptr(formIds[*data.FormId])
+			}
+
+			if data.ExitSurveyFormId != nil {
+				data.ExitSurveyFormId = utils.Ptr(formIds[*data.ExitSurveyFormId])
+			}
+
+			var welcomeMessageEmbed *int
+			if data.WelcomeMessage != nil {
+				embed, fields := data.WelcomeMessage.IntoDatabaseStruct()
+				embed.GuildId = guildId
+
+				id, err := dbclient.Client.Embeds.CreateWithFieldsTx(c, tx, embed, fields)
+				if err != nil {
+					return err
+				}
+
+				welcomeMessageEmbed = &id
+			}
+
+			dbPanel := data.intoDatabasePanel(guildId, panel.customId, panel.messageId, welcomeMessageEmbed)
+
+			var err error
+			panelIds[i], err = storePanelWithTx(c, tx, dbPanel, panel.options)
+			if err != nil {
+				return err
+			}
+		}
+
+		if multiPanelData != nil {
+			targetIds := make([]int, len(template.MultiPanel.Panels))
+			for i, key := range template.MultiPanel.Panels {
+				targetIds[i] = panelIds[panelIndexes[key]]
+			}
+
+			dbEmbed, dbEmbedFields := multiPanelData.Embed.IntoDatabaseStruct()
+			multiPanel := database.MultiPanel{
+				MessageId:             multiPanelMessageId,
+				ChannelId:             multiPanelData.ChannelId,
+				GuildId:               guildId,
+				SelectMenu:            multiPanelData.SelectMenu,
+				SelectMenuPlaceholder: multiPanelData.SelectMenuPlaceholder,
+				Embed: &database.CustomEmbedWithFields{
+					CustomEmbed: dbEmbed,
+					Fields:      dbEmbedFields,
+				},
+			}
+
+			id, err := dbclient.Dashboard.PanelTemplates.CreateMultiPanelWithTx(c, tx, multiPanel, targetIds)
+			if err != nil {
+				return err
+			}
+
+			multiPanelId = &id
+		}
+
+		return nil
+	})
+
+	if err != nil {
+		deleteImportedMessages(botContext, panels, multiPanelData, multiPanelMessageId)
+		_ = c.AbortWithError(http.StatusInternalServerError, app.NewServerError(err))
+		return
+	}
+
+	c.JSON(200, gin.H{
+		"success":        true,
+		"panel_ids":      panelIds,
+		"multi_panel_id": multiPanelId,
+		"unmapped":       emojiRemapper.unmapped,
+	})
+}
+
+// resolveTemplateMultiPanel validates the multi-panel in a template in the same way as MultiPanelCreate
+func resolveTemplateMultiPanel(resolver *templateResolver, multiPanel templateMultiPanel, channels []channel.Channel) (*multiPanelCreateData, error) {
+	channelId, err := resolver.channel(multiPanel.Channel)
+	if err != nil {
+		return nil, err
+	}
+
+	data := multiPanelCreateData{
+		ChannelId:             channelId,
+		SelectMenu:            multiPanel.SelectMenu,
+		SelectMenuPlaceholder: multiPanel.SelectMenuPlaceholder,
+		Embed:                 multiPanel.Embed,
+	}
+
+	if err := validate.Struct(data); err != nil {
+		var validationErrors validator.ValidationErrors
+		if ok := errors.As(err, &validationErrors); !ok {
+			return nil, app.NewError(err, "An error occurred while validating the multi-panel")
+		}
+
+		formatted := "Your input contained the following errors:\n" + utils.FormatValidationErrors(validationErrors)
+		return nil, validation.NewInvalidInputError(formatted)
+	}
+
+	if data.Embed == nil {
+		return nil, validation.NewInvalidInputError("Your embed message does not contain any content")
+	}
+
+	if err := validateEmbed(data.Embed); err != nil {
+		return nil, err
+	}
+
+	for _, ch := range channels {
+		if ch.Id == data.ChannelId && (ch.Type == channel.ChannelTypeGuildText || ch.Type == channel.ChannelTypeGuildNews) {
+			return &data, nil
+		}
+	}
+
+	return nil, validation.NewInvalidInputError("Multi-panel channel not found")
+}
+
+// deleteImportedMessages removes the messages that were sent for an import that could not be completed
+func deleteImportedMessages(botContext *botcontext.BotContext, panels []importedPanel, multiPanelData *multiPanelCreateData, multiPanelMessageId uint64) {
+	ctx, cancel := app.DefaultContext()
+	defer cancel()
+
+	for _, panel := range panels {
+		if panel.messageId != 0 {
+			_ = rest.DeleteMessage(ctx, botContext.Token, botContext.RateLimiter, panel.data.ChannelId, panel.messageId)
+		}
+	}
+
+	if multiPanelData != nil && multiPanelMessageId != 0 {
+		_ = rest.DeleteMessage(ctx, botContext.Token, botContext.RateLimiter, multiPanelData.ChannelId, multiPanelMessageId)
+	}
+}
@@ -14,6 +14,7 @@ import (
 	"github.com/TicketsBot-cloud/dashboard/botcontext"
 	dbclient "github.com/TicketsBot-cloud/dashboard/database"
 	"github.com/TicketsBot-cloud/dashboard/utils"
+	"github.com/TicketsBot-cloud/dashboard/utils/placeholders"
 	"github.com/TicketsBot-cloud/dashboard/utils/types"
 	"github.com/TicketsBot-cloud/database"
 	"github.com/rxdn/gdl/objects/channel"
@@ -48,6 +49,10 @@ type PanelValidationContext struct {
 	BotContext *botcontext.BotContext
 	Channels   []channel.Channel
 	Roles      []guild.Role
+
+	// TemplateForms holds the IDs that the panel refers to forms by, for forms that are being imported along with the
+	// panel, and so are not stored yet. If set, the panel may only refer to these forms.
+	TemplateForms []int
 }
 
 func ValidatePanelBody(validationContext PanelValidationContext) error {
@@ -73,6 +78,7 @@ func panelValidators() []validation.Validator[PanelValidationContext] {
 		validateTeams,
 		validateNamingScheme,
 		validateWelcomeMessage,
+		validateWelcomeMessagePlaceholders,
 		validateAccessControlList,
 		validatePendingCategory,
 	}
@@ -207,12 +213,20 @@ func validateButtonLabel(ctx PanelValidationContext) validation.ValidationFunc {
 	}
 }
 
-func validatedNullableFormId(guildId uint64, formId *int) validation.ValidationFunc {
+func validatedNullableFormId(ctx PanelValidationContext, formId *int) validation.ValidationFunc {
 	return func() error {
 		if formId == nil {
 			return nil
 		}
 
+		if ctx.TemplateForms != nil {
+			if !utils.Contains(ctx.TemplateForms, *formId) {
+				return validation.NewInvalidInputError("Form not found")
+			}
+
+			return nil
+		}
+
 		form, ok, err := dbclient.Client.Forms.Get(context.Background(), *formId)
 		if err != nil {
 			return err
@@ -222,7 +236,7 @@ func validatedNullableFormId(guildId uint64, formId *int) validation.ValidationF
 			return validation.NewInvalidInputError("Form not found")
 		}
 
-		if form.GuildId != guildId {
+		if form.GuildId != ctx.GuildId {
 			return validation.NewInvalidInputError("Guild ID mismatch when validating form")
 		}
 
@@ -231,12 +245,12 @@ func validatedNullableFormId(guildId uint64, formId *int) validation.ValidationF
 }
 
 func validateFormId(ctx PanelValidationContext) validation.ValidationFunc {
-	return validatedNullableFormId(ctx.GuildId, ctx.Data.FormId)
+	return validatedNullableFormId(ctx, ctx.Data.FormId)
 }
 
 // Check premium on the worker side to maintain settings if user unsubscribes and later resubscribes
 func validateExitSurveyFormId(ctx PanelValidationContext) validation.ValidationFunc {
-	return validatedNullableFormId(ctx.GuildId, ctx.Data.ExitSurveyFormId)
+	return validatedNullableFormId(ctx, ctx.Data.ExitSurveyFormId)
 }
 
 func validatePendingCategory(ctx PanelValidationContext) validation.ValidationFunc {
@@ -316,6 +330,31 @@ func validateWelcomeMessage(ctx PanelValidationContext) validation.ValidationFun
 	}
 }
 
+func validateWelcomeMessagePlaceholders(ctx PanelValidationContext) validation.ValidationFunc {
+	return func() error {
+		if placeholders.ValidateEmbed(ctx.Data.WelcomeMessage, nil) == nil {
+			return nil
+		}
+
+		// Welcome messages may also use the placeholders of the guild's custom integrations
+		integrationPlaceholders, err := dbclient.Client.CustomIntegrationPlaceholders.GetAllActivatedInGuild(context.Background(), ctx.GuildId)
+		if err != nil {
+			return err
+		}
+
+		names := make([]string, len(integrationPlaceholders))
+		for i, placeholder := range integrationPlaceholders {
+			names[i] = placeholder.Name
+		}
+
+		if err := placeholders.ValidateEmbed(ctx.Data.WelcomeMessage, names); err != nil {
+			return validation.NewInvalidInputError(err.Error())
+		}
+
+		return nil
+	}
+}
+
 func validateAccessControlList(ctx PanelValidationContext) validation.ValidationFunc {
 	return func() error {
 		acl := ctx.Data.AccessControlList
@@ -0,0 +1,139 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/TicketsBot-cloud/dashboard/app"
+	dbclient "github.com/TicketsBot-cloud/dashboard/database"
+	"github.com/TicketsBot-cloud/dashboard/utils"
+	"github.com/gin-gonic/gin"
+)
+
+type feedbackResponse struct {
+	From         string                        `json:"from"`
+	To           string                        `json:"to"` // Inclusive
+	Timezone     string                        `json:"timezone"`
+	Distribution dbclient.FeedbackDistribution `json:"distribution"`
+	Feedback     []dbclient.FeedbackEntry      `json:"feedback"`
+	Page         int                           `json:"page"`
+	PageSize     int                           `json:"page_size"`
+}
+
+const feedbackPageSize = 25
+
+// GetFeedback lists the ratings and exit survey answers left on tickets closed over a range of days, most recent
+// first, along with the distribution of ratings across every page. The feedback can be filtered by panel, exit survey
+// form, rating and the content of the answers.
+func GetFeedback(ctx *gin.Context) {
+	guildId := ctx.Keys["guildid"].(uint64)
+
+	statsRange, opts, err := parseFeedbackOptions(ctx, guildId, time.Now())
+	if err != nil {
+		ctx.JSON(400, utils.ErrorJson(err))
+		return
+	}
+
+	page := 1
+	if raw := ctx.Query("page"); raw != "" {
+		page, err = strconv.Atoi(raw)
+		if err != nil || page < 1 {
+			ctx.JSON(400, utils.ErrorStr("Invalid page"))
+			return
+		}
+	}
+
+	opts.Limit = feedbackPageSize
+	opts.Offset = feedbackPageSize * (page - 1)
+
+	res := feedbackResponse{
+		From:     statsRange.From.Format(statsDateFormat),
+		To:       statsRange.To.AddDate(0, 0, -1).Format(statsDateFormat),
+		Timezone: statsRange.Timezone.String(),
+		Page:     page,
+		PageSize: feedbackPageSize,
+	}
+
+	res.Distribution, err = dbclient.Dashboard.Feedback.GetDistribution(ctx, opts)
+	if err != nil {
+		_ = ctx.AbortWithError(http.StatusInternalServerError, app.NewServerError(err))
+		return
+	}
+
+	res.Feedback, err = dbclient.Dashboard.Feedback.Get(ctx, opts)
+	if err != nil {
+		_ = ctx.AbortWithError(http.StatusInternalServerError, app.NewServerError(err))
+		return
+	}
+
+	ctx.JSON(200, res)
+}
+
+// parseFeedbackOptions reads the date range, and the panel_id, form_id, min_rating, max_rating, with_responses and
+// query filters, from the query string
+func parseFeedbackOptions(ctx *gin.Context, guildId uint64, now time.Time) (statsRange, dbclient.FeedbackQueryOptions, error) {
+	statsRange, err := parseStatsRange(ctx, now)
+	if err != nil {
+		return statsRange, dbclient.FeedbackQueryOptions{}, err
+	}
+
+	opts := dbclient.FeedbackQueryOptions{
+		GuildId:       guildId,
+		From:          statsRange.From,
+		To:            statsRange.To,
+		WithResponses: ctx.Query("with_responses") == "true",
+		Search:        ctx.Query("query"),
+	}
+
+	if raw := ctx.Query("panel_id"); raw != "" {
+		panelId, err := strconv.Atoi(raw)
+		if err != nil {
+			return statsRange, opts, errors.New("Invalid panel ID")
+		}
+
+		opts.PanelId = &panelId
+	}
+
+	if raw := ctx.Query("form_id"); raw != "" {
+		formId, err := strconv.Atoi(raw)
+		if err != nil {
+			return statsRange, opts, errors.New("Invalid form ID")
+		}
+
+		opts.FormId = &formId
+	}
+
+	if opts.MinRating, err = parseRating(ctx.Query("min_rating")); err != nil {
+		return statsRange, opts, err
+	}
+
+	if opts.MaxRating, err = parseRating(ctx.Query("max_rating")); err != nil {
+		return statsRange, opts, err
+	}
+
+	if opts.MinRating != nil && opts.MaxRating != nil && *opts.MinRating > *opts.MaxRating {
+		return statsRange, opts, errors.New("The minimum rating must not be greater than the maximum rating")
+	}
+
+	if len(opts.Search) > 100 {
+		return statsRange, opts, errors.New("Search query must be 100 characters or less")
+	}
+
+	return statsRange, opts, nil
+}
+
+func parseRating(raw string) (*uint8, error) {
+	if raw == "" {
+		return nil, nil
+	}
+
+	rating, err := strconv.ParseUint(raw, 10, 8)
+	if err != nil || rating < 1 || rating > 5 {
+		return nil, errors.New("Ratings must be between 1 and 5")
+	}
+
+	value := uint8(rating)
+	return &value, nil
+}
@@ -0,0 +1,72 @@
+package api
+
+import (
+	"testing"
+	"time"
+
+	dbclient "github.com/TicketsBot-cloud/dashboard/database"
+	"github.com/rxdn/gdl/objects/user"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseFeedbackOptions(t *testing.T) {
+	_, opts, err := parseFeedbackOptions(testStatsContext("panel_id=2&form_id=3&min_rating=2&max_rating=4&with_responses=true&query=slow"), 1, testStatsNow)
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(1), opts.GuildId)
+	assert.Equal(t, 2, *opts.PanelId)
+	assert.Equal(t, 3, *opts.FormId)
+	assert.Equal(t, uint8(2), *opts.MinRating)
+	assert.Equal(t, uint8(4), *opts.MaxRating)
+	assert.True(t, opts.WithResponses)
+	assert.Equal(t, "slow", opts.Search)
+}
+
+func TestParseFeedbackOptionsInvalidRating(t *testing.T) {
+	for _, query := range []string{"min_rating=0", "max_rating=6", "min_rating=x", "min_rating=4&max_rating=2"} {
+		_, _, err := parseFeedbackOptions(testStatsContext(query), 1, testStatsNow)
+		assert.Error(t, err, query)
+	}
+}
+
+func TestFeedbackCsvRows(t *testing.T) {
+	rating := uint8(4)
+	entry := dbclient.FeedbackEntry{
+		TicketId:  10,
+		UserId:    1,
+		CloseTime: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+		Rating:    &rating,
+	}
+
+	users := map[uint64]user.User{1: {Id: 1, Username: "alice"}}
+
+	rows := feedbackCsvRows(entry, users)
+	assert.Equal(t, [][]string{{"10", "1", "alice", "", "", "", "", "2024-01-01T00:00:00Z", "4", "", "", "", ""}}, rows)
+
+	entry.Responses = []dbclient.FeedbackResponse{
+		{QuestionId: 1, Question: "How did we do?", Response: "Great"},
+		{QuestionId: 2, Question: "Anything else?", Response: "No"},
+	}
+
+	rows = feedbackCsvRows(entry, users)
+	assert.Len(t, rows, 2)
+	assert.Equal(t, []string{"How did we do?", "Great"}, rows[0][len(feedbackCsvHeader)-2:])
+	assert.Equal(t, []string{"Anything else?", "No"}, rows[1][len(feedbackCsvHeader)-2:])
+	assert.Equal(t, "alice", rows[1][2])
+}
+
+func TestFeedbackCsvRowsFormulaInjection(t *testing.T) {
+	entry := dbclient.FeedbackEntry{
+		TicketId:  10,
+		UserId:    1,
+		CloseTime: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+		Responses: []dbclient.FeedbackResponse{
+			{QuestionId: 1, Question: "Feedback", Response: "=HYPERLINK(\"https://example.com\")"},
+		},
+	}
+
+	users := map[uint64]user.User{1: {Id: 1, Username: "@alice"}}
+
+	rows := feedbackCsvRows(entry, users)
+	assert.Equal(t, "'@alice", rows[0][2])
+	assert.Equal(t, "'=HYPERLINK(\"https://example.com\")", rows[0][len(feedbackCsvHeader)-1])
+}
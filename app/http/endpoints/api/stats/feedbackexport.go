@@ -0,0 +1,149 @@
+package api
+
+import (
+	"encoding/csv"
+	"fmt"
+	"strconv"
+	"time"
+
+	dbclient "github.com/TicketsBot-cloud/dashboard/database"
+	"github.com/TicketsBot-cloud/dashboard/log"
+	"github.com/TicketsBot-cloud/dashboard/rpc/cache"
+	"github.com/TicketsBot-cloud/dashboard/utils"
+	"github.com/gin-gonic/gin"
+	"github.com/rxdn/gdl/objects/user"
+	"go.uber.org/zap"
+)
+
+const feedbackExportBatchSize = 500
+
+var feedbackCsvHeader = []string{
+	"ticket_id",
+	"user_id",
+	"username",
+	"claimed_by",
+	"claimed_by_username",
+	"panel_id",
+	"panel_title",
+	"close_time",
+	"rating",
+	"form_id",
+	"form_title",
+	"question",
+	"response",
+}
+
+// ExportFeedback streams all feedback matching the same filters as GetFeedback as CSV. There is one row per exit
+// survey answer, with the ticket's details repeated on each, and a single row without a question for tickets that were
+// only rated.
+func ExportFeedback(ctx *gin.Context) {
+	guildId := ctx.Keys["guildid"].(uint64)
+
+	_, opts, err := parseFeedbackOptions(ctx, guildId, time.Now())
+	if err != nil {
+		ctx.JSON(400, utils.ErrorJson(err))
+		return
+	}
+
+	filename := fmt.Sprintf("feedback-%d-%s.csv", guildId, time.Now().UTC().Format("2006-01-02"))
+	ctx.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, filename))
+	ctx.Header("Content-Type", "text/csv; charset=utf-8")
+
+	writer := csv.NewWriter(ctx.Writer)
+	if err := writer.Write(feedbackCsvHeader); err != nil {
+		ctx.JSON(500, utils.ErrorJson(err))
+		return
+	}
+
+	ctx.Status(200)
+
+	// The status has already been sent, so errors can only be logged
+	err = dbclient.Dashboard.Feedback.Export(ctx, opts, feedbackExportBatchSize, func(batch []dbclient.FeedbackEntry) error {
+		userIds := make([]uint64, 0, len(batch))
+		for _, entry := range batch {
+			userIds = append(userIds, entry.UserId)
+
+			if entry.ClaimedBy != nil {
+				userIds = append(userIds, *entry.ClaimedBy)
+			}
+		}
+
+		users, err := cache.Instance.GetUsers(ctx, userIds)
+		if err != nil {
+			return err
+		}
+
+		for _, entry := range batch {
+			for _, row := range feedbackCsvRows(entry, users) {
+				if err := writer.Write(row); err != nil {
+					return err
+				}
+			}
+		}
+
+		writer.Flush()
+		if err := writer.Error(); err != nil {
+			return err
+		}
+
+		ctx.Writer.Flush()
+		return nil
+	})
+
+	if err != nil {
+		log.Logger.Error("Failed to export feedback", zap.Uint64("guild_id", guildId), zap.Error(err))
+	}
+}
+
+func feedbackCsvRows(entry dbclient.FeedbackEntry, users map[uint64]user.User) [][]string {
+	ticket := []string{
+		strconv.Itoa(entry.TicketId),
+		strconv.FormatUint(entry.UserId, 10),
+		utils.CsvSafe(usernameOf(users, &entry.UserId)),
+		formatOptionalId(entry.ClaimedBy),
+		utils.CsvSafe(usernameOf(users, entry.ClaimedBy)),
+		formatOptionalInt(entry.PanelId),
+		utils.CsvSafe(utils.ValueOrZero(entry.PanelTitle)),
+		entry.CloseTime.UTC().Format(time.RFC3339),
+		formatOptionalInt(entry.Rating),
+		formatOptionalInt(entry.FormId),
+		utils.CsvSafe(utils.ValueOrZero(entry.FormTitle)),
+	}
+
+	if len(entry.Responses) == 0 {
+		return [][]string{append(ticket, "", "")}
+	}
+
+	rows := make([][]string, len(entry.Responses))
+	for i, response := range entry.Responses {
+		row := make([]string, len(ticket), len(ticket)+2)
+		copy(row, ticket)
+		rows[i] = append(row, utils.CsvSafe(response.Question), utils.CsvSafe(response.Response))
+	}
+
+	return rows
+}
+
+func usernameOf(users map[uint64]user.User, userId *uint64) string {
+	if userId == nil {
+		return ""
+	}
+
+	return users[*userId].Username
+}
+
+func formatOptionalId(id *uint64) string {
+	if id == nil {
+		return ""
+	}
+
+	return strconv.FormatUint(*id, 10)
+}
+
+func formatOptionalInt[T ~int | ~uint8](value *T) string {
+	if value == nil {
+		return ""
+	}
+
+	return strconv.Itoa(int(*value))
+}
@@ -0,0 +1,236 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/TicketsBot-cloud/dashboard/app"
+	"github.com/TicketsBot-cloud/dashboard/botcontext"
+	dbclient "github.com/TicketsBot-cloud/dashboard/database"
+	"github.com/TicketsBot-cloud/dashboard/rpc/cache"
+	"github.com/TicketsBot-cloud/dashboard/utils"
+	"github.com/gin-gonic/gin"
+)
+
+type staffReportResponse struct {
+	From     string                      `json:"from"`
+	To       string                      `json:"to"` // Inclusive
+	Timezone string                      `json:"timezone"`
+	TeamId   *int                        `json:"team_id"`
+	Staff    []dbclient.StaffTicketStats `json:"staff"`
+}
+
+// GetStaffReport returns the tickets claimed and closed, the ratings of claimed tickets, first response times and
+// dashboard messages of each staff member over a range of days. Closures are only counted for support staff. Passing a
+// team_id restricts the report to the members of that support team, including those who are members through a role,
+// and lists members without any activity too. Members through a role are found from the member cache, so only cached
+// members without any activity are listed.
+func GetStaffReport(ctx *gin.Context) {
+	guildId := ctx.Keys["guildid"].(uint64)
+
+	statsRange, err := parseStatsRange(ctx, time.Now())
+	if err != nil {
+		ctx.JSON(400, utils.ErrorJson(err))
+		return
+	}
+
+	res := staffReportResponse{
+		From:     statsRange.From.Format(statsDateFormat),
+		To:       statsRange.To.AddDate(0, 0, -1).Format(statsDateFormat),
+		Timezone: statsRange.Timezone.String(),
+	}
+
+	if raw := ctx.Query("team_id"); raw != "" {
+		teamId, err := strconv.Atoi(raw)
+		if err != nil {
+			ctx.JSON(400, utils.ErrorStr("Invalid team ID"))
+			return
+		}
+
+		exists, err := dbclient.Client.SupportTeam.Exists(ctx, teamId, guildId)
+		if err != nil {
+			_ = ctx.AbortWithError(http.StatusInternalServerError, app.NewServerError(err))
+			return
+		}
+
+		if !exists {
+			ctx.JSON(404, utils.ErrorStr("Support team with provided ID not found"))
+			return
+		}
+
+		res.TeamId = &teamId
+	}
+
+	res.Staff, err = dbclient.Dashboard.TicketStats.GetByStaff(ctx, guildId, statsRange.From, statsRange.To)
+	if err != nil {
+		_ = ctx.AbortWithError(http.StatusInternalServerError, app.NewServerError(err))
+		return
+	}
+
+	if res.TeamId != nil {
+		res.Staff, err = filterTeamStaff(ctx, guildId, *res.TeamId, res.Staff)
+	} else {
+		res.Staff, err = filterSupportStaff(ctx, guildId, res.Staff)
+	}
+
+	if err != nil {
+		_ = ctx.AbortWithError(http.StatusInternalServerError, app.NewServerError(err))
+		return
+	}
+
+	ctx.JSON(200, res)
+}
+
+func filterTeamStaff(ctx context.Context, guildId uint64, teamId int, staff []dbclient.StaffTicketStats) ([]dbclient.StaffTicketStats, error) {
+	userIds, err := dbclient.Client.SupportTeamMembers.Get(ctx, teamId)
+	if err != nil {
+		return nil, err
+	}
+
+	roleIds, err := dbclient.Client.SupportTeamRoles.Get(ctx, teamId)
+	if err != nil {
+		return nil, err
+	}
+
+	roleMembers, err := cache.Instance.GetMembersWithRoles(ctx, guildId, roleIds)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, userId := range roleMembers {
+		if !utils.Contains(userIds, userId) {
+			userIds = append(userIds, userId)
+		}
+	}
+
+	return teamStaff(staff, userIds), nil
+}
+
+// filterSupportStaff removes the closures of users who are not support staff, such as ticket openers who closed their
+// own tickets, along with any users who have no activity left
+func filterSupportStaff(ctx context.Context, guildId uint64, staff []dbclient.StaffTicketStats) ([]dbclient.StaffTicketStats, error) {
+	botContext, err := botcontext.ContextForGuild(guildId)
+	if err != nil {
+		return nil, err
+	}
+
+	ownerId, err := botContext.GetGuildOwner(ctx, guildId)
+	if err != nil {
+		return nil, err
+	}
+
+	userIds, err := dbclient.Client.Permissions.GetSupport(ctx, guildId)
+	if err != nil {
+		return nil, err
+	}
+
+	teamUserIds, err := dbclient.Client.SupportTeamMembers.GetAllSupportMembers(ctx, guildId)
+	if err != nil {
+		return nil, err
+	}
+
+	roleIds, err := dbclient.Client.RolePermissions.GetSupportRoles(ctx, guildId)
+	if err != nil {
+		return nil, err
+	}
+
+	teamRoleIds, err := dbclient.Client.SupportTeamRoles.GetAllSupportRoles(ctx, guildId)
+	if err != nil {
+		return nil, err
+	}
+
+	userIds = append(append(userIds, teamUserIds...), ownerId)
+	roleIds = append(roleIds, teamRoleIds...)
+
+	hasSupportRole, err := hasAnyRole(ctx, guildId, staff, userIds, roleIds)
+	if err != nil {
+		return nil, err
+	}
+
+	return supportStaff(staff, userIds, hasSupportRole), nil
+}
+
+// hasAnyRole reports whether each of the staff who are not in userIds has one of the given roles. Roles are read from
+// the member cache, and members who are not cached, such as those who have left the guild, are treated as having none.
+func hasAnyRole(ctx context.Context, guildId uint64, staff []dbclient.StaffTicketStats, userIds, roleIds []uint64) ([]bool, error) {
+	hasRole := make([]bool, len(staff))
+	if len(roleIds) == 0 {
+		return hasRole, nil
+	}
+
+	var lookup []uint64
+	for _, member := range staff {
+		if !utils.Contains(userIds, member.UserId) {
+			lookup = append(lookup, member.UserId)
+		}
+	}
+
+	memberRoles, err := cache.Instance.GetMemberRoles(ctx, guildId, lookup)
+	if err != nil {
+		return nil, err
+	}
+
+	for i, member := range staff {
+		for _, roleId := range memberRoles[member.UserId] {
+			if utils.Contains(roleIds, roleId) {
+				hasRole[i] = true
+				break
+			}
+		}
+	}
+
+	return hasRole, nil
+}
+
+// supportStaff zeroes the closures of users who are neither in userIds nor have a support role, and drops those who
+// have no other activity. The rest are re-sorted by claims and closures, keeping their order on ties.
+func supportStaff(staff []dbclient.StaffTicketStats, userIds []uint64, hasSupportRole []bool) []dbclient.StaffTicketStats {
+	filtered := make([]dbclient.StaffTicketStats, 0, len(staff))
+	for i, member := range staff {
+		if !hasSupportRole[i] && !utils.Contains(userIds, member.UserId) {
+			member.Closed = 0
+
+			if member.Claimed == 0 && member.ClaimedRating.Count == 0 && member.FirstResponse.Count == 0 && member.DashboardMessages == 0 {
+				continue
+			}
+		}
+
+		filtered = append(filtered, member)
+	}
+
+	sort.SliceStable(filtered, func(i, j int) bool {
+		return filtered[i].Claimed+filtered[i].Closed > filtered[j].Claimed+filtered[j].Closed
+	})
+
+	return filtered
+}
+
+// teamStaff returns the staff who are members of the team, directly or through a role, followed by the members who had
+// no activity in the range
+func teamStaff(staff []dbclient.StaffTicketStats, userIds []uint64) []dbclient.StaffTicketStats {
+	filtered := make([]dbclient.StaffTicketStats, 0)
+	for _, member := range staff {
+		if utils.Contains(userIds, member.UserId) {
+			filtered = append(filtered, member)
+		}
+	}
+
+	for _, userId := range userIds {
+		active := false
+		for _, member := range staff {
+			if member.UserId == userId {
+				active = true
+				break
+			}
+		}
+
+		if !active {
+			filtered = append(filtered, dbclient.StaffTicketStats{UserId: userId})
+		}
+	}
+
+	return filtered
+}
@@ -0,0 +1,48 @@
+package api
+
+import (
+	"testing"
+
+	dbclient "github.com/TicketsBot-cloud/dashboard/database"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTeamStaff(t *testing.T) {
+	staff := []dbclient.StaffTicketStats{
+		{UserId: 1, Claimed: 5},
+		{UserId: 2, Claimed: 3},
+		{UserId: 3, Closed: 1},
+	}
+
+	// Users 1 and 3 are members with activity, and users 4 and 5 are members with no activity, e.g. through a role
+	filtered := teamStaff(staff, []uint64{1, 4, 3, 5})
+	assert.Equal(t, []dbclient.StaffTicketStats{
+		{UserId: 1, Claimed: 5},
+		{UserId: 3, Closed: 1},
+		{UserId: 4},
+		{UserId: 5},
+	}, filtered)
+}
+
+func TestTeamStaffEmpty(t *testing.T) {
+	filtered := teamStaff([]dbclient.StaffTicketStats{{UserId: 1}}, nil)
+	assert.NotNil(t, filtered)
+	assert.Empty(t, filtered)
+}
+
+func TestSupportStaff(t *testing.T) {
+	staff := []dbclient.StaffTicketStats{
+		{UserId: 1, Claimed: 1, Closed: 4},
+		{UserId: 2, Closed: 3},
+		{UserId: 3, Claimed: 2, Closed: 2},
+		{UserId: 4, Closed: 1},
+	}
+
+	// User 1 is staff directly, user 4 through a role, user 2 only closed their own ticket and user 3 claimed one too
+	filtered := supportStaff(staff, []uint64{1}, []bool{false, false, false, true})
+	assert.Equal(t, []dbclient.StaffTicketStats{
+		{UserId: 1, Claimed: 1, Closed: 4},
+		{UserId: 3, Claimed: 2},
+		{UserId: 4, Closed: 1},
+	}, filtered)
+}
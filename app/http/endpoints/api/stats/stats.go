@@ -0,0 +1,59 @@
+package api
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/TicketsBot-cloud/dashboard/app"
+	dbclient "github.com/TicketsBot-cloud/dashboard/database"
+	"github.com/TicketsBot-cloud/dashboard/utils"
+	"github.com/gin-gonic/gin"
+)
+
+type statsResponse struct {
+	From     string                       `json:"from"`
+	To       string                       `json:"to"` // Inclusive
+	Timezone string                       `json:"timezone"`
+	Summary  dbclient.TicketStatsSummary  `json:"summary"`
+	Daily    []dbclient.DailyTicketCounts `json:"daily"`
+	Panels   []dbclient.PanelTicketStats  `json:"panels"`
+}
+
+// GetStats returns ticket volume, response and resolution times, and ratings over a range of days. Response times are
+// reported in seconds.
+func GetStats(ctx *gin.Context) {
+	guildId := ctx.Keys["guildid"].(uint64)
+
+	statsRange, err := parseStatsRange(ctx, time.Now())
+	if err != nil {
+		ctx.JSON(400, utils.ErrorJson(err))
+		return
+	}
+
+	res := statsResponse{
+		From:     statsRange.From.Format(statsDateFormat),
+		To:       statsRange.To.AddDate(0, 0, -1).Format(statsDateFormat),
+		Timezone: statsRange.Timezone.String(),
+	}
+
+	// The queries are run one at a time, so that a single request doesn't take every connection in the pool
+	res.Summary, err = dbclient.Dashboard.TicketStats.GetSummary(ctx, guildId, statsRange.From, statsRange.To)
+	if err != nil {
+		_ = ctx.AbortWithError(http.StatusInternalServerError, app.NewServerError(err))
+		return
+	}
+
+	res.Daily, err = dbclient.Dashboard.TicketStats.GetDaily(ctx, guildId, statsRange.From, statsRange.To, res.Timezone)
+	if err != nil {
+		_ = ctx.AbortWithError(http.StatusInternalServerError, app.NewServerError(err))
+		return
+	}
+
+	res.Panels, err = dbclient.Dashboard.TicketStats.GetByPanel(ctx, guildId, statsRange.From, statsRange.To)
+	if err != nil {
+		_ = ctx.AbortWithError(http.StatusInternalServerError, app.NewServerError(err))
+		return
+	}
+
+	ctx.JSON(200, res)
+}
@@ -0,0 +1,80 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// statsRange is a range of whole days in a timezone. To is exclusive.
+type statsRange struct {
+	From     time.Time
+	To       time.Time
+	Timezone *time.Location
+}
+
+const (
+	statsDateFormat   = "2006-01-02"
+	statsDefaultDays  = 30
+	statsMaxRangeDays = 366
+)
+
+// parseStatsRange reads the inclusive from and to dates (YYYY-MM-DD) and the IANA timezone that days are bounded in
+// from the query string. Without dates, the last 30 days, including today, are used.
+func parseStatsRange(ctx *gin.Context, now time.Time) (statsRange, error) {
+	location := time.UTC
+	if timezone := ctx.Query("timezone"); timezone != "" {
+		// Local depends on the server's configuration
+		if timezone == "Local" {
+			return statsRange{}, errors.New("Invalid timezone")
+		}
+
+		var err error
+		location, err = time.LoadLocation(timezone)
+		if err != nil {
+			return statsRange{}, errors.New("Invalid timezone")
+		}
+	}
+
+	year, month, day := now.In(location).Date()
+	today := time.Date(year, month, day, 0, 0, 0, 0, location)
+
+	from := today.AddDate(0, 0, -(statsDefaultDays - 1))
+	if raw := ctx.Query("from"); raw != "" {
+		parsed, err := time.ParseInLocation(statsDateFormat, raw, location)
+		if err != nil {
+			return statsRange{}, errors.New("Invalid from date")
+		}
+
+		from = parsed
+	}
+
+	to := today
+	if raw := ctx.Query("to"); raw != "" {
+		parsed, err := time.ParseInLocation(statsDateFormat, raw, location)
+		if err != nil {
+			return statsRange{}, errors.New("Invalid to date")
+		}
+
+		to = parsed
+	}
+
+	if to.Before(from) {
+		return statsRange{}, errors.New("from must not be after to")
+	}
+
+	// Include the whole of the last day
+	to = to.AddDate(0, 0, 1)
+
+	if to.After(from.AddDate(0, 0, statsMaxRangeDays)) {
+		return statsRange{}, fmt.Errorf("The range can't be longer than %d days", statsMaxRangeDays)
+	}
+
+	return statsRange{
+		From:     from,
+		To:       to,
+		Timezone: location,
+	}, nil
+}
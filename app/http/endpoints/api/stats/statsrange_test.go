@@ -0,0 +1,57 @@
+package api
+
+import (
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func testStatsContext(query string) *gin.Context {
+	ctx, _ := gin.CreateTestContext(httptest.NewRecorder())
+	ctx.Request = httptest.NewRequest("GET", "/stats?"+query, nil)
+	return ctx
+}
+
+var testStatsNow = time.Date(2024, 3, 15, 23, 30, 0, 0, time.UTC)
+
+func TestStatsRangeDefault(t *testing.T) {
+	statsRange, err := parseStatsRange(testStatsContext(""), testStatsNow)
+	assert.NoError(t, err)
+	assert.Equal(t, time.Date(2024, 2, 15, 0, 0, 0, 0, time.UTC), statsRange.From)
+	assert.Equal(t, time.Date(2024, 3, 16, 0, 0, 0, 0, time.UTC), statsRange.To)
+}
+
+func TestStatsRangeTimezone(t *testing.T) {
+	statsRange, err := parseStatsRange(testStatsContext("timezone=Asia/Tokyo&from=2024-03-01&to=2024-03-02"), testStatsNow)
+	assert.NoError(t, err)
+	assert.Equal(t, time.Date(2024, 2, 29, 15, 0, 0, 0, time.UTC), statsRange.From.UTC())
+	assert.Equal(t, time.Date(2024, 3, 2, 15, 0, 0, 0, time.UTC), statsRange.To.UTC())
+	assert.Equal(t, "Asia/Tokyo", statsRange.Timezone.String())
+}
+
+func TestStatsRangeDefaultsToTodayInTimezone(t *testing.T) {
+	// It is already the 16th in Tokyo
+	statsRange, err := parseStatsRange(testStatsContext("timezone=Asia/Tokyo"), testStatsNow)
+	assert.NoError(t, err)
+	assert.Equal(t, "2024-03-17", statsRange.To.Format(statsDateFormat))
+}
+
+func TestStatsRangeValidation(t *testing.T) {
+	for _, query := range []string{
+		"timezone=Not/AZone",
+		"timezone=Local",
+		"from=2024-13-01",
+		"to=yesterday",
+		"from=2024-03-02&to=2024-03-01",
+		"from=2020-01-01&to=2024-01-01",
+	} {
+		_, err := parseStatsRange(testStatsContext(query), testStatsNow)
+		assert.Error(t, err, query)
+	}
+
+	_, err := parseStatsRange(testStatsContext("from=2023-03-16&to=2024-03-15"), testStatsNow)
+	assert.NoError(t, err)
+}
@@ -11,6 +11,7 @@ import (
 	dbclient "github.com/TicketsBot-cloud/dashboard/database"
 	"github.com/TicketsBot-cloud/dashboard/rpc"
 	"github.com/TicketsBot-cloud/dashboard/utils"
+	"github.com/TicketsBot-cloud/dashboard/utils/placeholders"
 	"github.com/TicketsBot-cloud/dashboard/utils/types"
 	"github.com/TicketsBot-cloud/database"
 	"github.com/gin-gonic/gin"
@@ -82,6 +83,11 @@ func CreateTag(ctx *gin.Context) {
 		return
 	}
 
+	if err := data.verifyPlaceholders(); err != nil {
+		ctx.JSON(400, utils.ErrorJson(err))
+		return
+	}
+
 	botContext, err := botcontext.ContextForGuild(guildId)
 	if err != nil {
 		ctx.JSON(500, utils.ErrorJson(err))
@@ -168,3 +174,13 @@ func (t *tag) verifyContent() bool {
 
 	return false
 }
+
+func (t *tag) verifyPlaceholders() error {
+	if t.Content != nil {
+		if err := placeholders.Validate(*t.Content, nil); err != nil {
+			return err
+		}
+	}
+
+	return placeholders.ValidateEmbed(t.Embed, nil)
+}
@@ -70,5 +70,11 @@ func CloseTicket(c *gin.Context) {
 		return
 	}
 
+	// Ticket is being closed now, so any scheduled close is redundant
+	if err := database.Dashboard.ScheduledClose.Delete(c, guildId, ticket.Id); err != nil {
+		_ = c.AbortWithError(http.StatusInternalServerError, app.NewServerError(err))
+		return
+	}
+
 	c.JSON(200, utils.SuccessResponse)
 }
@@ -0,0 +1,76 @@
+package api
+
+import (
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/TicketsBot-cloud/dashboard/app"
+	"github.com/TicketsBot-cloud/dashboard/database"
+	"github.com/TicketsBot-cloud/dashboard/utils"
+	"github.com/gin-gonic/gin"
+)
+
+type overdueTicket struct {
+	TicketId  int       `json:"id"`
+	PanelId   *int      `json:"panel_id"`
+	UserId    uint64    `json:"user_id,string"`
+	ClaimedBy *uint64   `json:"claimed_by,string"`
+	OpenedAt  time.Time `json:"opened_at"`
+	Sla       slaData   `json:"sla"`
+}
+
+const maxOverdueWindow = time.Hour * 24 * 7
+
+// GetOverdueTickets lists the open tickets that have breached their SLA, or will breach it within the given window,
+// ordered by the soonest deadline first.
+func GetOverdueTickets(c *gin.Context) {
+	guildId := c.Keys["guildid"].(uint64)
+
+	window := slaAtRiskWindow
+	if raw := c.Query("within"); raw != "" {
+		seconds, err := strconv.Atoi(raw)
+		if err != nil || seconds < 0 {
+			c.JSON(400, utils.ErrorStr("Invalid window"))
+			return
+		}
+
+		window = min(time.Duration(seconds)*time.Second, maxOverdueWindow)
+	}
+
+	tickets, err := database.Client.Tickets.GetGuildOpenTicketsWithMetadata(c, guildId)
+	if err != nil {
+		_ = c.AbortWithError(http.StatusInternalServerError, app.NewServerError(err))
+		return
+	}
+
+	slas, err := getTicketSlas(c, guildId, tickets, time.Now(), window)
+	if err != nil {
+		_ = c.AbortWithError(http.StatusInternalServerError, app.NewServerError(err))
+		return
+	}
+
+	overdue := make([]overdueTicket, 0)
+	for _, ticket := range tickets {
+		sla, ok := slas[ticket.Id]
+		if !ok || sla.Status == SlaStatusOk {
+			continue
+		}
+
+		overdue = append(overdue, overdueTicket{
+			TicketId:  ticket.Id,
+			PanelId:   ticket.PanelId,
+			UserId:    ticket.Ticket.UserId,
+			ClaimedBy: ticket.ClaimedBy,
+			OpenedAt:  ticket.OpenTime,
+			Sla:       sla,
+		})
+	}
+
+	sort.Slice(overdue, func(i, j int) bool {
+		return overdue[i].Sla.DueAt.Before(overdue[j].Sla.DueAt)
+	})
+
+	c.JSON(200, overdue)
+}
@@ -13,20 +13,25 @@ import (
 
 type (
 	listTicketsResponse struct {
-		Tickets       []ticketData         `json:"tickets"`
-		PanelTitles   map[int]string       `json:"panel_titles"`
-		ResolvedUsers map[uint64]user.User `json:"resolved_users"`
-		SelfId        uint64               `json:"self_id,string"`
+		Tickets       []ticketData           `json:"tickets"`
+		PanelTitles   map[int]string         `json:"panel_titles"`
+		Labels        []database.TicketLabel `json:"labels"`
+		ResolvedUsers map[uint64]user.User   `json:"resolved_users"`
+		SelfId        uint64                 `json:"self_id,string"`
 	}
 
 	ticketData struct {
-		TicketId            int        `json:"id"`
-		PanelId             *int       `json:"panel_id"`
-		UserId              uint64     `json:"user_id,string"`
-		ClaimedBy           *uint64    `json:"claimed_by,string"`
-		OpenedAt            time.Time  `json:"opened_at"`
-		LastResponseTime    *time.Time `json:"last_response_time"`
-		LastResponseIsStaff *bool      `json:"last_response_is_staff"`
+		TicketId            int                      `json:"id"`
+		PanelId             *int                     `json:"panel_id"`
+		UserId              uint64                   `json:"user_id,string"`
+		ClaimedBy           *uint64                  `json:"claimed_by,string"`
+		OpenedAt            time.Time                `json:"opened_at"`
+		LastResponseTime    *time.Time               `json:"last_response_time"`
+		LastResponseIsStaff *bool                    `json:"last_response_is_staff"`
+		Labels              []int                    `json:"labels"`
+		Priority            *database.TicketPriority `json:"priority"`
+		ScheduledCloseAt    *time.Time               `json:"scheduled_close_at"`
+		Sla                 *slaData                 `json:"sla"`
 	}
 )
 
@@ -67,6 +72,41 @@ func GetTickets(c *gin.Context) {
 		return
 	}
 
+	labels, err := database.Dashboard.TicketLabels.GetByGuild(c, guildId)
+	if err != nil {
+		_ = c.AbortWithError(http.StatusInternalServerError, app.NewServerError(err))
+		return
+	}
+
+	ticketIds := make([]int, len(tickets))
+	for i, ticket := range tickets {
+		ticketIds[i] = ticket.Id
+	}
+
+	ticketLabels, err := database.Dashboard.TicketLabelAssignments.GetMulti(c, guildId, ticketIds)
+	if err != nil {
+		_ = c.AbortWithError(http.StatusInternalServerError, app.NewServerError(err))
+		return
+	}
+
+	priorities, err := database.Dashboard.TicketPriority.GetMulti(c, guildId, ticketIds)
+	if err != nil {
+		_ = c.AbortWithError(http.StatusInternalServerError, app.NewServerError(err))
+		return
+	}
+
+	scheduledCloses, err := database.Dashboard.ScheduledClose.GetByGuild(c, guildId)
+	if err != nil {
+		_ = c.AbortWithError(http.StatusInternalServerError, app.NewServerError(err))
+		return
+	}
+
+	slas, err := getTicketSlas(c, guildId, tickets, time.Now(), slaAtRiskWindow)
+	if err != nil {
+		_ = c.AbortWithError(http.StatusInternalServerError, app.NewServerError(err))
+		return
+	}
+
 	data := make([]ticketData, len(tickets))
 	for i, ticket := range tickets {
 		data[i] = ticketData{
@@ -77,12 +117,30 @@ func GetTickets(c *gin.Context) {
 			OpenedAt:            ticket.OpenTime,
 			LastResponseTime:    ticket.LastMessageTime,
 			LastResponseIsStaff: ticket.UserIsStaff,
+			Labels:              ticketLabels[ticket.Id],
+		}
+
+		if data[i].Labels == nil {
+			data[i].Labels = make([]int, 0)
+		}
+
+		if priority, ok := priorities[ticket.Id]; ok {
+			data[i].Priority = &priority
+		}
+
+		if scheduled, ok := scheduledCloses[ticket.Id]; ok {
+			data[i].ScheduledCloseAt = &scheduled.CloseAt
+		}
+
+		if sla, ok := slas[ticket.Id]; ok {
+			data[i].Sla = &sla
 		}
 	}
 
 	c.JSON(200, listTicketsResponse{
 		Tickets:       data,
 		PanelTitles:   panelTitles,
+		Labels:        labels,
 		ResolvedUsers: users,
 		SelfId:        userId,
 	})
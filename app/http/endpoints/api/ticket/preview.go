@@ -0,0 +1,123 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/TicketsBot-cloud/dashboard/app"
+	"github.com/TicketsBot-cloud/dashboard/botcontext"
+	dbclient "github.com/TicketsBot-cloud/dashboard/database"
+	"github.com/TicketsBot-cloud/dashboard/utils"
+	"github.com/TicketsBot-cloud/dashboard/utils/placeholders"
+	"github.com/TicketsBot-cloud/dashboard/utils/types"
+	"github.com/gin-gonic/gin"
+)
+
+type (
+	// Either a saved tag or panel welcome message is previewed, or the unsaved content and embed are previewed
+	previewBody struct {
+		TagId   *string            `json:"tag_id"`
+		PanelId *int               `json:"panel_id"`
+		Content *string            `json:"content"`
+		Embed   *types.CustomEmbed `json:"embed"`
+	}
+
+	previewResponse struct {
+		Content *string            `json:"content"`
+		Embed   *types.CustomEmbed `json:"embed"`
+		// Placeholders that are only substituted by the bot when the message is sent, and so are left as-is
+		Unrendered []string `json:"unrendered"`
+	}
+)
+
+// PreviewMessage renders the placeholders in a tag or panel welcome message against a ticket, without sending it
+func PreviewMessage(c *gin.Context) {
+	guildId := c.Keys["guildid"].(uint64)
+
+	ticket, ok := getTicketWithPermission(c)
+	if !ok {
+		return
+	}
+
+	var body previewBody
+	if err := c.BindJSON(&body); err != nil {
+		c.JSON(400, utils.ErrorStr("Invalid request body"))
+		return
+	}
+
+	content, embed := body.Content, body.Embed
+
+	if body.TagId != nil {
+		tag, ok, err := dbclient.Client.Tag.Get(c, guildId, *body.TagId)
+		if err != nil {
+			_ = c.AbortWithError(http.StatusInternalServerError, app.NewServerError(err))
+			return
+		}
+
+		if !ok {
+			c.JSON(404, utils.ErrorStr("Tag not found"))
+			return
+		}
+
+		content, embed = tag.Content, nil
+		if tag.Embed != nil {
+			embed = types.NewCustomEmbed(tag.Embed.CustomEmbed, tag.Embed.Fields)
+		}
+	} else if body.PanelId != nil {
+		panel, err := dbclient.Client.Panel.GetById(c, *body.PanelId)
+		if err != nil {
+			_ = c.AbortWithError(http.StatusInternalServerError, app.NewServerError(err))
+			return
+		}
+
+		if panel.PanelId == 0 || panel.GuildId != guildId {
+			c.JSON(404, utils.ErrorStr("Panel not found"))
+			return
+		}
+
+		content, embed = nil, nil
+		if panel.WelcomeMessageEmbed != nil {
+			welcomeMessage, err := dbclient.Client.Embeds.GetEmbed(c, *panel.WelcomeMessageEmbed)
+			if err != nil {
+				_ = c.AbortWithError(http.StatusInternalServerError, app.NewServerError(err))
+				return
+			}
+
+			fields, err := dbclient.Client.EmbedFields.GetFieldsForEmbed(c, *panel.WelcomeMessageEmbed)
+			if err != nil {
+				_ = c.AbortWithError(http.StatusInternalServerError, app.NewServerError(err))
+				return
+			}
+
+			embed = types.NewCustomEmbed(&welcomeMessage, fields)
+		}
+	}
+
+	botContext, err := botcontext.ContextForGuild(guildId)
+	if err != nil {
+		_ = c.AbortWithError(http.StatusInternalServerError, app.NewServerError(err))
+		return
+	}
+
+	names := placeholders.FindInEmbed(embed)
+	if content != nil {
+		names = append(names, placeholders.Find(*content)...)
+	}
+
+	values := placeholders.ForTicket(c, botContext, ticket, names)
+
+	var response previewResponse
+	if content != nil {
+		response.Content = utils.Ptr(placeholders.Render(*content, values))
+	}
+
+	response.Embed = placeholders.RenderEmbed(embed, values)
+
+	response.Unrendered = make([]string, 0)
+	for _, name := range names {
+		if _, ok := values[name]; !ok && !utils.Contains(response.Unrendered, name) {
+			response.Unrendered = append(response.Unrendered, name)
+		}
+	}
+
+	c.JSON(200, response)
+}
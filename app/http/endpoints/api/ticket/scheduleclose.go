@@ -0,0 +1,111 @@
+package api
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/TicketsBot-cloud/dashboard/app"
+	dbclient "github.com/TicketsBot-cloud/dashboard/database"
+	"github.com/TicketsBot-cloud/dashboard/utils"
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	minScheduledCloseDelay = time.Minute
+	maxScheduledCloseDelay = time.Hour * 24 * 30
+)
+
+type scheduleCloseBody struct {
+	Reason        *string `json:"reason"`
+	Delay         int     `json:"delay"` // Seconds
+	CancelOnReply bool    `json:"cancel_on_reply"`
+}
+
+func GetScheduledClose(c *gin.Context) {
+	guildId := c.Keys["guildid"].(uint64)
+
+	ticket, ok := getTicketWithPermission(c)
+	if !ok {
+		return
+	}
+
+	scheduled, ok, err := dbclient.Dashboard.ScheduledClose.Get(c, guildId, ticket.Id)
+	if err != nil {
+		_ = c.AbortWithError(http.StatusInternalServerError, app.NewServerError(err))
+		return
+	}
+
+	if !ok {
+		c.JSON(http.StatusNotFound, utils.ErrorStr("Ticket does not have a scheduled close"))
+		return
+	}
+
+	c.JSON(200, scheduled)
+}
+
+func ScheduleClose(c *gin.Context) {
+	guildId := c.Keys["guildid"].(uint64)
+	userId := c.Keys["userid"].(uint64)
+
+	var body scheduleCloseBody
+	if err := c.BindJSON(&body); err != nil {
+		c.JSON(400, utils.ErrorStr("Invalid request body"))
+		return
+	}
+
+	delay := time.Duration(body.Delay) * time.Second
+	if delay < minScheduledCloseDelay || delay > maxScheduledCloseDelay {
+		c.JSON(400, utils.ErrorStr("Close delay must be between 1 minute and 30 days"))
+		return
+	}
+
+	if body.Reason != nil && len(*body.Reason) > 1024 {
+		c.JSON(400, utils.ErrorStr("Close reason must be less than 1024 characters"))
+		return
+	}
+
+	utils.SetNilIfZero(&body.Reason)
+
+	ticket, ok := getTicketWithPermission(c)
+	if !ok {
+		return
+	}
+
+	if !ticket.Open {
+		c.JSON(400, utils.ErrorStr("Ticket is already closed"))
+		return
+	}
+
+	scheduled := dbclient.ScheduledClose{
+		GuildId:       guildId,
+		TicketId:      ticket.Id,
+		UserId:        userId,
+		Reason:        body.Reason,
+		CloseAt:       time.Now().Add(delay),
+		CancelOnReply: body.CancelOnReply,
+		CreatedAt:     time.Now(),
+	}
+
+	if err := dbclient.Dashboard.ScheduledClose.Set(c, scheduled); err != nil {
+		_ = c.AbortWithError(http.StatusInternalServerError, app.NewServerError(err))
+		return
+	}
+
+	c.JSON(200, scheduled)
+}
+
+func CancelScheduledClose(c *gin.Context) {
+	guildId := c.Keys["guildid"].(uint64)
+
+	ticket, ok := getTicketWithPermission(c)
+	if !ok {
+		return
+	}
+
+	if err := dbclient.Dashboard.ScheduledClose.Delete(c, guildId, ticket.Id); err != nil {
+		_ = c.AbortWithError(http.StatusInternalServerError, app.NewServerError(err))
+		return
+	}
+
+	c.Status(204)
+}
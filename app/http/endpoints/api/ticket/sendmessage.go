@@ -9,11 +9,13 @@ import (
 	"github.com/TicketsBot-cloud/common/premium"
 	"github.com/TicketsBot-cloud/dashboard/botcontext"
 	"github.com/TicketsBot-cloud/dashboard/database"
+	"github.com/TicketsBot-cloud/dashboard/log"
 	"github.com/TicketsBot-cloud/dashboard/rpc"
 	"github.com/TicketsBot-cloud/dashboard/utils"
 	"github.com/gin-gonic/gin"
 	"github.com/rxdn/gdl/rest"
 	"github.com/rxdn/gdl/rest/request"
+	"go.uber.org/zap"
 )
 
 type sendMessageBody struct {
@@ -157,6 +159,7 @@ func SendMessage(ctx *gin.Context) {
 				go database.Client.Webhooks.Delete(ctx, guildId, ticketId)
 			}
 		} else {
+			recordDashboardMessage(ctx, guildId, ticketId, userId)
 			ctx.JSON(200, gin.H{
 				"success": true,
 			})
@@ -195,7 +198,16 @@ func SendMessage(ctx *gin.Context) {
 		return
 	}
 
+	recordDashboardMessage(ctx, guildId, ticketId, userId)
 	ctx.JSON(200, gin.H{
 		"success": true,
 	})
 }
+
+// recordDashboardMessage logs that a message was sent through the dashboard, for the staff report. The message has
+// already been sent, so failures are not returned to the user.
+func recordDashboardMessage(ctx context.Context, guildId uint64, ticketId int, userId uint64) {
+	if err := database.Dashboard.DashboardMessages.Record(ctx, guildId, ticketId, userId); err != nil {
+		log.Logger.Warn("Failed to record dashboard message", zap.Uint64("guild_id", guildId), zap.Int("ticket_id", ticketId), zap.Error(err))
+	}
+}
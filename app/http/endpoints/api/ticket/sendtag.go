@@ -11,6 +11,7 @@ import (
 	"github.com/TicketsBot-cloud/dashboard/database"
 	"github.com/TicketsBot-cloud/dashboard/rpc"
 	"github.com/TicketsBot-cloud/dashboard/utils"
+	"github.com/TicketsBot-cloud/dashboard/utils/placeholders"
 	"github.com/TicketsBot-cloud/dashboard/utils/types"
 	"github.com/gin-gonic/gin"
 	"github.com/rxdn/gdl/objects/channel/embed"
@@ -121,11 +122,23 @@ func SendTag(ctx *gin.Context) {
 		return
 	}
 
-	var embeds []*embed.Embed
+	content := utils.ValueOrZero(tag.Content)
+
+	var customEmbed *types.CustomEmbed
 	if tag.Embed != nil {
-		embeds = []*embed.Embed{
-			types.NewCustomEmbed(tag.Embed.CustomEmbed, tag.Embed.Fields).IntoDiscordEmbed(),
-		}
+		customEmbed = types.NewCustomEmbed(tag.Embed.CustomEmbed, tag.Embed.Fields)
+	}
+
+	// Only look up the values of placeholders that the tag uses
+	if names := placeholders.Needed(append(placeholders.Find(content), placeholders.FindInEmbed(customEmbed)...)); len(names) > 0 {
+		values := placeholders.ForTicket(ctx, botContext, ticket, names)
+		content = placeholders.Render(content, values)
+		customEmbed = placeholders.RenderEmbed(customEmbed, values)
+	}
+
+	var embeds []*embed.Embed
+	if customEmbed != nil {
+		embeds = []*embed.Embed{customEmbed.IntoDiscordEmbed()}
 	}
 
 	if webhook.Id != 0 {
@@ -138,7 +151,7 @@ func SendTag(ctx *gin.Context) {
 			}
 
 			webhookData = rest.WebhookBody{
-				Content:   utils.ValueOrZero(tag.Content),
+				Content:   content,
 				Embeds:    embeds,
 				Username:  guild.Name,
 				AvatarUrl: guild.IconUrl(),
@@ -151,7 +164,7 @@ func SendTag(ctx *gin.Context) {
 			}
 
 			webhookData = rest.WebhookBody{
-				Content:   utils.ValueOrZero(tag.Content),
+				Content:   content,
 				Embeds:    embeds,
 				Username:  user.EffectiveName(),
 				AvatarUrl: user.AvatarUrl(256),
@@ -168,6 +181,7 @@ func SendTag(ctx *gin.Context) {
 				go database.Client.Webhooks.Delete(ctx, guildId, ticketId)
 			}
 		} else {
+			recordDashboardMessage(ctx, guildId, ticketId, userId)
 			ctx.JSON(200, gin.H{
 				"success": true,
 			})
@@ -175,7 +189,7 @@ func SendTag(ctx *gin.Context) {
 		}
 	}
 
-	message := utils.ValueOrZero(tag.Content)
+	message := content
 	if !settings.AnonymiseDashboardResponses {
 		user, err := botContext.GetUser(context.Background(), userId)
 		if err != nil {
@@ -206,6 +220,7 @@ func SendTag(ctx *gin.Context) {
 		return
 	}
 
+	recordDashboardMessage(ctx, guildId, ticketId, userId)
 	ctx.JSON(200, gin.H{
 		"success": true,
 	})
@@ -0,0 +1,125 @@
+package api
+
+import (
+	"context"
+	"time"
+
+	"github.com/TicketsBot-cloud/dashboard/database"
+	dbmodel "github.com/TicketsBot-cloud/database"
+)
+
+type (
+	SlaType   string
+	SlaStatus string
+
+	slaData struct {
+		Type   SlaType   `json:"type"`
+		DueAt  time.Time `json:"due_at"`
+		Status SlaStatus `json:"status"`
+	}
+
+	slaInput struct {
+		OpenTime           time.Time
+		LastMessageTime    *time.Time
+		LastMessageIsStaff *bool
+		HasFirstResponse   bool
+	}
+)
+
+const (
+	SlaTypeFirstResponse SlaType = "first_response"
+	SlaTypeFollowUp      SlaType = "follow_up"
+
+	SlaStatusOk       SlaStatus = "ok"
+	SlaStatusAtRisk   SlaStatus = "at_risk"
+	SlaStatusBreached SlaStatus = "breached"
+)
+
+// slaAtRiskWindow is how long before the deadline a ticket is considered to be at risk of breaching its SLA
+const slaAtRiskWindow = time.Minute * 15
+
+// calculateSla returns the SLA deadline that currently applies to the ticket, or nil if there is no deadline, e.g. if
+// staff were the last to respond, or the panel has no targets set.
+func calculateSla(ticket slaInput, settings database.PanelSla, now time.Time, atRiskWindow time.Duration) *slaData {
+	lastMessageIsStaff := ticket.LastMessageIsStaff != nil && *ticket.LastMessageIsStaff
+
+	var data slaData
+	if !ticket.HasFirstResponse && !lastMessageIsStaff {
+		if settings.FirstResponseTime == nil {
+			return nil
+		}
+
+		data = slaData{
+			Type:  SlaTypeFirstResponse,
+			DueAt: ticket.OpenTime.Add(*settings.FirstResponseTime),
+		}
+	} else {
+		// Staff have responded last, so the ball is in the user's court
+		if settings.FollowUpTime == nil || ticket.LastMessageTime == nil || lastMessageIsStaff {
+			return nil
+		}
+
+		data = slaData{
+			Type:  SlaTypeFollowUp,
+			DueAt: ticket.LastMessageTime.Add(*settings.FollowUpTime),
+		}
+	}
+
+	if !now.Before(data.DueAt) {
+		data.Status = SlaStatusBreached
+	} else if data.DueAt.Sub(now) <= atRiskWindow {
+		data.Status = SlaStatusAtRisk
+	} else {
+		data.Status = SlaStatusOk
+	}
+
+	return &data
+}
+
+// getTicketSlas returns a map of ticket ID -> SLA data for the given open tickets. Tickets without an SLA deadline are
+// not included in the map.
+func getTicketSlas(ctx context.Context, guildId uint64, tickets []dbmodel.TicketWithMetadata, now time.Time, atRiskWindow time.Duration) (map[int]slaData, error) {
+	settings, err := database.Dashboard.PanelSla.GetByGuild(ctx, guildId)
+	if err != nil {
+		return nil, err
+	}
+
+	slas := make(map[int]slaData)
+	if len(settings) == 0 {
+		return slas, nil
+	}
+
+	ticketIds := make([]int, len(tickets))
+	for i, ticket := range tickets {
+		ticketIds[i] = ticket.Id
+	}
+
+	responded, err := database.Dashboard.FirstResponseTime.GetResponded(ctx, guildId, ticketIds)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, ticket := range tickets {
+		if ticket.PanelId == nil {
+			continue
+		}
+
+		panelSettings, ok := settings[*ticket.PanelId]
+		if !ok {
+			continue
+		}
+
+		input := slaInput{
+			OpenTime:           ticket.OpenTime,
+			LastMessageTime:    ticket.LastMessageTime,
+			LastMessageIsStaff: ticket.UserIsStaff,
+			HasFirstResponse:   responded[ticket.Id],
+		}
+
+		if sla := calculateSla(input, panelSettings, now, atRiskWindow); sla != nil {
+			slas[ticket.Id] = *sla
+		}
+	}
+
+	return slas, nil
+}
@@ -0,0 +1,69 @@
+package api
+
+import (
+	"testing"
+	"time"
+
+	"github.com/TicketsBot-cloud/dashboard/database"
+	"github.com/TicketsBot-cloud/dashboard/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+var (
+	testNow      = time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	testSettings = database.PanelSla{
+		FirstResponseTime: utils.Ptr(time.Hour),
+		FollowUpTime:      utils.Ptr(time.Hour * 4),
+	}
+)
+
+func TestSlaFirstResponseOk(t *testing.T) {
+	sla := calculateSla(slaInput{OpenTime: testNow.Add(-time.Minute * 10)}, testSettings, testNow, slaAtRiskWindow)
+	assert.NotNil(t, sla)
+	assert.Equal(t, SlaTypeFirstResponse, sla.Type)
+	assert.Equal(t, testNow.Add(time.Minute*50), sla.DueAt)
+	assert.Equal(t, SlaStatusOk, sla.Status)
+}
+
+func TestSlaFirstResponseAtRisk(t *testing.T) {
+	sla := calculateSla(slaInput{OpenTime: testNow.Add(-time.Minute * 50)}, testSettings, testNow, slaAtRiskWindow)
+	assert.NotNil(t, sla)
+	assert.Equal(t, SlaStatusAtRisk, sla.Status)
+}
+
+func TestSlaFirstResponseBreached(t *testing.T) {
+	sla := calculateSla(slaInput{OpenTime: testNow.Add(-time.Hour * 2)}, testSettings, testNow, slaAtRiskWindow)
+	assert.NotNil(t, sla)
+	assert.Equal(t, SlaStatusBreached, sla.Status)
+}
+
+func TestSlaStaffRespondedLast(t *testing.T) {
+	input := slaInput{
+		OpenTime:           testNow.Add(-time.Hour * 24),
+		LastMessageTime:    utils.Ptr(testNow.Add(-time.Hour * 12)),
+		LastMessageIsStaff: utils.Ptr(true),
+		HasFirstResponse:   true,
+	}
+
+	assert.Nil(t, calculateSla(input, testSettings, testNow, slaAtRiskWindow))
+}
+
+func TestSlaFollowUp(t *testing.T) {
+	input := slaInput{
+		OpenTime:           testNow.Add(-time.Hour * 24),
+		LastMessageTime:    utils.Ptr(testNow.Add(-time.Hour * 5)),
+		LastMessageIsStaff: utils.Ptr(false),
+		HasFirstResponse:   true,
+	}
+
+	sla := calculateSla(input, testSettings, testNow, slaAtRiskWindow)
+	assert.NotNil(t, sla)
+	assert.Equal(t, SlaTypeFollowUp, sla.Type)
+	assert.Equal(t, testNow.Add(-time.Hour), sla.DueAt)
+	assert.Equal(t, SlaStatusBreached, sla.Status)
+}
+
+func TestSlaNoTarget(t *testing.T) {
+	settings := database.PanelSla{FollowUpTime: utils.Ptr(time.Hour)}
+	assert.Nil(t, calculateSla(slaInput{OpenTime: testNow}, settings, testNow, slaAtRiskWindow))
+}
@@ -0,0 +1,96 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/TicketsBot-cloud/dashboard/app"
+	dbclient "github.com/TicketsBot-cloud/dashboard/database"
+	"github.com/TicketsBot-cloud/dashboard/utils"
+	"github.com/TicketsBot-cloud/database"
+	"github.com/gin-gonic/gin"
+)
+
+const ticketLabelLimit = 10
+
+type setLabelsBody struct {
+	LabelIds []int `json:"label_ids"`
+}
+
+func SetTicketLabels(c *gin.Context) {
+	guildId := c.Keys["guildid"].(uint64)
+
+	var body setLabelsBody
+	if err := c.BindJSON(&body); err != nil {
+		c.JSON(400, utils.ErrorStr("Invalid request body"))
+		return
+	}
+
+	ticket, ok := getTicketWithPermission(c)
+	if !ok {
+		return
+	}
+
+	labelIds := utils.ToSet(body.LabelIds).Collect()
+	if len(labelIds) > ticketLabelLimit {
+		c.JSON(400, utils.ErrorStr("Tickets cannot have more than %d labels", ticketLabelLimit))
+		return
+	}
+
+	if len(labelIds) > 0 {
+		valid, err := dbclient.Dashboard.TicketLabels.AllExistForGuild(c, guildId, labelIds)
+		if err != nil {
+			_ = c.AbortWithError(http.StatusInternalServerError, app.NewServerError(err))
+			return
+		}
+
+		if !valid {
+			c.JSON(400, utils.ErrorStr("Invalid label"))
+			return
+		}
+	}
+
+	if err := dbclient.Dashboard.TicketLabelAssignments.Replace(c, guildId, ticket.Id, labelIds); err != nil {
+		_ = c.AbortWithError(http.StatusInternalServerError, app.NewServerError(err))
+		return
+	}
+
+	c.JSON(200, utils.SuccessResponse)
+}
+
+// getTicketWithPermission writes the error response itself, returning false if the ticket does not exist or the user
+// does not have permission to view it
+func getTicketWithPermission(c *gin.Context) (database.Ticket, bool) {
+	guildId := c.Keys["guildid"].(uint64)
+	userId := c.Keys["userid"].(uint64)
+
+	ticketId, err := strconv.Atoi(c.Param("ticketId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, utils.ErrorStr("Invalid ticket ID"))
+		return database.Ticket{}, false
+	}
+
+	ticket, err := dbclient.Client.Tickets.Get(c, ticketId, guildId)
+	if err != nil {
+		_ = c.AbortWithError(http.StatusInternalServerError, app.NewServerError(err))
+		return database.Ticket{}, false
+	}
+
+	if ticket.UserId == 0 || ticket.GuildId != guildId {
+		c.JSON(http.StatusNotFound, utils.ErrorStr("Ticket not found"))
+		return database.Ticket{}, false
+	}
+
+	hasPermission, requestErr := utils.HasPermissionToViewTicket(c, guildId, userId, ticket)
+	if requestErr != nil {
+		c.JSON(requestErr.StatusCode, utils.ErrorJson(requestErr))
+		return database.Ticket{}, false
+	}
+
+	if !hasPermission {
+		c.JSON(http.StatusForbidden, utils.ErrorStr("You do not have permission to modify this ticket"))
+		return database.Ticket{}, false
+	}
+
+	return ticket, true
+}
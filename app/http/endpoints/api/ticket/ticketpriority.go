@@ -0,0 +1,49 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/TicketsBot-cloud/dashboard/app"
+	dbclient "github.com/TicketsBot-cloud/dashboard/database"
+	"github.com/TicketsBot-cloud/dashboard/utils"
+	"github.com/gin-gonic/gin"
+)
+
+type setPriorityBody struct {
+	Priority *dbclient.TicketPriority `json:"priority"`
+}
+
+// SetTicketPriority sets the priority of the ticket, or clears it if the priority is null
+func SetTicketPriority(c *gin.Context) {
+	guildId := c.Keys["guildid"].(uint64)
+
+	var body setPriorityBody
+	if err := c.BindJSON(&body); err != nil {
+		c.JSON(400, utils.ErrorStr("Invalid request body"))
+		return
+	}
+
+	if body.Priority != nil && !body.Priority.IsValid() {
+		c.JSON(400, utils.ErrorStr("Invalid priority"))
+		return
+	}
+
+	ticket, ok := getTicketWithPermission(c)
+	if !ok {
+		return
+	}
+
+	var err error
+	if body.Priority == nil {
+		err = dbclient.Dashboard.TicketPriority.Delete(c, guildId, ticket.Id)
+	} else {
+		err = dbclient.Dashboard.TicketPriority.Set(c, guildId, ticket.Id, *body.Priority)
+	}
+
+	if err != nil {
+		_ = c.AbortWithError(http.StatusInternalServerError, app.NewServerError(err))
+		return
+	}
+
+	c.JSON(200, utils.SuccessResponse)
+}
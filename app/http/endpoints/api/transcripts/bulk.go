@@ -0,0 +1,129 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/TicketsBot-cloud/dashboard/app"
+	"github.com/TicketsBot-cloud/dashboard/chatreplica"
+	dbclient "github.com/TicketsBot-cloud/dashboard/database"
+	"github.com/TicketsBot-cloud/dashboard/s3"
+	"github.com/TicketsBot-cloud/dashboard/utils"
+	"github.com/gin-gonic/gin"
+)
+
+type (
+	bulkExportBody struct {
+		Format       chatreplica.Format `json:"format"`
+		UserId       *uint64            `json:"user_id,string"`
+		ClosedAfter  *time.Time         `json:"closed_after"`
+		ClosedBefore *time.Time         `json:"closed_before"`
+	}
+
+	bulkExportResponse struct {
+		dbclient.TranscriptExportJob
+		DownloadUrl *string `json:"download_url"`
+	}
+)
+
+const (
+	bulkExportListLimit   = 10
+	bulkExportUrlValidity = time.Hour
+)
+
+// CreateBulkExport queues a job that collects every closed ticket's transcript matching the filter into a ZIP file.
+// Only one job per guild may be active at a time.
+func CreateBulkExport(ctx *gin.Context) {
+	guildId, userId := ctx.Keys["guildid"].(uint64), ctx.Keys["userid"].(uint64)
+
+	var body bulkExportBody
+	if err := ctx.BindJSON(&body); err != nil {
+		ctx.JSON(400, utils.ErrorJson(err))
+		return
+	}
+
+	if !body.Format.IsValid() {
+		ctx.JSON(400, utils.ErrorStr("Invalid format"))
+		return
+	}
+
+	if body.ClosedAfter != nil && body.ClosedBefore != nil && !body.ClosedAfter.Before(*body.ClosedBefore) {
+		ctx.JSON(400, utils.ErrorStr("closed_after must be before closed_before"))
+		return
+	}
+
+	filter := dbclient.TranscriptExportFilter{
+		UserId:       body.UserId,
+		ClosedAfter:  body.ClosedAfter,
+		ClosedBefore: body.ClosedBefore,
+	}
+
+	job, err := dbclient.Dashboard.TranscriptExportJobs.Create(ctx, guildId, userId, string(body.Format), filter)
+	if err != nil {
+		if errors.Is(err, dbclient.ErrExportAlreadyActive) {
+			ctx.JSON(409, utils.ErrorStr("An export is already in progress for this server"))
+		} else {
+			_ = ctx.AbortWithError(http.StatusInternalServerError, app.NewServerError(err))
+		}
+
+		return
+	}
+
+	ctx.JSON(201, bulkExportResponse{TranscriptExportJob: job})
+}
+
+// ListBulkExports returns the guild's most recent export jobs, including the progress of any active job
+func ListBulkExports(ctx *gin.Context) {
+	guildId := ctx.Keys["guildid"].(uint64)
+
+	jobs, err := dbclient.Dashboard.TranscriptExportJobs.GetByGuild(ctx, guildId, bulkExportListLimit)
+	if err != nil {
+		_ = ctx.AbortWithError(http.StatusInternalServerError, app.NewServerError(err))
+		return
+	}
+
+	ctx.JSON(200, jobs)
+}
+
+// GetBulkExport returns the status of an export job, with a short-lived download URL once it has completed
+func GetBulkExport(ctx *gin.Context) {
+	guildId := ctx.Keys["guildid"].(uint64)
+
+	jobId, err := strconv.Atoi(ctx.Param("jobId"))
+	if err != nil {
+		ctx.JSON(400, utils.ErrorStr("Invalid job ID"))
+		return
+	}
+
+	job, ok, err := dbclient.Dashboard.TranscriptExportJobs.Get(ctx, guildId, jobId)
+	if err != nil {
+		_ = ctx.AbortWithError(http.StatusInternalServerError, app.NewServerError(err))
+		return
+	}
+
+	if !ok {
+		ctx.JSON(404, utils.ErrorStr("Export not found"))
+		return
+	}
+
+	res := bulkExportResponse{TranscriptExportJob: job}
+	if job.Status == dbclient.TranscriptExportStatusCompleted && job.ObjectKey != nil {
+		params := url.Values{}
+		params.Set("response-content-disposition", fmt.Sprintf(`attachment; filename="transcripts-%d-%d.zip"`, guildId, job.Id))
+
+		presigned, err := s3.S3Client.PresignedGetObject(ctx, s3.ExportBucket(), *job.ObjectKey, bulkExportUrlValidity, params)
+		if err != nil {
+			_ = ctx.AbortWithError(http.StatusInternalServerError, app.NewServerError(err))
+			return
+		}
+
+		downloadUrl := presigned.String()
+		res.DownloadUrl = &downloadUrl
+	}
+
+	ctx.JSON(200, res)
+}
@@ -0,0 +1,243 @@
+package api
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/TicketsBot-cloud/dashboard/app/http/validation"
+	dbclient "github.com/TicketsBot-cloud/dashboard/database"
+	"github.com/TicketsBot-cloud/dashboard/log"
+	"github.com/TicketsBot-cloud/dashboard/rpc/cache"
+	"github.com/TicketsBot-cloud/dashboard/utils"
+	"github.com/gin-gonic/gin"
+	"github.com/rxdn/gdl/objects/user"
+	"go.uber.org/zap"
+)
+
+type (
+	exportBody struct {
+		wrappedQueryOptions
+		Format      exportFormat `json:"format"`
+		IncludeOpen bool         `json:"include_open"`
+	}
+
+	exportFormat string
+
+	exportRow struct {
+		dbclient.TicketExportRow
+		Username          *string `json:"username"`
+		ClaimedByUsername *string `json:"claimed_by_username"`
+		ClosedByUsername  *string `json:"closed_by_username"`
+	}
+)
+
+const (
+	exportFormatCsv    exportFormat = "csv"
+	exportFormatNdjson exportFormat = "ndjson"
+
+	exportBatchSize = 500
+)
+
+var exportCsvHeader = []string{
+	"ticket_id",
+	"user_id",
+	"username",
+	"claimed_by",
+	"claimed_by_username",
+	"panel_id",
+	"panel_title",
+	"open",
+	"open_time",
+	"close_time",
+	"close_reason",
+	"closed_by",
+	"closed_by_username",
+	"rating",
+}
+
+// ExportTickets streams all tickets matching the filter as CSV or NDJSON, in the requested order. Tickets are read from
+// the database and written to the response in batches, so the export is never held in memory in its entirety.
+func ExportTickets(ctx *gin.Context) {
+	guildId := ctx.Keys["guildid"].(uint64)
+
+	var body exportBody
+	if err := ctx.BindJSON(&body); err != nil {
+		ctx.JSON(400, utils.ErrorJson(err))
+		return
+	}
+
+	if body.Format == "" {
+		body.Format = exportFormatCsv
+	}
+
+	if body.Format != exportFormatCsv && body.Format != exportFormatNdjson {
+		ctx.JSON(400, utils.ErrorStr("Invalid export format"))
+		return
+	}
+
+	opts, err := body.toQueryOptions(guildId)
+	if err != nil {
+		var validationError *validation.InvalidInputError
+		if errors.As(err, &validationError) {
+			ctx.JSON(400, utils.ErrorJson(err))
+		} else {
+			ctx.JSON(500, utils.ErrorJson(err))
+		}
+
+		return
+	}
+
+	if body.IncludeOpen {
+		opts.Open = nil
+	}
+
+	filename := fmt.Sprintf("tickets-%d-%s.%s", guildId, time.Now().UTC().Format("2006-01-02"), body.Format)
+	ctx.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, filename))
+
+	var writeBatch func([]exportRow) error
+	if body.Format == exportFormatCsv {
+		ctx.Header("Content-Type", "text/csv; charset=utf-8")
+
+		writer := csv.NewWriter(ctx.Writer)
+		if err := writer.Write(exportCsvHeader); err != nil {
+			ctx.JSON(500, utils.ErrorJson(err))
+			return
+		}
+
+		writeBatch = func(rows []exportRow) error {
+			for _, row := range rows {
+				if err := writer.Write(row.toCsv()); err != nil {
+					return err
+				}
+			}
+
+			writer.Flush()
+			return writer.Error()
+		}
+	} else {
+		ctx.Header("Content-Type", "application/x-ndjson")
+
+		encoder := json.NewEncoder(ctx.Writer)
+		writeBatch = func(rows []exportRow) error {
+			for _, row := range rows {
+				if err := encoder.Encode(row); err != nil {
+					return err
+				}
+			}
+
+			return nil
+		}
+	}
+
+	ctx.Status(200)
+
+	// The status has already been sent, so errors can only be logged
+	err = dbclient.Dashboard.TicketQuery.Export(ctx, opts, exportBatchSize, func(batch []dbclient.TicketExportRow) error {
+		rows, err := resolveExportUsernames(ctx, batch)
+		if err != nil {
+			return err
+		}
+
+		if err := writeBatch(rows); err != nil {
+			return err
+		}
+
+		ctx.Writer.Flush()
+		return nil
+	})
+
+	if err != nil {
+		log.Logger.Error("Failed to export tickets", zap.Uint64("guild_id", guildId), zap.Error(err))
+	}
+}
+
+func resolveExportUsernames(ctx *gin.Context, batch []dbclient.TicketExportRow) ([]exportRow, error) {
+	userIds := make([]uint64, 0, len(batch))
+	for _, row := range batch {
+		userIds = append(userIds, row.UserId)
+
+		if row.ClaimedBy != nil {
+			userIds = append(userIds, *row.ClaimedBy)
+		}
+
+		if row.ClosedBy != nil {
+			userIds = append(userIds, *row.ClosedBy)
+		}
+	}
+
+	users, err := cache.Instance.GetUsers(ctx, userIds)
+	if err != nil {
+		return nil, err
+	}
+
+	rows := make([]exportRow, len(batch))
+	for i, row := range batch {
+		rows[i] = exportRow{
+			TicketExportRow:   row,
+			Username:          usernameOf(users, &row.UserId),
+			ClaimedByUsername: usernameOf(users, row.ClaimedBy),
+			ClosedByUsername:  usernameOf(users, row.ClosedBy),
+		}
+	}
+
+	return rows, nil
+}
+
+func usernameOf(users map[uint64]user.User, userId *uint64) *string {
+	if userId == nil {
+		return nil
+	}
+
+	if u, ok := users[*userId]; ok {
+		return &u.Username
+	}
+
+	return nil
+}
+
+func (r exportRow) toCsv() []string {
+	return []string{
+		strconv.Itoa(r.TicketId),
+		strconv.FormatUint(r.UserId, 10),
+		utils.CsvSafe(utils.ValueOrZero(r.Username)),
+		formatOptionalId(r.ClaimedBy),
+		utils.CsvSafe(utils.ValueOrZero(r.ClaimedByUsername)),
+		formatOptionalInt(r.PanelId),
+		utils.CsvSafe(utils.ValueOrZero(r.PanelTitle)),
+		strconv.FormatBool(r.Open),
+		r.OpenTime.UTC().Format(time.RFC3339),
+		formatOptionalTime(r.CloseTime),
+		utils.CsvSafe(utils.ValueOrZero(r.CloseReason)),
+		formatOptionalId(r.ClosedBy),
+		utils.CsvSafe(utils.ValueOrZero(r.ClosedByUsername)),
+		formatOptionalInt(r.Rating),
+	}
+}
+
+func formatOptionalId(id *uint64) string {
+	if id == nil {
+		return ""
+	}
+
+	return strconv.FormatUint(*id, 10)
+}
+
+func formatOptionalInt[T ~int | ~uint8](value *T) string {
+	if value == nil {
+		return ""
+	}
+
+	return strconv.Itoa(int(*value))
+}
+
+func formatOptionalTime(t *time.Time) string {
+	if t == nil {
+		return ""
+	}
+
+	return t.UTC().Format(time.RFC3339)
+}
@@ -1,20 +1,30 @@
 package api
 
 import (
+	"archive/zip"
 	"context"
 	"errors"
+	"fmt"
 	"strconv"
 
 	"github.com/TicketsBot-cloud/archiverclient"
+	"github.com/TicketsBot-cloud/dashboard/chatreplica"
 	dbclient "github.com/TicketsBot-cloud/dashboard/database"
+	"github.com/TicketsBot-cloud/dashboard/log"
 	"github.com/TicketsBot-cloud/dashboard/utils"
 	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
 )
 
 func GetTranscriptHandler(ctx *gin.Context) {
 	guildId := ctx.Keys["guildid"].(uint64)
 	userId := ctx.Keys["userid"].(uint64)
 
+	if format := chatreplica.Format(ctx.Query("format")); format != "" && !format.IsValid() {
+		ctx.JSON(400, utils.ErrorStr("Invalid format"))
+		return
+	}
+
 	// format ticket ID
 	ticketId, err := strconv.Atoi(ctx.Param("ticketId"))
 	if err != nil {
@@ -54,7 +64,7 @@ func GetTranscriptHandler(ctx *gin.Context) {
 	}
 
 	// retrieve ticket messages from bucket
-	messages, err := utils.ArchiverClient.Get(ctx, guildId, ticketId)
+	messages, err := utils.GetTranscript(ctx, guildId, ticketId)
 	if err != nil {
 		if errors.Is(err, archiverclient.ErrNotFound) {
 			ctx.JSON(404, utils.ErrorStr("Transcript not found"))
@@ -65,5 +75,48 @@ func GetTranscriptHandler(ctx *gin.Context) {
 		return
 	}
 
-	ctx.JSON(200, messages)
+	// No format returns the raw archiver data, which is used by the frontend
+	format := chatreplica.Format(ctx.Query("format"))
+	if format == "" {
+		ctx.JSON(200, messages)
+		return
+	}
+
+	mirrors, err := dbclient.Dashboard.AttachmentMirrors.GetByTicket(ctx, guildId, ticketId)
+	if err != nil {
+		ctx.JSON(500, utils.ErrorJson(err))
+		return
+	}
+
+	name := fmt.Sprintf("transcript-%d-%d.%s", guildId, ticketId, format)
+
+	// Links to mirrored attachments expire, so transcripts with mirrored attachments are downloaded as a ZIP archive
+	// containing the attachments, which the transcript links to instead
+	if len(mirrors) > 0 {
+		ctx.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="transcript-%d-%d.zip"`, guildId, ticketId))
+		ctx.Header("Content-Type", "application/zip")
+		ctx.Status(200)
+
+		archive := zip.NewWriter(ctx.Writer)
+		if err := utils.WriteTranscriptBundle(ctx, archive, name, messages, mirrors, guildId, ticketId, format); err != nil {
+			// The response has already been started, so the download is left incomplete
+			log.Logger.Error("Failed to write transcript download", zap.Uint64("guild_id", guildId), zap.Int("ticket_id", ticketId), zap.Error(err))
+			return
+		}
+
+		if err := archive.Close(); err != nil {
+			log.Logger.Error("Failed to write transcript download", zap.Uint64("guild_id", guildId), zap.Int("ticket_id", ticketId), zap.Error(err))
+		}
+
+		return
+	}
+
+	exported, err := chatreplica.Export(utils.TranscriptPayload(ctx, messages, guildId, ticketId), format)
+	if err != nil {
+		ctx.JSON(500, utils.ErrorJson(err))
+		return
+	}
+
+	ctx.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, name))
+	ctx.Data(200, format.ContentType(), exported)
 }
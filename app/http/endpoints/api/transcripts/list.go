@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 
+	"github.com/TicketsBot-cloud/dashboard/app/http/validation"
 	"github.com/TicketsBot-cloud/dashboard/botcontext"
 	dbclient "github.com/TicketsBot-cloud/dashboard/database"
 	"github.com/TicketsBot-cloud/dashboard/rpc/cache"
@@ -14,13 +15,22 @@ import (
 
 const pageLimit = 15
 
+type listTranscriptsResponse struct {
+	Transcripts []transcriptMetadata `json:"transcripts"`
+	Total       int                  `json:"total"`
+	Page        int                  `json:"page"`
+	PageSize    int                  `json:"page_size"`
+}
+
 type transcriptMetadata struct {
-	TicketId      int     `json:"ticket_id"`
-	Username      string  `json:"username"`
-	CloseReason   *string `json:"close_reason"`
-	ClosedBy      *uint64 `json:"closed_by"`
-	Rating        *uint8  `json:"rating"`
-	HasTranscript bool    `json:"has_transcript"`
+	TicketId      int                      `json:"ticket_id"`
+	Username      string                   `json:"username"`
+	CloseReason   *string                  `json:"close_reason"`
+	ClosedBy      *uint64                  `json:"closed_by"`
+	Rating        *uint8                   `json:"rating"`
+	HasTranscript bool                     `json:"has_transcript"`
+	Labels        []int                    `json:"labels"`
+	Priority      *dbclient.TicketPriority `json:"priority"`
 }
 
 func ListTranscripts(ctx *gin.Context) {
@@ -33,12 +43,24 @@ func ListTranscripts(ctx *gin.Context) {
 	}
 
 	opts, err := queryOptions.toQueryOptions(guildId)
+	if err != nil {
+		var validationError *validation.InvalidInputError
+		if errors.As(err, &validationError) {
+			ctx.JSON(400, utils.ErrorJson(err))
+		} else {
+			ctx.JSON(500, utils.ErrorJson(err))
+		}
+
+		return
+	}
+
+	tickets, err := dbclient.Dashboard.TicketQuery.GetByOptions(ctx, opts)
 	if err != nil {
 		ctx.JSON(500, utils.ErrorJson(err))
 		return
 	}
 
-	tickets, err := dbclient.Client.Tickets.GetByOptions(ctx, opts)
+	total, err := dbclient.Dashboard.TicketQuery.Count(ctx, opts)
 	if err != nil {
 		ctx.JSON(500, utils.ErrorJson(err))
 		return
@@ -96,12 +118,33 @@ func ListTranscripts(ctx *gin.Context) {
 		return
 	}
 
+	labels, err := dbclient.Dashboard.TicketLabelAssignments.GetMulti(ctx, guildId, ticketIds)
+	if err != nil {
+		ctx.JSON(500, utils.ErrorJson(err))
+		return
+	}
+
+	priorities, err := dbclient.Dashboard.TicketPriority.GetMulti(ctx, guildId, ticketIds)
+	if err != nil {
+		ctx.JSON(500, utils.ErrorJson(err))
+		return
+	}
+
 	transcripts := make([]transcriptMetadata, len(tickets))
 	for i, ticket := range tickets {
 		transcript := transcriptMetadata{
 			TicketId:      ticket.Id,
 			Username:      usernames[ticket.UserId],
 			HasTranscript: ticket.HasTranscript,
+			Labels:        labels[ticket.Id],
+		}
+
+		if transcript.Labels == nil {
+			transcript.Labels = make([]int, 0)
+		}
+
+		if v, ok := priorities[ticket.Id]; ok {
+			transcript.Priority = &v
 		}
 
 		if v, ok := ratings[ticket.Id]; ok {
@@ -116,5 +159,15 @@ func ListTranscripts(ctx *gin.Context) {
 		transcripts[i] = transcript
 	}
 
-	ctx.JSON(200, transcripts)
+	page := queryOptions.Page
+	if page < 1 {
+		page = 1
+	}
+
+	ctx.JSON(200, listTranscriptsResponse{
+		Transcripts: transcripts,
+		Total:       total,
+		Page:        page,
+		PageSize:    queryOptions.pageSize(),
+	})
 }
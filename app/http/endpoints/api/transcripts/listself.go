@@ -1,87 +1,142 @@
 package api
 
 import (
-	"context"
-	"errors"
+	"fmt"
+	"net/http"
 	"strconv"
+	"time"
 
+	"github.com/TicketsBot-cloud/dashboard/app"
 	dbclient "github.com/TicketsBot-cloud/dashboard/database"
 	"github.com/TicketsBot-cloud/dashboard/rpc/cache"
 	"github.com/TicketsBot-cloud/dashboard/utils"
-	"github.com/TicketsBot-cloud/database"
 	"github.com/gin-gonic/gin"
-	cache2 "github.com/rxdn/gdl/cache"
-	gdlutils "github.com/rxdn/gdl/utils"
-	"golang.org/x/sync/errgroup"
 )
 
-// ListSelfTranscripts TODO: Give user option to rate ticket
+type (
+	selfTicket struct {
+		TicketId       int        `json:"ticket_id"`
+		GuildId        uint64     `json:"guild_id,string"`
+		GuildName      string     `json:"guild_name"`
+		Open           bool       `json:"open"`
+		OpenTime       time.Time  `json:"open_time"`
+		CloseTime      *time.Time `json:"close_time"`
+		HasTranscript  bool       `json:"has_transcript"`
+		Rating         *uint8     `json:"rating"`
+		CanRate        bool       `json:"can_rate"`
+		TranscriptPath *string    `json:"transcript_path"`
+		RatingPath     *string    `json:"rating_path"`
+	}
+
+	selfTicketsResponse struct {
+		Tickets []selfTicket `json:"tickets"`
+		Page    int          `json:"page"`
+		HasMore bool         `json:"has_more"`
+	}
+)
+
+// ListSelfTranscripts lists the tickets that the user has opened, across all servers. Closed tickets link to their
+// transcript, and to the rating endpoint if the server accepts feedback and the ticket hasn't been rated yet.
 func ListSelfTranscripts(ctx *gin.Context) {
 	userId := ctx.Keys["userid"].(uint64)
 
-	page, err := strconv.Atoi(ctx.Query("page"))
-	if err != nil {
-		ctx.JSON(400, utils.ErrorJson(err))
-		return
+	page := 1
+	if raw := ctx.Query("page"); raw != "" {
+		var err error
+		page, err = strconv.Atoi(raw)
+		if err != nil || page < 1 {
+			ctx.JSON(400, utils.ErrorStr("Invalid page"))
+			return
+		}
 	}
 
-	var offset int
-	if page > 1 {
-		offset = pageLimit * (page - 1)
+	opts := dbclient.UserTicketQueryOptions{
+		UserId: userId,
+		Limit:  pageLimit + 1, // Fetch an extra ticket to determine whether there is another page
+		Offset: pageLimit * (page - 1),
 	}
 
-	opts := database.TicketQueryOptions{
-		UserIds: []uint64{userId},
-		Open:    gdlutils.BoolPtr(false),
-		Order:   database.OrderTypeDescending,
-		Limit:   pageLimit,
-		Offset:  offset,
+	switch ctx.Query("status") {
+	case "", "all":
+	case "open":
+		opts.Open = utils.Ptr(true)
+	case "closed":
+		opts.Open = utils.Ptr(false)
+	default:
+		ctx.JSON(400, utils.ErrorStr("Invalid status"))
+		return
+	}
+
+	if raw := ctx.Query("guild_id"); raw != "" {
+		guildId, err := strconv.ParseUint(raw, 10, 64)
+		if err != nil {
+			ctx.JSON(400, utils.ErrorStr("Invalid guild ID"))
+			return
+		}
+
+		opts.GuildId = guildId
 	}
 
-	tickets, err := dbclient.Client.Tickets.GetByOptions(ctx, opts)
+	tickets, err := dbclient.Dashboard.UserTickets.GetByUser(ctx, opts)
 	if err != nil {
-		ctx.JSON(500, utils.ErrorJson(err))
+		_ = ctx.AbortWithError(http.StatusInternalServerError, app.NewServerError(err))
 		return
 	}
 
-	type TranscriptData struct {
-		TicketId  int    `json:"ticket_id"`
-		GuildId   uint64 `json:"guild_id"`
-		GuildName string `json:"guild_name"`
+	hasMore := len(tickets) > pageLimit
+	if hasMore {
+		tickets = tickets[:pageLimit]
 	}
 
-	// TODO: Not O(n)
-	data := make([]TranscriptData, len(tickets))
-
-	group, _ := errgroup.WithContext(context.Background())
-	for i, ticket := range tickets {
-		group.Go(func() error {
-			var guildName string
-			{
-				guild, err := cache.Instance.GetGuild(context.Background(), ticket.GuildId)
-				if err == nil {
-					guildName = guild.Name
-				} else if errors.Is(err, cache2.ErrNotFound) {
-					guildName = "Unknown server"
-				} else {
-					return err
-				}
-			}
-
-			data[i] = TranscriptData{
-				TicketId:  ticket.Id,
-				GuildId:   ticket.GuildId,
-				GuildName: guildName,
-			}
-
-			return nil
-		})
+	// Resolve the names of all guilds on the page at once
+	var guildIds []uint64
+	for _, ticket := range tickets {
+		if !utils.Contains(guildIds, ticket.GuildId) {
+			guildIds = append(guildIds, ticket.GuildId)
+		}
 	}
 
-	if err := group.Wait(); err != nil {
-		ctx.JSON(500, utils.ErrorJson(err))
+	guildNames, err := cache.Instance.GetGuildNames(ctx, guildIds)
+	if err != nil {
+		_ = ctx.AbortWithError(http.StatusInternalServerError, app.NewServerError(err))
 		return
 	}
 
-	ctx.JSON(200, data)
+	data := make([]selfTicket, len(tickets))
+	for i, ticket := range tickets {
+		guildName, ok := guildNames[ticket.GuildId]
+		if !ok {
+			guildName = "Unknown server"
+		}
+
+		data[i] = selfTicket{
+			TicketId:      ticket.TicketId,
+			GuildId:       ticket.GuildId,
+			GuildName:     guildName,
+			Open:          ticket.Open,
+			OpenTime:      ticket.OpenTime,
+			CloseTime:     ticket.CloseTime,
+			HasTranscript: ticket.HasTranscript,
+			Rating:        ticket.Rating,
+			CanRate:       canRateTicket(ticket),
+		}
+
+		if !ticket.Open && ticket.HasTranscript {
+			data[i].TranscriptPath = utils.Ptr(fmt.Sprintf("/api/%d/transcripts/%d/render", ticket.GuildId, ticket.TicketId))
+		}
+
+		if data[i].CanRate {
+			data[i].RatingPath = utils.Ptr(fmt.Sprintf("/user/transcripts/%d/%d/rating", ticket.GuildId, ticket.TicketId))
+		}
+	}
+
+	ctx.JSON(200, selfTicketsResponse{
+		Tickets: data,
+		Page:    page,
+		HasMore: hasMore,
+	})
+}
+
+func canRateTicket(ticket dbclient.UserTicket) bool {
+	return !ticket.Open && ticket.FeedbackEnabled && ticket.Rating == nil
 }
@@ -2,36 +2,64 @@ package api
 
 import (
 	"context"
-	"errors"
+	"strings"
+	"time"
 
+	"github.com/TicketsBot-cloud/dashboard/app/http/validation"
 	"github.com/TicketsBot-cloud/dashboard/botcontext"
+	dbclient "github.com/TicketsBot-cloud/dashboard/database"
 	"github.com/TicketsBot-cloud/database"
 	"github.com/rxdn/gdl/utils"
 )
 
 type wrappedQueryOptions struct {
-	Id          int    `json:"id,string"`
-	Username    string `json:"username"`
-	UserId      uint64 `json:"user_id,string"`
-	PanelId     int    `json:"panel_id"`
-	Page        int    `json:"page"`
-	Rating      int    `json:"rating,string"`
-	ClosedById  uint64 `json:"closed_by_id,string"`
-	ClaimedById uint64 `json:"claimed_by_id,string"`
+	Id           int                       `json:"id,string"`
+	Username     string                    `json:"username"`
+	UserId       uint64                    `json:"user_id,string"`
+	PanelId      int                       `json:"panel_id"`
+	Page         int                       `json:"page"`
+	PageSize     int                       `json:"page_size"`
+	Rating       int                       `json:"rating,string"`
+	ClosedById   uint64                    `json:"closed_by_id,string"`
+	ClaimedById  uint64                    `json:"claimed_by_id,string"`
+	LabelIds     []int                     `json:"label_ids"`
+	Priority     *dbclient.TicketPriority  `json:"priority"`
+	OpenedAfter  *time.Time                `json:"opened_after"`
+	OpenedBefore *time.Time                `json:"opened_before"`
+	ClosedAfter  *time.Time                `json:"closed_after"`
+	ClosedBefore *time.Time                `json:"closed_before"`
+	CloseReason  string                    `json:"close_reason"`
+	SortBy       dbclient.TicketSortColumn `json:"sort_by"`
+	SortOrder    database.OrderType        `json:"sort_order"`
 }
 
-func (o *wrappedQueryOptions) toQueryOptions(guildId uint64) (database.TicketQueryOptions, error) {
+const (
+	maxPageSize          = 100
+	maxCloseReasonSearch = 256
+)
+
+// pageSize returns the requested page size, or the default if it is missing or out of range
+func (o *wrappedQueryOptions) pageSize() int {
+	if o.PageSize < 1 || o.PageSize > maxPageSize {
+		return pageLimit
+	}
+
+	return o.PageSize
+}
+
+// toQueryOptions returns a *validation.InvalidInputError if the filter is invalid
+func (o *wrappedQueryOptions) toQueryOptions(guildId uint64) (dbclient.TicketQueryOptions, error) {
 	var userIds []uint64
 	if len(o.Username) > 0 {
 		var err error
 		userIds, err = usernameToIds(guildId, o.Username)
 		if err != nil {
-			return database.TicketQueryOptions{}, err
+			return dbclient.TicketQueryOptions{}, err
 		}
 
 		// TODO: Do this better
 		if len(userIds) == 0 {
-			return database.TicketQueryOptions{}, errors.New("user not found")
+			return dbclient.TicketQueryOptions{}, validation.NewInvalidInputError("User not found")
 		}
 	}
 
@@ -41,32 +69,60 @@ func (o *wrappedQueryOptions) toQueryOptions(guildId uint64) (database.TicketQue
 
 	var offset int
 	if o.Page > 1 {
-		offset = pageLimit * (o.Page - 1)
+		offset = o.pageSize() * (o.Page - 1)
+	}
+
+	if len(o.CloseReason) > maxCloseReasonSearch {
+		return dbclient.TicketQueryOptions{}, validation.NewInvalidInputError("Close reason search too long")
+	}
+
+	if o.SortBy != "" && !o.SortBy.IsValid() {
+		o.SortBy = dbclient.TicketSortColumnId
+	}
+
+	if strings.EqualFold(string(o.SortOrder), string(database.OrderTypeAscending)) {
+		o.SortOrder = database.OrderTypeAscending
+	} else {
+		o.SortOrder = database.OrderTypeDescending
 	}
 
 	if o.Rating < 0 || o.Rating > 5 {
 		o.Rating = 0
 	}
 
-	opts := database.TicketQueryOptions{
-		Id:          o.Id,
-		GuildId:     guildId,
-		UserIds:     userIds,
-		Open:        utils.BoolPtr(false),
-		PanelId:     o.PanelId,
-		Rating:      o.Rating,
-		ClosedById:  o.ClosedById,
-		ClaimedById: o.ClaimedById,
-		Order:       database.OrderTypeDescending,
-		Limit:       pageLimit,
-		Offset:      offset,
+	if o.Priority != nil && !o.Priority.IsValid() {
+		return dbclient.TicketQueryOptions{}, validation.NewInvalidInputError("Invalid priority")
+	}
+
+	opts := dbclient.TicketQueryOptions{
+		TicketQueryOptions: database.TicketQueryOptions{
+			Id:          o.Id,
+			GuildId:     guildId,
+			UserIds:     userIds,
+			Open:        utils.BoolPtr(false),
+			PanelId:     o.PanelId,
+			Rating:      o.Rating,
+			ClosedById:  o.ClosedById,
+			ClaimedById: o.ClaimedById,
+			Order:       o.SortOrder,
+			Limit:       o.pageSize(),
+			Offset:      offset,
+		},
+		LabelIds:     o.LabelIds,
+		Priority:     o.Priority,
+		OpenedAfter:  o.OpenedAfter,
+		OpenedBefore: o.OpenedBefore,
+		ClosedAfter:  o.ClosedAfter,
+		ClosedBefore: o.ClosedBefore,
+		CloseReason:  strings.TrimSpace(o.CloseReason),
+		SortBy:       o.SortBy,
 	}
 	return opts, nil
 }
 
 func usernameToIds(guildId uint64, username string) ([]uint64, error) {
 	if len(username) > 32 {
-		return nil, errors.New("username too long")
+		return nil, validation.NewInvalidInputError("Username too long")
 	}
 
 	botContext, err := botcontext.ContextForGuild(guildId)
@@ -0,0 +1,90 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/TicketsBot-cloud/dashboard/app"
+	dbclient "github.com/TicketsBot-cloud/dashboard/database"
+	"github.com/TicketsBot-cloud/dashboard/utils"
+	"github.com/gin-gonic/gin"
+)
+
+type rateTicketBody struct {
+	Rating uint8 `json:"rating"`
+}
+
+// RateSelfTicket allows the user to rate a ticket that they opened, once it has been closed, if they did not rate it
+// when it was closed
+func RateSelfTicket(ctx *gin.Context) {
+	userId := ctx.Keys["userid"].(uint64)
+
+	guildId, err := strconv.ParseUint(ctx.Param("guildId"), 10, 64)
+	if err != nil {
+		ctx.JSON(400, utils.ErrorStr("Invalid guild ID"))
+		return
+	}
+
+	ticketId, err := strconv.Atoi(ctx.Param("ticketId"))
+	if err != nil {
+		ctx.JSON(400, utils.ErrorStr("Invalid ticket ID"))
+		return
+	}
+
+	var body rateTicketBody
+	if err := ctx.BindJSON(&body); err != nil {
+		ctx.JSON(400, utils.ErrorJson(err))
+		return
+	}
+
+	if body.Rating < 1 || body.Rating > 5 {
+		ctx.JSON(400, utils.ErrorStr("Rating must be between 1 and 5"))
+		return
+	}
+
+	ticket, err := dbclient.Client.Tickets.Get(ctx, ticketId, guildId)
+	if err != nil {
+		_ = ctx.AbortWithError(http.StatusInternalServerError, app.NewServerError(err))
+		return
+	}
+
+	// Don't reveal whether other users' tickets exist
+	if ticket.UserId == 0 || ticket.UserId != userId {
+		ctx.JSON(404, utils.ErrorStr("Ticket not found"))
+		return
+	}
+
+	if ticket.Open {
+		ctx.JSON(400, utils.ErrorStr("Tickets can only be rated once they have been closed"))
+		return
+	}
+
+	feedbackEnabled, err := dbclient.Client.FeedbackEnabled.Get(ctx, guildId)
+	if err != nil {
+		_ = ctx.AbortWithError(http.StatusInternalServerError, app.NewServerError(err))
+		return
+	}
+
+	if !feedbackEnabled {
+		ctx.JSON(403, utils.ErrorStr("This server does not accept feedback"))
+		return
+	}
+
+	_, alreadyRated, err := dbclient.Client.ServiceRatings.Get(ctx, guildId, ticketId)
+	if err != nil {
+		_ = ctx.AbortWithError(http.StatusInternalServerError, app.NewServerError(err))
+		return
+	}
+
+	if alreadyRated {
+		ctx.JSON(409, utils.ErrorStr("You have already rated this ticket"))
+		return
+	}
+
+	if err := dbclient.Client.ServiceRatings.Set(ctx, guildId, ticketId, body.Rating); err != nil {
+		_ = ctx.AbortWithError(http.StatusInternalServerError, app.NewServerError(err))
+		return
+	}
+
+	ctx.Status(204)
+}
@@ -0,0 +1,358 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"regexp"
+	"strconv"
+	"time"
+
+	"github.com/TicketsBot-cloud/archiverclient"
+	"github.com/TicketsBot-cloud/dashboard/app"
+	"github.com/TicketsBot-cloud/dashboard/app/background"
+	"github.com/TicketsBot-cloud/dashboard/chatreplica"
+	dbclient "github.com/TicketsBot-cloud/dashboard/database"
+	"github.com/TicketsBot-cloud/dashboard/redis"
+	"github.com/TicketsBot-cloud/dashboard/utils"
+	"github.com/TicketsBot-cloud/dashboard/utils/types"
+	"github.com/TicketsBot/logarchiver/pkg/model"
+	"github.com/gin-gonic/gin"
+	"github.com/rxdn/gdl/objects/channel"
+)
+
+type (
+	redactBody struct {
+		MessageIds    types.UInt64StringSlice `json:"message_ids"`
+		AttachmentIds types.UInt64StringSlice `json:"attachment_ids"`
+		Patterns      []string                `json:"patterns"`
+		Reason        *string                 `json:"reason"`
+	}
+
+	redactionResponse struct {
+		dbclient.TranscriptRedaction
+		MessageIds    types.UInt64StringSlice `json:"message_ids"`
+		AttachmentIds types.UInt64StringSlice `json:"attachment_ids"`
+	}
+
+	redactionRules struct {
+		MessageIds    []uint64
+		AttachmentIds []uint64
+		Patterns      []*regexp.Regexp
+	}
+
+	redactionResult struct {
+		MessagesAffected   int
+		AttachmentsRemoved int
+	}
+)
+
+const (
+	redactedPlaceholder    = "[redacted]"
+	maxRedactionIds        = 100
+	maxRedactionPatterns   = 10
+	maxRedactionPatternLen = 256
+	maxRedactionReasonLen  = 255
+
+	// redactionLockLease is the longest that a redaction may take, after which the transcript may be redacted again
+	redactionLockLease = time.Minute * 5
+)
+
+var errNothingToRedact = errors.New("nothing to redact")
+
+// RedactTranscript permanently removes messages, attachments, or text matching regular expressions from an archived
+// transcript. The original is overwritten in the archive, and cached copies are removed.
+func RedactTranscript(ctx *gin.Context) {
+	guildId, userId := ctx.Keys["guildid"].(uint64), ctx.Keys["userid"].(uint64)
+
+	ticketId, err := strconv.Atoi(ctx.Param("ticketId"))
+	if err != nil {
+		ctx.JSON(400, utils.ErrorStr("Invalid ticket ID"))
+		return
+	}
+
+	var body redactBody
+	if err := ctx.BindJSON(&body); err != nil {
+		ctx.JSON(400, utils.ErrorJson(err))
+		return
+	}
+
+	rules, err := body.toRules()
+	if err != nil {
+		ctx.JSON(400, utils.ErrorJson(err))
+		return
+	}
+
+	ticket, err := dbclient.Client.Tickets.Get(ctx, ticketId, guildId)
+	if err != nil {
+		_ = ctx.AbortWithError(http.StatusInternalServerError, app.NewServerError(err))
+		return
+	}
+
+	if ticket.UserId == 0 || ticket.Open {
+		ctx.JSON(404, utils.ErrorStr("Transcript not found"))
+		return
+	}
+
+	// A lease is held rather than a transaction, as fetching and importing the transcript can be slow
+	locked, err := dbclient.Dashboard.TranscriptRedactions.Lock(ctx, guildId, ticketId, redactionLockLease)
+	if err != nil {
+		_ = ctx.AbortWithError(http.StatusInternalServerError, app.NewServerError(err))
+		return
+	}
+
+	if !locked {
+		ctx.JSON(409, utils.ErrorStr("This transcript is already being redacted, please try again shortly"))
+		return
+	}
+
+	defer func() {
+		_ = dbclient.Dashboard.TranscriptRedactions.Unlock(context.Background(), guildId, ticketId)
+	}()
+
+	redaction := dbclient.TranscriptRedaction{
+		GuildId:       guildId,
+		TicketId:      ticketId,
+		UserId:        userId,
+		MessageIds:    rules.MessageIds,
+		AttachmentIds: rules.AttachmentIds,
+		PatternCount:  len(rules.Patterns),
+		Reason:        body.Reason,
+	}
+
+	// Stop before the lease expires, so that another redaction can't start while this one is still running
+	redactCtx, cancel := context.WithTimeout(ctx, redactionLockLease)
+	defer cancel()
+
+	err = applyRedaction(redactCtx, &redaction, rules)
+	if err != nil {
+		if errors.Is(err, archiverclient.ErrNotFound) {
+			ctx.JSON(404, utils.ErrorStr("Transcript not found"))
+		} else if errors.Is(err, errNothingToRedact) {
+			ctx.JSON(400, utils.ErrorStr("No messages or attachments matched"))
+		} else {
+			_ = ctx.AbortWithError(http.StatusInternalServerError, app.NewServerError(err))
+		}
+
+		return
+	}
+
+	ctx.JSON(200, newRedactionResponse(redaction))
+}
+
+// applyRedaction redacts the archived transcript, and records the redaction once the redacted transcript has been
+// imported. The caller must hold the ticket's redaction lock.
+func applyRedaction(ctx context.Context, redaction *dbclient.TranscriptRedaction, rules redactionRules) error {
+	guildId, ticketId := redaction.GuildId, redaction.TicketId
+
+	transcript, err := utils.GetTranscript(ctx, guildId, ticketId)
+	if err != nil {
+		return err
+	}
+
+	result := redactTranscript(&transcript, rules)
+	if result.MessagesAffected == 0 && result.AttachmentsRemoved == 0 {
+		return errNothingToRedact
+	}
+
+	redaction.MessagesAffected = result.MessagesAffected
+	redaction.AttachmentsRemoved = result.AttachmentsRemoved
+
+	// Transcripts in the legacy format are converted on read, so are always written back in the current format
+	transcript.Version = model.V2
+
+	data, err := json.Marshal(transcript)
+	if err != nil {
+		return err
+	}
+
+	if err := utils.ArchiverClient.ImportTranscript(ctx, guildId, ticketId, data); err != nil {
+		return err
+	}
+
+	// Attachment mirroring started before this point will see the redaction when it completes, and discard its copies
+	redaction.Id, err = dbclient.Dashboard.TranscriptRedactions.Create(ctx, *redaction)
+	if err != nil {
+		return err
+	}
+
+	// Mirrored copies of removed attachments must also be deleted
+	if err := background.DeleteMirroredAttachments(ctx, guildId, ticketId, attachmentIds(transcript)); err != nil {
+		return err
+	}
+
+	return invalidateTranscriptCopies(ctx, guildId, ticketId)
+}
+
+// GetRedactions returns the log of redactions made to the transcript
+func GetRedactions(ctx *gin.Context) {
+	guildId := ctx.Keys["guildid"].(uint64)
+
+	ticketId, err := strconv.Atoi(ctx.Param("ticketId"))
+	if err != nil {
+		ctx.JSON(400, utils.ErrorStr("Invalid ticket ID"))
+		return
+	}
+
+	redactions, err := dbclient.Dashboard.TranscriptRedactions.GetByTicket(ctx, guildId, ticketId)
+	if err != nil {
+		_ = ctx.AbortWithError(http.StatusInternalServerError, app.NewServerError(err))
+		return
+	}
+
+	res := make([]redactionResponse, len(redactions))
+	for i, redaction := range redactions {
+		res[i] = newRedactionResponse(redaction)
+	}
+
+	ctx.JSON(200, res)
+}
+
+func newRedactionResponse(redaction dbclient.TranscriptRedaction) redactionResponse {
+	return redactionResponse{
+		TranscriptRedaction: redaction,
+		MessageIds:          types.UInt64StringSlice(redaction.MessageIds),
+		AttachmentIds:       types.UInt64StringSlice(redaction.AttachmentIds),
+	}
+}
+
+// invalidateTranscriptCopies removes copies of the transcript that the dashboard keeps outside the archive, so that
+// the previous content is no longer served
+func invalidateTranscriptCopies(ctx context.Context, guildId uint64, ticketId int) error {
+	if err := redis.Client.InvalidateRenderedTranscript(ctx, guildId, ticketId); err != nil {
+		return err
+	}
+
+	if err := background.DeleteTranscriptExports(ctx, guildId, []int{ticketId}, "Transcripts have been redacted since this export was created"); err != nil {
+		return err
+	}
+
+	// The transcript is indexed again from the archive, if the guild has search enabled
+	return dbclient.Dashboard.TranscriptSearch.Invalidate(ctx, guildId, ticketId)
+}
+
+// attachmentIds returns the IDs of every attachment in the transcript
+func attachmentIds(transcript chatreplica.Transcript) []uint64 {
+	ids := make([]uint64, 0)
+	for _, msg := range transcript.Messages {
+		for _, attachment := range msg.Attachments {
+			ids = append(ids, attachment.Id)
+		}
+	}
+
+	return ids
+}
+
+func (b redactBody) toRules() (redactionRules, error) {
+	if len(b.MessageIds) == 0 && len(b.AttachmentIds) == 0 && len(b.Patterns) == 0 {
+		return redactionRules{}, errors.New("no messages, attachments or patterns to redact were provided")
+	}
+
+	if len(b.MessageIds) > maxRedactionIds || len(b.AttachmentIds) > maxRedactionIds {
+		return redactionRules{}, errors.New("too many messages or attachments to redact at once")
+	}
+
+	if len(b.Patterns) > maxRedactionPatterns {
+		return redactionRules{}, errors.New("too many patterns to redact at once")
+	}
+
+	if b.Reason != nil && len(*b.Reason) > maxRedactionReasonLen {
+		return redactionRules{}, errors.New("reason is too long")
+	}
+
+	rules := redactionRules{
+		MessageIds:    b.MessageIds,
+		AttachmentIds: b.AttachmentIds,
+	}
+
+	for _, pattern := range b.Patterns {
+		if len(pattern) == 0 || len(pattern) > maxRedactionPatternLen {
+			return redactionRules{}, errors.New("patterns must be between 1 and 256 characters")
+		}
+
+		// Go regular expressions run in linear time, so user supplied patterns can't cause catastrophic backtracking
+		compiled, err := regexp.Compile(pattern)
+		if err != nil {
+			return redactionRules{}, err
+		}
+
+		rules.Patterns = append(rules.Patterns, compiled)
+	}
+
+	return rules, nil
+}
+
+// redactTranscript redacts the transcript in place. Messages listed by ID have their content, embeds, attachments and
+// stickers removed entirely, listed attachments are removed, and text matching a pattern is replaced in message content and
+// embeds.
+func redactTranscript(transcript *chatreplica.Transcript, rules redactionRules) redactionResult {
+	var result redactionResult
+
+	for i := range transcript.Messages {
+		msg := &transcript.Messages[i]
+
+		if utils.Contains(rules.MessageIds, msg.Id) {
+			result.MessagesAffected++
+			result.AttachmentsRemoved += len(msg.Attachments)
+
+			msg.Content = redactedPlaceholder
+			msg.Embeds = nil
+			msg.Attachments = nil
+			msg.StickerItems = nil
+			continue
+		}
+
+		changed := false
+
+		attachments := make([]channel.Attachment, 0, len(msg.Attachments))
+		for _, attachment := range msg.Attachments {
+			if utils.Contains(rules.AttachmentIds, attachment.Id) {
+				result.AttachmentsRemoved++
+				changed = true
+			} else {
+				attachments = append(attachments, attachment)
+			}
+		}
+
+		msg.Attachments = attachments
+
+		redactText := func(s *string) {
+			for _, pattern := range rules.Patterns {
+				if pattern.MatchString(*s) {
+					*s = pattern.ReplaceAllLiteralString(*s, redactedPlaceholder)
+					changed = true
+				}
+			}
+		}
+
+		redactText(&msg.Content)
+
+		for j := range msg.Embeds {
+			e := &msg.Embeds[j]
+			redactText(&e.Title)
+			redactText(&e.Description)
+
+			if e.Author != nil {
+				redactText(&e.Author.Name)
+			}
+
+			if e.Footer != nil {
+				redactText(&e.Footer.Text)
+			}
+
+			for _, field := range e.Fields {
+				if field != nil {
+					redactText(&field.Name)
+					redactText(&field.Value)
+				}
+			}
+		}
+
+		if changed {
+			result.MessagesAffected++
+		}
+	}
+
+	return result
+}
@@ -0,0 +1,98 @@
+package api
+
+import (
+	"regexp"
+	"testing"
+
+	"github.com/TicketsBot-cloud/dashboard/chatreplica"
+	v2 "github.com/TicketsBot/logarchiver/pkg/model/v2"
+	"github.com/rxdn/gdl/objects/channel"
+	"github.com/rxdn/gdl/objects/channel/embed"
+	"github.com/stretchr/testify/assert"
+)
+
+func testRedactionTranscript() chatreplica.Transcript {
+	return chatreplica.Transcript{
+		Messages: []chatreplica.TranscriptMessage{
+			{
+				Message: v2.Message{
+					Id:          1,
+					Content:     "my password is hunter2",
+					Attachments: []channel.Attachment{{Id: 10, Filename: "passport.png"}},
+				},
+				StickerItems: []chatreplica.StickerItem{{Id: 30, Name: "wave"}},
+			},
+			{
+				Message: v2.Message{
+					Id:          2,
+					Content:     "thanks",
+					Attachments: []channel.Attachment{{Id: 20, Filename: "a.png"}, {Id: 21, Filename: "b.png"}},
+				},
+			},
+			{
+				Message: v2.Message{
+					Id: 3,
+					Embeds: []embed.Embed{{
+						Description: "email: user@example.com",
+						Fields:      []*embed.EmbedField{{Name: "Contact", Value: "user@example.com"}},
+					}},
+				},
+			},
+		},
+	}
+}
+
+func TestRedactMessageIds(t *testing.T) {
+	transcript := testRedactionTranscript()
+
+	result := redactTranscript(&transcript, redactionRules{MessageIds: []uint64{1}})
+	assert.Equal(t, redactionResult{MessagesAffected: 1, AttachmentsRemoved: 1}, result)
+	assert.Equal(t, redactedPlaceholder, transcript.Messages[0].Content)
+	assert.Empty(t, transcript.Messages[0].Attachments)
+	assert.Empty(t, transcript.Messages[0].StickerItems)
+	assert.Equal(t, "thanks", transcript.Messages[1].Content)
+}
+
+func TestRedactAttachmentIds(t *testing.T) {
+	transcript := testRedactionTranscript()
+
+	result := redactTranscript(&transcript, redactionRules{AttachmentIds: []uint64{21}})
+	assert.Equal(t, redactionResult{MessagesAffected: 1, AttachmentsRemoved: 1}, result)
+	assert.Equal(t, []channel.Attachment{{Id: 20, Filename: "a.png"}}, transcript.Messages[1].Attachments)
+	assert.Equal(t, "thanks", transcript.Messages[1].Content)
+}
+
+func TestRedactPatterns(t *testing.T) {
+	transcript := testRedactionTranscript()
+
+	rules := redactionRules{
+		Patterns: []*regexp.Regexp{regexp.MustCompile(`hunter\d`), regexp.MustCompile(`[\w.]+@[\w.]+`)},
+	}
+
+	result := redactTranscript(&transcript, rules)
+	assert.Equal(t, redactionResult{MessagesAffected: 2}, result)
+	assert.Equal(t, "my password is [redacted]", transcript.Messages[0].Content)
+	assert.Equal(t, "email: [redacted]", transcript.Messages[2].Embeds[0].Description)
+	assert.Equal(t, "[redacted]", transcript.Messages[2].Embeds[0].Fields[0].Value)
+	assert.Equal(t, "Contact", transcript.Messages[2].Embeds[0].Fields[0].Name)
+}
+
+func TestRedactNoMatches(t *testing.T) {
+	transcript := testRedactionTranscript()
+
+	result := redactTranscript(&transcript, redactionRules{Patterns: []*regexp.Regexp{regexp.MustCompile(`nothing`)}})
+	assert.Equal(t, redactionResult{}, result)
+}
+
+func TestRedactBodyValidation(t *testing.T) {
+	_, err := redactBody{}.toRules()
+	assert.Error(t, err)
+
+	_, err = redactBody{Patterns: []string{"("}}.toRules()
+	assert.Error(t, err)
+
+	rules, err := redactBody{Patterns: []string{`\d+`}, MessageIds: []uint64{1}}.toRules()
+	assert.NoError(t, err)
+	assert.Len(t, rules.Patterns, 1)
+	assert.Equal(t, []uint64{1}, rules.MessageIds)
+}
@@ -1,14 +1,20 @@
 package api
 
 import (
+	"context"
 	"errors"
+	"fmt"
 	"strconv"
+	"time"
 
 	"github.com/TicketsBot-cloud/archiverclient"
 	"github.com/TicketsBot-cloud/dashboard/chatreplica"
 	dbclient "github.com/TicketsBot-cloud/dashboard/database"
+	"github.com/TicketsBot-cloud/dashboard/log"
+	"github.com/TicketsBot-cloud/dashboard/redis"
 	"github.com/TicketsBot-cloud/dashboard/utils"
 	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
 )
 
 func GetTranscriptRenderHandler(ctx *gin.Context) {
@@ -53,8 +59,7 @@ func GetTranscriptRenderHandler(ctx *gin.Context) {
 		}
 	}
 
-	// retrieve ticket messages from bucket
-	transcript, err := utils.ArchiverClient.Get(ctx, guildId, ticketId)
+	html, err := renderTranscript(ctx, guildId, ticketId)
 	if err != nil {
 		if errors.Is(err, archiverclient.ErrNotFound) {
 			ctx.JSON(404, utils.ErrorStr("Transcript not found"))
@@ -65,13 +70,68 @@ func GetTranscriptRenderHandler(ctx *gin.Context) {
 		return
 	}
 
+	ctx.Data(200, "text/html", html)
+}
+
+// renderTranscript returns the rendered transcript, from the cache if possible. If the transcript does not exist,
+// archiverclient.ErrNotFound is returned.
+func renderTranscript(ctx context.Context, guildId uint64, ticketId int) ([]byte, error) {
+	cacheVersion, cacheTtl, err := renderCacheVersion(ctx, guildId)
+	if err != nil {
+		return nil, err
+	}
+
+	// Transcripts never change once archived, unless redacted or imported again, so can be served from the cache
+	cached, ok, err := redis.Client.GetRenderedTranscript(ctx, guildId, ticketId, cacheVersion)
+	if err != nil {
+		log.Logger.Warn("Failed to get rendered transcript from cache", zap.Error(err))
+	} else if ok {
+		return cached, nil
+	}
+
+	// retrieve ticket messages from bucket
+	transcript, err := utils.GetTranscript(ctx, guildId, ticketId)
+	if err != nil {
+		return nil, err
+	}
+
 	// Render
-	payload := chatreplica.FromTranscript(transcript, ticketId)
-	html, err := chatreplica.Render(payload)
+	payload := utils.TranscriptPayload(ctx, transcript, guildId, ticketId)
+	html, rendererVersion, err := chatreplica.Render(payload)
 	if err != nil {
-		ctx.JSON(500, utils.ErrorJson(err))
-		return
+		return nil, err
 	}
 
-	ctx.Data(200, "text/html", html)
+	// Don't cache the output of the fallback renderer, so that the render service is tried again next time
+	if rendererVersion == chatreplica.RendererVersion() {
+		if err := redis.Client.SetRenderedTranscript(ctx, guildId, ticketId, cacheVersion, html, cacheTtl); err != nil {
+			log.Logger.Warn("Failed to cache rendered transcript", zap.Error(err))
+		}
+	}
+
+	return html, nil
+}
+
+// renderCacheVersion returns the version that rendered transcripts for the guild are cached under, and how long the
+// version remains in use for. Importing data may overwrite transcripts, so the time of the last import activity is
+// included. Links to mirrored attachments expire, so the version also changes halfway through their validity,
+// ensuring that cached links are never stale. The change is offset by the guild ID, so that every guild's cache
+// doesn't expire at the same moment.
+func renderCacheVersion(ctx context.Context, guildId uint64) (string, time.Duration, error) {
+	lastImport, err := dbclient.Dashboard.ImportLogs.GetLastActivity(ctx, guildId)
+	if err != nil {
+		return "", 0, err
+	}
+
+	var importGeneration int64
+	if lastImport != nil {
+		importGeneration = lastImport.Unix()
+	}
+
+	period := int64((utils.MirroredAttachmentLinkValidity / 2).Seconds())
+	offset := time.Now().Unix() + int64(guildId%uint64(period))
+	linkGeneration := offset / period
+	remaining := time.Duration(period-offset%period) * time.Second
+
+	return fmt.Sprintf("%s:%d:%d", chatreplica.RendererVersion(), importGeneration, linkGeneration), remaining, nil
 }
@@ -0,0 +1,169 @@
+package api
+
+import (
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/TicketsBot-cloud/dashboard/app"
+	"github.com/TicketsBot-cloud/dashboard/app/background"
+	dbclient "github.com/TicketsBot-cloud/dashboard/database"
+	"github.com/TicketsBot-cloud/dashboard/utils"
+	"github.com/gin-gonic/gin"
+)
+
+type (
+	retentionBody struct {
+		RetentionDays *int             `json:"retention_days"` // Keep forever if nil
+		Panels        []panelRetention `json:"panels"`
+	}
+
+	panelRetention struct {
+		PanelId       int  `json:"panel_id"`
+		RetentionDays *int `json:"retention_days"` // Keep forever if nil
+	}
+
+	retentionResponse struct {
+		retentionBody
+		EnforcedFrom *time.Time `json:"enforced_from"`
+	}
+
+	retentionPreviewResponse struct {
+		Total        int                          `json:"total"`
+		Transcripts  []dbclient.ExpiredTranscript `json:"transcripts"`
+		EnforcedFrom *time.Time                   `json:"enforced_from"`
+	}
+)
+
+const (
+	minRetentionDays         = 1
+	maxRetentionDays         = 3650
+	retentionPreviewLimit    = 100
+	retentionPanelLimit      = 100
+	retentionDaysErrorFormat = "Retention must be between %d and %d days"
+)
+
+func GetRetention(ctx *gin.Context) {
+	guildId := ctx.Keys["guildid"].(uint64)
+
+	retention, ok, err := dbclient.Dashboard.TranscriptRetention.Get(ctx, guildId)
+	if err != nil {
+		_ = ctx.AbortWithError(http.StatusInternalServerError, app.NewServerError(err))
+		return
+	}
+
+	res := retentionResponse{
+		retentionBody: retentionBody{
+			RetentionDays: retention.RetentionDays,
+			Panels:        make([]panelRetention, 0, len(retention.PanelOverrides)),
+		},
+	}
+
+	for panelId, days := range retention.PanelOverrides {
+		res.Panels = append(res.Panels, panelRetention{PanelId: panelId, RetentionDays: days})
+	}
+
+	sort.Slice(res.Panels, func(i, j int) bool {
+		return res.Panels[i].PanelId < res.Panels[j].PanelId
+	})
+
+	if ok {
+		res.EnforcedFrom = utils.Ptr(retention.UpdatedAt.Add(background.TranscriptRetentionGracePeriod))
+	}
+
+	ctx.JSON(200, res)
+}
+
+// SetRetention replaces the guild's retention policy. Changes only take effect after a grace period, so that admins
+// can review which transcripts would be deleted using the preview endpoint.
+func SetRetention(ctx *gin.Context) {
+	guildId := ctx.Keys["guildid"].(uint64)
+
+	var body retentionBody
+	if err := ctx.BindJSON(&body); err != nil {
+		ctx.JSON(400, utils.ErrorJson(err))
+		return
+	}
+
+	if !isValidRetention(body.RetentionDays) {
+		ctx.JSON(400, utils.ErrorStr(retentionDaysErrorFormat, minRetentionDays, maxRetentionDays))
+		return
+	}
+
+	if len(body.Panels) > retentionPanelLimit {
+		ctx.JSON(400, utils.ErrorStr("Too many panel overrides"))
+		return
+	}
+
+	panels, err := dbclient.Client.Panel.GetByGuild(ctx, guildId)
+	if err != nil {
+		_ = ctx.AbortWithError(http.StatusInternalServerError, app.NewServerError(err))
+		return
+	}
+
+	guildPanels := make(map[int]struct{}, len(panels))
+	for _, panel := range panels {
+		guildPanels[panel.PanelId] = struct{}{}
+	}
+
+	retention := dbclient.TranscriptRetention{
+		RetentionDays:  body.RetentionDays,
+		PanelOverrides: make(map[int]*int, len(body.Panels)),
+	}
+
+	for _, panel := range body.Panels {
+		if _, ok := guildPanels[panel.PanelId]; !ok {
+			ctx.JSON(400, utils.ErrorStr("Panel not found"))
+			return
+		}
+
+		if !isValidRetention(panel.RetentionDays) {
+			ctx.JSON(400, utils.ErrorStr(retentionDaysErrorFormat, minRetentionDays, maxRetentionDays))
+			return
+		}
+
+		retention.PanelOverrides[panel.PanelId] = panel.RetentionDays
+	}
+
+	if err := dbclient.Dashboard.TranscriptRetention.Set(ctx, guildId, retention); err != nil {
+		_ = ctx.AbortWithError(http.StatusInternalServerError, app.NewServerError(err))
+		return
+	}
+
+	ctx.Status(204)
+}
+
+// PreviewRetention lists the transcripts that the current policy has expired, which will be deleted by the next
+// sweeps once the policy is being enforced
+func PreviewRetention(ctx *gin.Context) {
+	guildId := ctx.Keys["guildid"].(uint64)
+
+	retention, ok, err := dbclient.Dashboard.TranscriptRetention.Get(ctx, guildId)
+	if err != nil {
+		_ = ctx.AbortWithError(http.StatusInternalServerError, app.NewServerError(err))
+		return
+	}
+
+	if !ok {
+		ctx.JSON(200, retentionPreviewResponse{
+			Transcripts: make([]dbclient.ExpiredTranscript, 0),
+		})
+		return
+	}
+
+	expired, total, err := dbclient.Dashboard.TranscriptRetention.GetExpired(ctx, guildId, retentionPreviewLimit)
+	if err != nil {
+		_ = ctx.AbortWithError(http.StatusInternalServerError, app.NewServerError(err))
+		return
+	}
+
+	ctx.JSON(200, retentionPreviewResponse{
+		Total:        total,
+		Transcripts:  expired,
+		EnforcedFrom: utils.Ptr(retention.UpdatedAt.Add(background.TranscriptRetentionGracePeriod)),
+	})
+}
+
+func isValidRetention(days *int) bool {
+	return days == nil || (*days >= minRetentionDays && *days <= maxRetentionDays)
+}
@@ -0,0 +1,145 @@
+package api
+
+import (
+	"html"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/TicketsBot-cloud/dashboard/app"
+	dbclient "github.com/TicketsBot-cloud/dashboard/database"
+	"github.com/TicketsBot-cloud/dashboard/utils"
+	"github.com/gin-gonic/gin"
+)
+
+type (
+	searchSettings struct {
+		Enabled        bool `json:"enabled"`
+		IndexedTickets int  `json:"indexed_tickets"`
+	}
+
+	searchSettingsBody struct {
+		Enabled bool `json:"enabled"`
+	}
+)
+
+const (
+	searchPageLimit      = 25
+	searchMaxQueryLength = 256
+)
+
+// SearchTranscripts searches the content of the guild's indexed transcripts that the user may view. Snippets are HTML escaped, with matched
+// terms wrapped in <mark> tags.
+func SearchTranscripts(ctx *gin.Context) {
+	guildId := ctx.Keys["guildid"].(uint64)
+	userId := ctx.Keys["userid"].(uint64)
+
+	query := strings.TrimSpace(ctx.Query("q"))
+	if len(query) == 0 || len(query) > searchMaxQueryLength {
+		ctx.JSON(400, utils.ErrorStr("Search query must be between 1 and %d characters", searchMaxQueryLength))
+		return
+	}
+
+	page := 1
+	if raw := ctx.Query("page"); raw != "" {
+		var err error
+		page, err = strconv.Atoi(raw)
+		if err != nil || page < 1 {
+			ctx.JSON(400, utils.ErrorStr("Invalid page"))
+			return
+		}
+	}
+
+	enabled, err := dbclient.Dashboard.TranscriptSearch.IsEnabled(ctx, guildId)
+	if err != nil {
+		_ = ctx.AbortWithError(http.StatusInternalServerError, app.NewServerError(err))
+		return
+	}
+
+	if !enabled {
+		ctx.JSON(400, utils.ErrorStr("Transcript search is not enabled for this server"))
+		return
+	}
+
+	// Only search the tickets that the user may view, as support members may not be on every panel's team
+	filter, requestErr := utils.GetTicketViewFilter(ctx, guildId, userId)
+	if requestErr != nil {
+		ctx.JSON(requestErr.StatusCode, utils.ErrorJson(requestErr))
+		return
+	}
+
+	results, err := dbclient.Dashboard.TranscriptSearch.Search(ctx, guildId, query, filter, searchPageLimit, searchPageLimit*(page-1))
+	if err != nil {
+		_ = ctx.AbortWithError(http.StatusInternalServerError, app.NewServerError(err))
+		return
+	}
+
+	for i := range results {
+		results[i].Snippet = escapeSnippet(results[i].Snippet)
+	}
+
+	ctx.JSON(200, results)
+}
+
+func GetSearchSettings(ctx *gin.Context) {
+	guildId := ctx.Keys["guildid"].(uint64)
+
+	enabled, err := dbclient.Dashboard.TranscriptSearch.IsEnabled(ctx, guildId)
+	if err != nil {
+		_ = ctx.AbortWithError(http.StatusInternalServerError, app.NewServerError(err))
+		return
+	}
+
+	indexed, err := dbclient.Dashboard.TranscriptSearch.GetIndexedCount(ctx, guildId)
+	if err != nil {
+		_ = ctx.AbortWithError(http.StatusInternalServerError, app.NewServerError(err))
+		return
+	}
+
+	ctx.JSON(200, searchSettings{
+		Enabled:        enabled,
+		IndexedTickets: indexed,
+	})
+}
+
+// SetSearchSettings enables or disables transcript search. Once enabled, existing transcripts are indexed in the
+// background. Disabling search deletes the guild's index.
+func SetSearchSettings(ctx *gin.Context) {
+	guildId := ctx.Keys["guildid"].(uint64)
+
+	var body searchSettingsBody
+	if err := ctx.BindJSON(&body); err != nil {
+		ctx.JSON(400, utils.ErrorStr("Invalid request body"))
+		return
+	}
+
+	if err := dbclient.Dashboard.TranscriptSearch.SetEnabled(ctx, guildId, body.Enabled); err != nil {
+		_ = ctx.AbortWithError(http.StatusInternalServerError, app.NewServerError(err))
+		return
+	}
+
+	ctx.Status(204)
+}
+
+// escapeSnippet escapes the snippet returned by the database, other than the highlight tags
+func escapeSnippet(snippet string) string {
+	parts := strings.Split(snippet, dbclient.SearchHighlightStart)
+
+	var escaped strings.Builder
+	escaped.WriteString(html.EscapeString(parts[0]))
+
+	for _, part := range parts[1:] {
+		highlighted, rest, found := strings.Cut(part, dbclient.SearchHighlightStop)
+		if !found {
+			escaped.WriteString(html.EscapeString(dbclient.SearchHighlightStart + part))
+			continue
+		}
+
+		escaped.WriteString(dbclient.SearchHighlightStart)
+		escaped.WriteString(html.EscapeString(highlighted))
+		escaped.WriteString(dbclient.SearchHighlightStop)
+		escaped.WriteString(html.EscapeString(rest))
+	}
+
+	return escaped.String()
+}
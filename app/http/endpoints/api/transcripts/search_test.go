@@ -0,0 +1,14 @@
+package api
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEscapeSnippet(t *testing.T) {
+	assert.Equal(t, "order <mark>#12345</mark> &lt;b&gt;", escapeSnippet("order <mark>#12345</mark> <b>"))
+	assert.Equal(t, "&lt;script&gt; <mark>x</mark>", escapeSnippet("<script> <mark>x</mark>"))
+	assert.Equal(t, "a &lt;mark&gt;b", escapeSnippet("a <mark>b"))
+	assert.Equal(t, "no matches", escapeSnippet("no matches"))
+}
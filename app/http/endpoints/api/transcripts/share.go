@@ -0,0 +1,287 @@
+package api
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/TicketsBot-cloud/archiverclient"
+	"github.com/TicketsBot-cloud/dashboard/app"
+	"github.com/TicketsBot-cloud/dashboard/config"
+	dbclient "github.com/TicketsBot-cloud/dashboard/database"
+	"github.com/TicketsBot-cloud/dashboard/log"
+	"github.com/TicketsBot-cloud/dashboard/utils"
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+type (
+	createShareLinkBody struct {
+		ExpiresIn *int `json:"expires_in"` // Seconds
+	}
+
+	shareLinkResponse struct {
+		dbclient.TranscriptShareLink
+		Active bool    `json:"active"`
+		Path   *string `json:"path"`
+	}
+)
+
+const (
+	shareLinkDefaultExpiry = time.Hour * 24 * 7
+	shareLinkMinExpiry     = time.Minute * 5
+	shareLinkMaxExpiry     = time.Hour * 24 * 30
+	shareLinkListLimit     = 100
+	shareLinkAccessLimit   = 250
+	shareLinkMaxUserAgent  = 512
+)
+
+// CreateShareLink creates a link that allows anyone with it to view the transcript, without logging in, until it
+// expires or is revoked.
+func CreateShareLink(ctx *gin.Context) {
+	guildId, userId := ctx.Keys["guildid"].(uint64), ctx.Keys["userid"].(uint64)
+
+	ticketId, err := strconv.Atoi(ctx.Param("ticketId"))
+	if err != nil {
+		ctx.JSON(400, utils.ErrorStr("Invalid ticket ID"))
+		return
+	}
+
+	var body createShareLinkBody
+	if err := ctx.BindJSON(&body); err != nil {
+		ctx.JSON(400, utils.ErrorJson(err))
+		return
+	}
+
+	expiresIn := shareLinkDefaultExpiry
+	if body.ExpiresIn != nil {
+		expiresIn = time.Duration(*body.ExpiresIn) * time.Second
+	}
+
+	if expiresIn < shareLinkMinExpiry || expiresIn > shareLinkMaxExpiry {
+		ctx.JSON(400, utils.ErrorStr("Links must expire between 5 minutes and 30 days from now"))
+		return
+	}
+
+	ticket, err := dbclient.Client.Tickets.Get(ctx, ticketId, guildId)
+	if err != nil {
+		_ = ctx.AbortWithError(http.StatusInternalServerError, app.NewServerError(err))
+		return
+	}
+
+	if ticket.UserId == 0 || ticket.Open {
+		ctx.JSON(404, utils.ErrorStr("Transcript not found"))
+		return
+	}
+
+	link, err := dbclient.Dashboard.TranscriptShareLinks.Create(ctx, guildId, ticketId, userId, time.Now().Add(expiresIn))
+	if err != nil {
+		_ = ctx.AbortWithError(http.StatusInternalServerError, app.NewServerError(err))
+		return
+	}
+
+	ctx.JSON(201, newShareLinkResponse(link, time.Now()))
+}
+
+// ListShareLinks returns the guild's share links, including those that have expired or been revoked
+func ListShareLinks(ctx *gin.Context) {
+	guildId := ctx.Keys["guildid"].(uint64)
+
+	links, err := dbclient.Dashboard.TranscriptShareLinks.GetByGuild(ctx, guildId, shareLinkListLimit)
+	if err != nil {
+		_ = ctx.AbortWithError(http.StatusInternalServerError, app.NewServerError(err))
+		return
+	}
+
+	now := time.Now()
+	res := make([]shareLinkResponse, len(links))
+	for i, link := range links {
+		res[i] = newShareLinkResponse(link, now)
+	}
+
+	ctx.JSON(200, res)
+}
+
+func RevokeShareLink(ctx *gin.Context) {
+	guildId := ctx.Keys["guildid"].(uint64)
+
+	linkId, err := strconv.Atoi(ctx.Param("linkId"))
+	if err != nil {
+		ctx.JSON(400, utils.ErrorStr("Invalid link ID"))
+		return
+	}
+
+	found, err := dbclient.Dashboard.TranscriptShareLinks.Revoke(ctx, guildId, linkId)
+	if err != nil {
+		_ = ctx.AbortWithError(http.StatusInternalServerError, app.NewServerError(err))
+		return
+	}
+
+	if !found {
+		ctx.JSON(404, utils.ErrorStr("Link not found"))
+		return
+	}
+
+	ctx.Status(204)
+}
+
+// GetShareLinkAccesses returns the log of the link being used to view the transcript
+func GetShareLinkAccesses(ctx *gin.Context) {
+	guildId := ctx.Keys["guildid"].(uint64)
+
+	linkId, err := strconv.Atoi(ctx.Param("linkId"))
+	if err != nil {
+		ctx.JSON(400, utils.ErrorStr("Invalid link ID"))
+		return
+	}
+
+	link, ok, err := dbclient.Dashboard.TranscriptShareLinks.Get(ctx, linkId)
+	if err != nil {
+		_ = ctx.AbortWithError(http.StatusInternalServerError, app.NewServerError(err))
+		return
+	}
+
+	if !ok || link.GuildId != guildId {
+		ctx.JSON(404, utils.ErrorStr("Link not found"))
+		return
+	}
+
+	accesses, err := dbclient.Dashboard.TranscriptShareLinks.GetAccesses(ctx, linkId, shareLinkAccessLimit)
+	if err != nil {
+		_ = ctx.AbortWithError(http.StatusInternalServerError, app.NewServerError(err))
+		return
+	}
+
+	ctx.JSON(200, accesses)
+}
+
+// ViewSharedTranscript renders the transcript that a share link points to. It is served outside the API, without
+// authentication, so responds with plain text rather than JSON errors.
+func ViewSharedTranscript(ctx *gin.Context) {
+	ctx.Header("Cache-Control", "no-store")
+	ctx.Header("Referrer-Policy", "no-referrer")
+	ctx.Header("X-Robots-Tag", "noindex, nofollow")
+
+	link, ok, err := getSharedLink(ctx, ctx.Param("token"))
+	if err != nil {
+		log.Logger.Error("Failed to get transcript share link", zap.Error(err))
+		ctx.String(500, "Failed to load transcript")
+		return
+	}
+
+	if !ok {
+		ctx.String(404, "This link is invalid")
+		return
+	}
+
+	if !link.IsActive(time.Now()) {
+		ctx.String(410, "This link has expired or been revoked")
+		return
+	}
+
+	userAgent := ctx.Request.UserAgent()
+	if len(userAgent) > shareLinkMaxUserAgent {
+		userAgent = userAgent[:shareLinkMaxUserAgent]
+	}
+
+	if err := dbclient.Dashboard.TranscriptShareLinks.LogAccess(ctx, link.Id, ctx.ClientIP(), userAgent); err != nil {
+		// Don't serve the transcript if the access can't be recorded
+		log.Logger.Error("Failed to log transcript share link access", zap.Error(err))
+		ctx.String(500, "Failed to load transcript")
+		return
+	}
+
+	html, err := renderTranscript(ctx, link.GuildId, link.TicketId)
+	if err != nil {
+		if errors.Is(err, archiverclient.ErrNotFound) {
+			ctx.String(404, "Transcript not found")
+		} else {
+			log.Logger.Error("Failed to render shared transcript", zap.Int("link_id", link.Id), zap.Error(err))
+			ctx.String(500, "Failed to load transcript")
+		}
+
+		return
+	}
+
+	ctx.Data(200, "text/html; charset=utf-8", html)
+}
+
+func newShareLinkResponse(link dbclient.TranscriptShareLink, now time.Time) shareLinkResponse {
+	res := shareLinkResponse{
+		TranscriptShareLink: link,
+		Active:              link.IsActive(now),
+	}
+
+	if res.Active {
+		path := "/share/transcripts/" + signShareLink([]byte(config.Conf.Server.Secret), link)
+		res.Path = &path
+	}
+
+	return res
+}
+
+// getSharedLink returns the link that the token was issued for, if the token's signature is valid
+func getSharedLink(ctx context.Context, token string) (dbclient.TranscriptShareLink, bool, error) {
+	linkId, ok := parseShareToken(token)
+	if !ok {
+		return dbclient.TranscriptShareLink{}, false, nil
+	}
+
+	link, ok, err := dbclient.Dashboard.TranscriptShareLinks.Get(ctx, linkId)
+	if err != nil || !ok {
+		return dbclient.TranscriptShareLink{}, false, err
+	}
+
+	if !verifyShareToken([]byte(config.Conf.Server.Secret), token, link) {
+		return dbclient.TranscriptShareLink{}, false, nil
+	}
+
+	return link, true, nil
+}
+
+// signShareLink returns a token of the form "<link ID>.<signature>". The signature covers the transcript and expiry
+// time, so a token can't be reused for another transcript, nor have its expiry extended.
+func signShareLink(secret []byte, link dbclient.TranscriptShareLink) string {
+	return fmt.Sprintf("%d.%s", link.Id, base64.RawURLEncoding.EncodeToString(shareLinkSignature(secret, link)))
+}
+
+func verifyShareToken(secret []byte, token string, link dbclient.TranscriptShareLink) bool {
+	_, encodedSignature, found := strings.Cut(token, ".")
+	if !found {
+		return false
+	}
+
+	signature, err := base64.RawURLEncoding.DecodeString(encodedSignature)
+	if err != nil {
+		return false
+	}
+
+	return hmac.Equal(signature, shareLinkSignature(secret, link))
+}
+
+func parseShareToken(token string) (int, bool) {
+	rawId, _, found := strings.Cut(token, ".")
+	if !found {
+		return 0, false
+	}
+
+	linkId, err := strconv.Atoi(rawId)
+	if err != nil || linkId <= 0 {
+		return 0, false
+	}
+
+	return linkId, true
+}
+
+func shareLinkSignature(secret []byte, link dbclient.TranscriptShareLink) []byte {
+	mac := hmac.New(sha256.New, secret)
+	_, _ = fmt.Fprintf(mac, "transcript-share:%d:%d:%d:%d", link.Id, link.GuildId, link.TicketId, link.ExpiresAt.Unix())
+	return mac.Sum(nil)
+}
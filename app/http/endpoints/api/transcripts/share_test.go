@@ -0,0 +1,66 @@
+package api
+
+import (
+	"testing"
+	"time"
+
+	dbclient "github.com/TicketsBot-cloud/dashboard/database"
+	"github.com/stretchr/testify/assert"
+)
+
+func testShareLink() dbclient.TranscriptShareLink {
+	return dbclient.TranscriptShareLink{
+		Id:        7,
+		GuildId:   123,
+		TicketId:  45,
+		ExpiresAt: time.Unix(1700000000, 0),
+	}
+}
+
+func TestShareTokenRoundTrip(t *testing.T) {
+	secret := []byte("secret")
+	link := testShareLink()
+
+	token := signShareLink(secret, link)
+
+	linkId, ok := parseShareToken(token)
+	assert.True(t, ok)
+	assert.Equal(t, link.Id, linkId)
+	assert.True(t, verifyShareToken(secret, token, link))
+}
+
+func TestShareTokenRejectsTampering(t *testing.T) {
+	secret := []byte("secret")
+	link := testShareLink()
+	token := signShareLink(secret, link)
+
+	assert.False(t, verifyShareToken([]byte("other"), token, link))
+
+	extended := link
+	extended.ExpiresAt = link.ExpiresAt.Add(time.Hour)
+	assert.False(t, verifyShareToken(secret, token, extended))
+
+	otherTicket := link
+	otherTicket.TicketId = 46
+	assert.False(t, verifyShareToken(secret, token, otherTicket))
+
+	assert.False(t, verifyShareToken(secret, "7.", link))
+	assert.False(t, verifyShareToken(secret, "7", link))
+}
+
+func TestParseShareTokenInvalid(t *testing.T) {
+	for _, token := range []string{"", "abc", "abc.def", "-1.abc", "0.abc"} {
+		_, ok := parseShareToken(token)
+		assert.False(t, ok, token)
+	}
+}
+
+func TestShareLinkIsActive(t *testing.T) {
+	link := testShareLink()
+	assert.True(t, link.IsActive(link.ExpiresAt.Add(-time.Second)))
+	assert.False(t, link.IsActive(link.ExpiresAt))
+
+	revokedAt := link.ExpiresAt.Add(-time.Hour)
+	link.RevokedAt = &revokedAt
+	assert.False(t, link.IsActive(link.ExpiresAt.Add(-time.Minute)))
+}
@@ -10,10 +10,12 @@ import (
 	api_import "github.com/TicketsBot-cloud/dashboard/app/http/endpoints/api/export"
 	api_forms "github.com/TicketsBot-cloud/dashboard/app/http/endpoints/api/forms"
 	api_integrations "github.com/TicketsBot-cloud/dashboard/app/http/endpoints/api/integrations"
+	api_labels "github.com/TicketsBot-cloud/dashboard/app/http/endpoints/api/labels"
 	api_panels "github.com/TicketsBot-cloud/dashboard/app/http/endpoints/api/panel"
 	api_premium "github.com/TicketsBot-cloud/dashboard/app/http/endpoints/api/premium"
 	api_settings "github.com/TicketsBot-cloud/dashboard/app/http/endpoints/api/settings"
 	api_override "github.com/TicketsBot-cloud/dashboard/app/http/endpoints/api/staffoverride"
+	api_stats "github.com/TicketsBot-cloud/dashboard/app/http/endpoints/api/stats"
 	api_tags "github.com/TicketsBot-cloud/dashboard/app/http/endpoints/api/tags"
 	api_team "github.com/TicketsBot-cloud/dashboard/app/http/endpoints/api/team"
 	api_ticket "github.com/TicketsBot-cloud/dashboard/app/http/endpoints/api/ticket"
@@ -77,6 +79,9 @@ func StartServer(logger *zap.Logger, sm *livechat.SocketManager) {
 		ctx.String(200, "Disallow: /")
 	})
 
+	// Shared transcripts are opened directly in the browser by people without a dashboard account
+	router.GET("/share/transcripts/:token", rl(middleware.RateLimitTypeIp, 10, time.Second*10), api_transcripts.ViewSharedTranscript)
+
 	router.POST("/callback", middleware.VerifyXTicketsHeader, root.CallbackHandler)
 	router.POST("/logout", middleware.VerifyXTicketsHeader, middleware.AuthenticateToken, root.LogoutHandler)
 
@@ -135,13 +140,24 @@ func StartServer(logger *zap.Logger, sm *livechat.SocketManager) {
 		// Must be readable to load transcripts page
 		guildAuthApiSupport.GET("/panels", api_panels.ListPanels)
 		guildAuthApiAdmin.POST("/panels", api_panels.CreatePanel)
+		guildAuthApiAdmin.POST("/panels/preview", rl(middleware.RateLimitTypeGuild, 30, time.Minute), api_panels.PreviewPanel)
+		guildAuthApiAdmin.POST("/panels/import", rl(middleware.RateLimitTypeGuild, 5, time.Minute), api_panels.ImportPanelTemplate)
 		guildAuthApiAdmin.POST("/panels/:panelid", rl(middleware.RateLimitTypeGuild, 5, 5*time.Second), api_panels.ResendPanel)
+		guildAuthApiAdmin.POST("/panels/:panelid/clone", rl(middleware.RateLimitTypeGuild, 5, time.Minute), api_panels.ClonePanel)
+		guildAuthApiAdmin.GET("/panels/:panelid/template", api_panels.ExportPanelTemplate)
 		guildAuthApiAdmin.PATCH("/panels/:panelid", api_panels.UpdatePanel)
 		guildAuthApiAdmin.DELETE("/panels/:panelid", api_panels.DeletePanel)
+		guildAuthApiAdmin.GET("/panels/:panelid/sla", api_panels.GetPanelSla)
+		guildAuthApiAdmin.PUT("/panels/:panelid/sla", api_panels.SetPanelSla)
+		guildAuthApiAdmin.GET("/panels/:panelid/availability", api_panels.GetPanelAvailability)
+		guildAuthApiAdmin.PUT("/panels/:panelid/availability", api_panels.SetPanelAvailability)
+		guildAuthApiAdmin.DELETE("/panels/:panelid/availability", api_panels.DeletePanelAvailability)
 
 		guildAuthApiAdmin.GET("/multipanels", api_panels.MultiPanelList)
 		guildAuthApiAdmin.POST("/multipanels", api_panels.MultiPanelCreate)
+		guildAuthApiAdmin.POST("/multipanels/preview", rl(middleware.RateLimitTypeGuild, 30, time.Minute), api_panels.PreviewMultiPanel)
 		guildAuthApiAdmin.POST("/multipanels/:panelid", rl(middleware.RateLimitTypeGuild, 5, 5*time.Second), api_panels.MultiPanelResend)
+		guildAuthApiAdmin.GET("/multipanels/:panelid/template", api_panels.ExportMultiPanelTemplate)
 		guildAuthApiAdmin.PATCH("/multipanels/:panelid", api_panels.MultiPanelUpdate)
 		guildAuthApiAdmin.DELETE("/multipanels/:panelid", api_panels.MultiPanelDelete)
 
@@ -157,16 +173,56 @@ func StartServer(logger *zap.Logger, sm *livechat.SocketManager) {
 			rl(middleware.RateLimitTypeUser, 20, time.Minute),
 			api_transcripts.ListTranscripts,
 		)
+		guildAuthApiSupport.GET("/transcripts/search", rl(middleware.RateLimitTypeUser, 10, 10*time.Second), api_transcripts.SearchTranscripts)
+		guildAuthApiSupport.GET("/transcripts/search/settings", api_transcripts.GetSearchSettings)
+		guildAuthApiAdmin.PUT("/transcripts/search/settings", api_transcripts.SetSearchSettings)
+		guildAuthApiSupport.POST("/transcripts/export",
+			rl(middleware.RateLimitTypeGuild, 5, time.Minute),
+			api_transcripts.ExportTickets,
+		)
+
+		guildAuthApiAdmin.GET("/transcripts/retention", api_transcripts.GetRetention)
+		guildAuthApiAdmin.PUT("/transcripts/retention", api_transcripts.SetRetention)
+		guildAuthApiAdmin.GET("/transcripts/retention/preview", api_transcripts.PreviewRetention)
+
+		guildAuthApiAdmin.POST("/transcripts/bulk", rl(middleware.RateLimitTypeGuild, 5, time.Minute), api_transcripts.CreateBulkExport)
+		guildAuthApiAdmin.GET("/transcripts/bulk", api_transcripts.ListBulkExports)
+		guildAuthApiAdmin.GET("/transcripts/bulk/:jobId", api_transcripts.GetBulkExport)
+
+		guildAuthApiAdmin.POST("/transcripts/:ticketId/share", api_transcripts.CreateShareLink)
+		guildAuthApiAdmin.POST("/transcripts/:ticketId/redact", rl(middleware.RateLimitTypeGuild, 10, time.Minute), api_transcripts.RedactTranscript)
+		guildAuthApiAdmin.GET("/transcripts/:ticketId/redactions", api_transcripts.GetRedactions)
+		guildAuthApiAdmin.GET("/transcripts/shares", api_transcripts.ListShareLinks)
+		guildAuthApiAdmin.DELETE("/transcripts/shares/:linkId", api_transcripts.RevokeShareLink)
+		guildAuthApiAdmin.GET("/transcripts/shares/:linkId/accesses", api_transcripts.GetShareLinkAccesses)
 
 		// Allow regular users to get their own transcripts, make sure you check perms inside
 		guildApiNoAuth.GET("/transcripts/:ticketId", rl(middleware.RateLimitTypeGuild, 10, 10*time.Second), api_transcripts.GetTranscriptHandler)
 		guildApiNoAuth.GET("/transcripts/:ticketId/render", rl(middleware.RateLimitTypeGuild, 10, 10*time.Second), api_transcripts.GetTranscriptRenderHandler)
 
+		guildAuthApiAdmin.GET("/stats", rl(middleware.RateLimitTypeGuild, 10, time.Minute), api_stats.GetStats)
+		guildAuthApiAdmin.GET("/stats/staff", rl(middleware.RateLimitTypeGuild, 10, time.Minute), api_stats.GetStaffReport)
+		guildAuthApiAdmin.GET("/feedback", rl(middleware.RateLimitTypeGuild, 10, time.Minute), api_stats.GetFeedback)
+		guildAuthApiAdmin.GET("/feedback/export", rl(middleware.RateLimitTypeGuild, 2, time.Minute), api_stats.ExportFeedback)
+
 		guildAuthApiSupport.GET("/tickets", api_ticket.GetTickets)
+		guildAuthApiSupport.GET("/tickets/overdue", api_ticket.GetOverdueTickets)
 		guildAuthApiSupport.GET("/tickets/:ticketId", api_ticket.GetTicket)
 		guildAuthApiSupport.POST("/tickets/:ticketId", rl(middleware.RateLimitTypeGuild, 5, time.Second*5), api_ticket.SendMessage)
 		guildAuthApiSupport.POST("/tickets/:ticketId/tag", rl(middleware.RateLimitTypeGuild, 5, time.Second*5), api_ticket.SendTag)
+		guildAuthApiSupport.POST("/tickets/:ticketId/preview", rl(middleware.RateLimitTypeGuild, 10, time.Second*10), api_ticket.PreviewMessage)
 		guildAuthApiSupport.DELETE("/tickets/:ticketId", api_ticket.CloseTicket)
+		guildAuthApiSupport.GET("/tickets/:ticketId/scheduled-close", api_ticket.GetScheduledClose)
+		guildAuthApiSupport.PUT("/tickets/:ticketId/scheduled-close", rl(middleware.RateLimitTypeGuild, 5, time.Second*5), api_ticket.ScheduleClose)
+		guildAuthApiSupport.DELETE("/tickets/:ticketId/scheduled-close", api_ticket.CancelScheduledClose)
+		guildAuthApiSupport.PUT("/tickets/:ticketId/labels", rl(middleware.RateLimitTypeGuild, 10, time.Second*10), api_ticket.SetTicketLabels)
+		guildAuthApiSupport.PUT("/tickets/:ticketId/priority", rl(middleware.RateLimitTypeGuild, 10, time.Second*10), api_ticket.SetTicketPriority)
+
+		// Must be readable to load tickets and transcripts pages
+		guildAuthApiSupport.GET("/labels", api_labels.ListLabels)
+		guildAuthApiAdmin.POST("/labels", rl(middleware.RateLimitTypeGuild, 30, time.Hour), api_labels.CreateLabel)
+		guildAuthApiAdmin.PATCH("/labels/:labelid", api_labels.UpdateLabel)
+		guildAuthApiAdmin.DELETE("/labels/:labelid", api_labels.DeleteLabel)
 
 		// Websockets do not support headers: so we must implement authentication over the WS connection
 		router.GET("/api/:id/tickets/:ticketId/live-chat", livechat.GetLiveChatHandler(sm))
@@ -203,6 +259,8 @@ func StartServer(logger *zap.Logger, sm *livechat.SocketManager) {
 	{
 		userGroup.POST("/guilds/reload", api.ReloadGuildsHandler)
 		userGroup.GET("/permissionlevel", api.GetPermissionLevel)
+		userGroup.GET("/transcripts", rl(middleware.RateLimitTypeUser, 10, time.Second*10), api_transcripts.ListSelfTranscripts)
+		userGroup.POST("/transcripts/:guildId/:ticketId/rating", rl(middleware.RateLimitTypeUser, 10, time.Minute), api_transcripts.RateSelfTicket)
 
 		{
 			whitelabelGroup := userGroup.Group("/whitelabel", middleware.VerifyWhitelabel(true))
@@ -0,0 +1,26 @@
+package chatreplica
+
+import "github.com/rxdn/gdl/objects/channel"
+
+// RewriteAttachmentUrls points attachments at different URLs, such as copies in our own storage, keyed by attachment
+// ID. Attachments without a URL are left unchanged.
+func (p *Payload) RewriteAttachmentUrls(urls map[uint64]string) {
+	for i, msg := range p.Messages {
+		if len(msg.Attachments) == 0 {
+			continue
+		}
+
+		// Copy, as the attachments may be shared with the transcript that the payload was created from
+		attachments := make([]channel.Attachment, len(msg.Attachments))
+		copy(attachments, msg.Attachments)
+
+		for j, attachment := range attachments {
+			if url, ok := urls[attachment.Id]; ok {
+				attachments[j].Url = url
+				attachments[j].ProxyUrl = url
+			}
+		}
+
+		p.Messages[i].Attachments = attachments
+	}
+}
@@ -0,0 +1,26 @@
+package chatreplica
+
+import (
+	"testing"
+
+	"github.com/rxdn/gdl/objects/channel"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRewriteAttachmentUrls(t *testing.T) {
+	attachments := []channel.Attachment{
+		{Id: 10, Url: "https://cdn.discordapp.com/a.png", ProxyUrl: "https://media.discordapp.net/a.png"},
+		{Id: 11, Url: "https://cdn.discordapp.com/b.png"},
+	}
+
+	payload := Payload{Messages: []Message{{Attachments: attachments}, {Content: "no attachments"}}}
+	payload.RewriteAttachmentUrls(map[uint64]string{10: "https://mirror.example.com/a.png"})
+
+	assert.Equal(t, "https://mirror.example.com/a.png", payload.Messages[0].Attachments[0].Url)
+	assert.Equal(t, "https://mirror.example.com/a.png", payload.Messages[0].Attachments[0].ProxyUrl)
+	assert.Equal(t, "https://cdn.discordapp.com/b.png", payload.Messages[0].Attachments[1].Url)
+	assert.Nil(t, payload.Messages[1].Attachments)
+
+	// The source transcript's attachments are not modified
+	assert.Equal(t, "https://cdn.discordapp.com/a.png", attachments[0].Url)
+}
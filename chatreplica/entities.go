@@ -0,0 +1,115 @@
+package chatreplica
+
+import (
+	"context"
+	"strconv"
+
+	"github.com/rxdn/gdl/objects/channel"
+	"github.com/rxdn/gdl/objects/channel/embed"
+	"github.com/rxdn/gdl/objects/guild"
+)
+
+// EntityResolver looks up the current roles and channels of a guild. It is satisfied by botcontext.BotContext.
+type EntityResolver interface {
+	GetGuildRoles(ctx context.Context, guildId uint64) ([]guild.Role, error)
+	GetGuildChannels(ctx context.Context, guildId uint64) ([]channel.Channel, error)
+}
+
+// ResolveEntities fills in the roles and channels mentioned by the payload's messages, using the guild's current
+// state. Mentions that can no longer be resolved keep any entity data that was archived with the transcript. If the
+// resolver returns an error, the payload is left with whatever data it already had.
+func (p *Payload) ResolveEntities(ctx context.Context, resolver EntityResolver, guildId uint64) error {
+	roleIds, channelIds := p.mentionedEntities()
+
+	if p.Entities.Roles == nil {
+		p.Entities.Roles = make(map[string]Role)
+	}
+
+	if p.Entities.Channels == nil {
+		p.Entities.Channels = make(map[string]Channel)
+	}
+
+	if len(roleIds) > 0 {
+		roles, err := resolver.GetGuildRoles(ctx, guildId)
+		if err != nil {
+			return err
+		}
+
+		for _, role := range roles {
+			snowflake := strconv.FormatUint(role.Id, 10)
+			if _, ok := roleIds[snowflake]; ok {
+				p.Entities.Roles[snowflake] = Role{
+					Name:  role.Name,
+					Color: role.Color,
+				}
+			}
+		}
+	}
+
+	if len(channelIds) > 0 {
+		channels, err := resolver.GetGuildChannels(ctx, guildId)
+		if err != nil {
+			return err
+		}
+
+		for _, ch := range channels {
+			snowflake := strconv.FormatUint(ch.Id, 10)
+			if _, ok := channelIds[snowflake]; ok {
+				p.Entities.Channels[snowflake] = Channel{
+					Name: ch.Name,
+				}
+			}
+		}
+	}
+
+	return nil
+}
+
+// mentionedEntities returns the IDs of the roles and channels mentioned in message content and embeds
+func (p *Payload) mentionedEntities() (map[string]struct{}, map[string]struct{}) {
+	roleIds := make(map[string]struct{})
+	channelIds := make(map[string]struct{})
+
+	collect := func(text string) {
+		for _, match := range roleMentionRegex.FindAllStringSubmatch(text, -1) {
+			roleIds[match[1]] = struct{}{}
+		}
+
+		for _, match := range channelMentionRegex.FindAllStringSubmatch(text, -1) {
+			channelIds[match[1]] = struct{}{}
+		}
+	}
+
+	for _, msg := range p.Messages {
+		collect(msg.Content)
+
+		for _, e := range msg.Embeds {
+			for _, text := range embedText(e) {
+				collect(text)
+			}
+		}
+	}
+
+	return roleIds, channelIds
+}
+
+// embedText returns the fields of an embed that Discord resolves mentions in
+func embedText(e embed.Embed) []string {
+	text := []string{e.Title, e.Description}
+
+	for _, field := range e.Fields {
+		if field != nil {
+			text = append(text, field.Name, field.Value)
+		}
+	}
+
+	if e.Footer != nil {
+		text = append(text, e.Footer.Text)
+	}
+
+	if e.Author != nil {
+		text = append(text, e.Author.Name)
+	}
+
+	return text
+}
@@ -0,0 +1,75 @@
+package chatreplica
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/rxdn/gdl/objects/channel"
+	"github.com/rxdn/gdl/objects/channel/embed"
+	"github.com/rxdn/gdl/objects/guild"
+	"github.com/stretchr/testify/assert"
+)
+
+type testResolver struct {
+	roles    []guild.Role
+	channels []channel.Channel
+	err      error
+	calls    int
+}
+
+func (r *testResolver) GetGuildRoles(context.Context, uint64) ([]guild.Role, error) {
+	r.calls++
+	return r.roles, r.err
+}
+
+func (r *testResolver) GetGuildChannels(context.Context, uint64) ([]channel.Channel, error) {
+	r.calls++
+	return r.channels, r.err
+}
+
+func TestResolveEntities(t *testing.T) {
+	payload := Payload{
+		Entities: Entities{
+			Roles: map[string]Role{"423456789012345678": {Name: "Deleted role"}},
+		},
+		Messages: []Message{
+			{Content: "<@&223456789012345678> <@&423456789012345678>"},
+			{Embeds: []embed.Embed{{Fields: []*embed.EmbedField{{Name: "Channel", Value: "<#323456789012345678>"}}}}},
+		},
+	}
+
+	resolver := &testResolver{
+		roles: []guild.Role{
+			{Id: 223456789012345678, Name: "Support", Color: 0xff0000},
+			{Id: 523456789012345678, Name: "Unmentioned"},
+		},
+		channels: []channel.Channel{{Id: 323456789012345678, Name: "general"}},
+	}
+
+	assert.NoError(t, payload.ResolveEntities(context.Background(), resolver, 1))
+	assert.Equal(t, map[string]Role{
+		"223456789012345678": {Name: "Support", Color: 0xff0000},
+		"423456789012345678": {Name: "Deleted role"},
+	}, payload.Entities.Roles)
+	assert.Equal(t, map[string]Channel{"323456789012345678": {Name: "general"}}, payload.Entities.Channels)
+}
+
+func TestResolveEntitiesWithoutMentions(t *testing.T) {
+	payload := Payload{Messages: []Message{{Content: "no mentions here"}}}
+	resolver := &testResolver{}
+
+	assert.NoError(t, payload.ResolveEntities(context.Background(), resolver, 1))
+	assert.Zero(t, resolver.calls)
+	assert.Empty(t, payload.Entities.Roles)
+}
+
+func TestResolveEntitiesKeepsArchivedDataOnError(t *testing.T) {
+	payload := Payload{
+		Entities: Entities{Channels: map[string]Channel{"323456789012345678": {Name: "archived"}}},
+		Messages: []Message{{Content: "<#323456789012345678>"}},
+	}
+
+	assert.Error(t, payload.ResolveEntities(context.Background(), &testResolver{err: errors.New("unavailable")}, 1))
+	assert.Equal(t, "archived", payload.Entities.Channels["323456789012345678"].Name)
+}
@@ -0,0 +1,353 @@
+package chatreplica
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/rxdn/gdl/objects/channel/embed"
+	"github.com/rxdn/gdl/objects/channel/message"
+)
+
+type Format string
+
+const (
+	FormatText     Format = "txt"
+	FormatMarkdown Format = "md"
+	FormatJson     Format = "json"
+	FormatHtml     Format = "html"
+)
+
+const exportTimeFormat = "2006-01-02 15:04:05 MST"
+
+var (
+	userMentionRegex    = regexp.MustCompile(`<@!?(\d{16,20})>`)
+	roleMentionRegex    = regexp.MustCompile(`<@&(\d{16,20})>`)
+	channelMentionRegex = regexp.MustCompile(`<#(\d{16,20})>`)
+)
+
+func (f Format) IsValid() bool {
+	return f == FormatText || f == FormatMarkdown || f == FormatJson || f == FormatHtml
+}
+
+func (f Format) ContentType() string {
+	switch f {
+	case FormatText:
+		return "text/plain; charset=utf-8"
+	case FormatMarkdown:
+		return "text/markdown; charset=utf-8"
+	case FormatJson:
+		return "application/json"
+	case FormatHtml:
+		return "text/html; charset=utf-8"
+	default:
+		return "application/octet-stream"
+	}
+}
+
+// Export produces a standalone file containing the transcript, which does not depend on the render service
+func Export(payload Payload, format Format) ([]byte, error) {
+	switch format {
+	case FormatText:
+		return exportText(payload), nil
+	case FormatMarkdown:
+		return exportMarkdown(payload), nil
+	case FormatJson:
+		return json.Marshal(normalise(payload))
+	case FormatHtml:
+		return RenderNative(payload)
+	default:
+		return nil, fmt.Errorf("unknown export format: %s", format)
+	}
+}
+
+type (
+	exportedTranscript struct {
+		ChannelName string            `json:"channel_name"`
+		Messages    []exportedMessage `json:"messages"`
+	}
+
+	exportedMessage struct {
+		Id          uint64               `json:"id,string"`
+		Author      exportedUser         `json:"author"`
+		Timestamp   time.Time            `json:"timestamp"`
+		Content     string               `json:"content"`
+		Embeds      []embed.Embed        `json:"embeds"`
+		Attachments []exportedAttachment `json:"attachments"`
+		Type        message.MessageType  `json:"type"`
+		ReplyTo     *exportedReply       `json:"reply_to,omitempty"`
+		Stickers    []Sticker            `json:"stickers,omitempty"`
+		EditedAt    *time.Time           `json:"edited_at,omitempty"`
+
+		// SystemContent is shown in place of the content for system messages
+		SystemContent string `json:"system_content,omitempty"`
+
+		// ResolvedContent has mentions replaced with the names of the entities, for the plain text formats
+		ResolvedContent string `json:"-"`
+	}
+
+	// exportedReply is the message being replied to. Username is empty if the message is not in the transcript, e.g.
+	// because it was deleted.
+	exportedReply struct {
+		MessageId uint64 `json:"message_id,string"`
+		Username  string `json:"username,omitempty"`
+		Content   string `json:"-"`
+	}
+
+	exportedUser struct {
+		Id       uint64 `json:"id,string"`
+		Username string `json:"username"`
+		Avatar   string `json:"avatar"`
+		Bot      bool   `json:"bot"`
+	}
+
+	exportedAttachment struct {
+		Filename string `json:"filename"`
+		Url      string `json:"url"`
+		Size     int    `json:"size"`
+	}
+)
+
+func normalise(payload Payload) exportedTranscript {
+	messages := make([]exportedMessage, len(payload.Messages))
+	indexes := make(map[uint64]int, len(payload.Messages)) // Message ID -> index, for resolving replies
+	for i, msg := range payload.Messages {
+		author := exportedUser{
+			Id:       msg.Author,
+			Username: fmt.Sprintf("Unknown User (%d)", msg.Author),
+		}
+
+		if user, ok := payload.Entities.Users[strconv.FormatUint(msg.Author, 10)]; ok {
+			author.Username = user.Username
+			author.Avatar = user.Avatar
+			author.Bot = user.Badge != nil && *user.Badge == BadgeBot
+		}
+
+		attachments := make([]exportedAttachment, len(msg.Attachments))
+		for j, attachment := range msg.Attachments {
+			attachments[j] = exportedAttachment{
+				Filename: attachment.Filename,
+				Url:      attachment.Url,
+				Size:     attachment.Size,
+			}
+		}
+
+		embeds := msg.Embeds
+		if embeds == nil {
+			embeds = make([]embed.Embed, 0)
+		}
+
+		resolvedContent := resolveMentions(msg.Content, payload.Entities)
+		if msg.SystemContent != "" {
+			resolvedContent = msg.SystemContent
+		}
+
+		messages[i] = exportedMessage{
+			Id:              msg.Id,
+			Author:          author,
+			Timestamp:       time.UnixMilli(msg.Time).UTC(),
+			Content:         msg.Content,
+			Embeds:          embeds,
+			Attachments:     attachments,
+			Type:            msg.Type,
+			Stickers:        msg.Stickers,
+			SystemContent:   msg.SystemContent,
+			ResolvedContent: resolvedContent,
+		}
+
+		if msg.EditedTime != nil {
+			edited := time.UnixMilli(*msg.EditedTime).UTC()
+			messages[i].EditedAt = &edited
+		}
+
+		// Replies always come after the message they reply to
+		if msg.Reference != nil {
+			reply := &exportedReply{MessageId: msg.Reference.MessageId}
+			if index, ok := indexes[msg.Reference.MessageId]; ok {
+				reply.Username = messages[index].Author.Username
+				reply.Content = messages[index].ResolvedContent
+			}
+
+			messages[i].ReplyTo = reply
+		}
+
+		indexes[msg.Id] = i
+	}
+
+	return exportedTranscript{
+		ChannelName: payload.ChannelName,
+		Messages:    messages,
+	}
+}
+
+func exportText(payload Payload) []byte {
+	transcript := normalise(payload)
+
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "#%s\n\n", transcript.ChannelName)
+
+	for _, msg := range transcript.Messages {
+		fmt.Fprintf(&buf, "[%s] %s%s: %s%s\n", formatTime(msg.Timestamp), msg.Author.Username, replySuffix(msg), msg.ResolvedContent, editedSuffix(msg))
+
+		for _, e := range msg.Embeds {
+			buf.WriteString("    [Embed]\n")
+			for _, line := range embedLines(e, payload.Entities, false) {
+				fmt.Fprintf(&buf, "    %s\n", line)
+			}
+		}
+
+		for _, attachment := range msg.Attachments {
+			fmt.Fprintf(&buf, "    [Attachment] %s (%s)\n", attachment.Filename, attachment.Url)
+		}
+
+		for _, sticker := range msg.Stickers {
+			fmt.Fprintf(&buf, "    [Sticker] %s (%s)\n", sticker.Name, sticker.Url)
+		}
+	}
+
+	return buf.Bytes()
+}
+
+func exportMarkdown(payload Payload) []byte {
+	transcript := normalise(payload)
+
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "# #%s\n", transcript.ChannelName)
+
+	for _, msg := range transcript.Messages {
+		fmt.Fprintf(&buf, "\n**%s** — %s%s\n\n", msg.Author.Username, formatTime(msg.Timestamp), editedSuffix(msg))
+
+		if msg.ReplyTo != nil {
+			fmt.Fprintf(&buf, "> Replying to %s\n\n", replyTarget(*msg.ReplyTo, true))
+		}
+
+		if msg.ResolvedContent != "" {
+			buf.WriteString(msg.ResolvedContent)
+			buf.WriteString("\n")
+		}
+
+		for _, e := range msg.Embeds {
+			buf.WriteString("\n")
+			for _, line := range embedLines(e, payload.Entities, true) {
+				fmt.Fprintf(&buf, "> %s\n", line)
+			}
+		}
+
+		if len(msg.Attachments) > 0 {
+			buf.WriteString("\n")
+			for _, attachment := range msg.Attachments {
+				fmt.Fprintf(&buf, "- [%s](%s)\n", attachment.Filename, attachment.Url)
+			}
+		}
+
+		if len(msg.Stickers) > 0 {
+			buf.WriteString("\n")
+			for _, sticker := range msg.Stickers {
+				fmt.Fprintf(&buf, "- Sticker: [%s](%s)\n", sticker.Name, sticker.Url)
+			}
+		}
+	}
+
+	return buf.Bytes()
+}
+
+func replySuffix(msg exportedMessage) string {
+	if msg.ReplyTo == nil {
+		return ""
+	}
+
+	return fmt.Sprintf(" (replying to %s)", replyTarget(*msg.ReplyTo, false))
+}
+
+func replyTarget(reply exportedReply, markdown bool) string {
+	if reply.Username == "" {
+		return "a deleted message"
+	}
+
+	if markdown {
+		return fmt.Sprintf("**%s**", reply.Username)
+	}
+
+	return reply.Username
+}
+
+func editedSuffix(msg exportedMessage) string {
+	if msg.EditedAt == nil {
+		return ""
+	}
+
+	return " (edited)"
+}
+
+func embedLines(e embed.Embed, entities Entities, markdown bool) []string {
+	var lines []string
+
+	if e.Author != nil && e.Author.Name != "" {
+		lines = append(lines, e.Author.Name)
+	}
+
+	if e.Title != "" {
+		if markdown {
+			lines = append(lines, fmt.Sprintf("**%s**", e.Title))
+		} else {
+			lines = append(lines, e.Title)
+		}
+	}
+
+	if e.Description != "" {
+		lines = append(lines, strings.Split(resolveMentions(e.Description, entities), "\n")...)
+	}
+
+	for _, field := range e.Fields {
+		if markdown {
+			lines = append(lines, fmt.Sprintf("**%s**: %s", field.Name, resolveMentions(field.Value, entities)))
+		} else {
+			lines = append(lines, fmt.Sprintf("%s: %s", field.Name, resolveMentions(field.Value, entities)))
+		}
+	}
+
+	if e.Footer != nil && e.Footer.Text != "" {
+		lines = append(lines, e.Footer.Text)
+	}
+
+	return lines
+}
+
+// resolveMentions replaces user, role and channel mentions with the names of the entities
+func resolveMentions(content string, entities Entities) string {
+	content = userMentionRegex.ReplaceAllStringFunc(content, func(match string) string {
+		if user, ok := entities.Users[userMentionRegex.FindStringSubmatch(match)[1]]; ok {
+			return "@" + user.Username
+		}
+
+		return match
+	})
+
+	content = roleMentionRegex.ReplaceAllStringFunc(content, func(match string) string {
+		if role, ok := entities.Roles[roleMentionRegex.FindStringSubmatch(match)[1]]; ok {
+			return "@" + role.Name
+		}
+
+		return match
+	})
+
+	return channelMentionRegex.ReplaceAllStringFunc(content, func(match string) string {
+		if channel, ok := entities.Channels[channelMentionRegex.FindStringSubmatch(match)[1]]; ok {
+			return "#" + channel.Name
+		}
+
+		return match
+	})
+}
+
+func formatTime(t time.Time) string {
+	return t.UTC().Format(exportTimeFormat)
+}
+
+func formatColour(colour int) string {
+	return fmt.Sprintf("#%06x", colour)
+}
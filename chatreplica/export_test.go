@@ -0,0 +1,145 @@
+package chatreplica
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/rxdn/gdl/objects/channel"
+	"github.com/rxdn/gdl/objects/channel/message"
+	"github.com/stretchr/testify/assert"
+)
+
+func testPayload() Payload {
+	return Payload{
+		Entities: Entities{
+			Users: map[string]User{
+				"123456789012345678": {Username: "alice"},
+			},
+			Channels: map[string]Channel{
+				"223456789012345678": {Name: "general"},
+			},
+			Roles: map[string]Role{
+				"323456789012345678": {Name: "Support"},
+			},
+		},
+		Messages: []Message{
+			{
+				Id:      1,
+				Author:  123456789012345678,
+				Time:    time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC).UnixMilli(),
+				Content: "Hi <@123456789012345678>, see <#223456789012345678> or ask <@&323456789012345678> <script>",
+				Attachments: []channel.Attachment{
+					{Filename: "log.txt", Url: "https://example.com/log.txt"},
+				},
+			},
+		},
+		ChannelName: "ticket-1",
+	}
+}
+
+func TestExportText(t *testing.T) {
+	exported, err := Export(testPayload(), FormatText)
+	assert.NoError(t, err)
+
+	text := string(exported)
+	assert.Contains(t, text, "[2024-01-01 12:00:00 UTC] alice: Hi @alice, see #general or ask @Support <script>")
+	assert.Contains(t, text, "[Attachment] log.txt (https://example.com/log.txt)")
+}
+
+func TestExportJson(t *testing.T) {
+	exported, err := Export(testPayload(), FormatJson)
+	assert.NoError(t, err)
+
+	var transcript exportedTranscript
+	assert.NoError(t, json.Unmarshal(exported, &transcript))
+	assert.Equal(t, "alice", transcript.Messages[0].Author.Username)
+	assert.Len(t, transcript.Messages[0].Attachments, 1)
+}
+
+func TestExportHtmlEscapesContent(t *testing.T) {
+	exported, err := Export(testPayload(), FormatHtml)
+	assert.NoError(t, err)
+
+	html := string(exported)
+	assert.False(t, strings.Contains(html, "<script>"))
+	assert.Contains(t, html, "&lt;script&gt;")
+}
+
+func testMessageTypesPayload() Payload {
+	payload := testPayload()
+	edited := time.Date(2024, 1, 1, 12, 5, 0, 0, time.UTC).UnixMilli()
+
+	payload.Messages = append(payload.Messages,
+		Message{
+			Id:         2,
+			Type:       message.MessageTypeReply,
+			Author:     123456789012345678,
+			Time:       time.Date(2024, 1, 1, 12, 1, 0, 0, time.UTC).UnixMilli(),
+			Content:    "agreed",
+			Reference:  &Reference{MessageId: 1},
+			EditedTime: &edited,
+		},
+		Message{
+			Id:       3,
+			Author:   123456789012345678,
+			Time:     time.Date(2024, 1, 1, 12, 2, 0, 0, time.UTC).UnixMilli(),
+			Stickers: []Sticker{{Id: 10, Name: "wave", FormatType: StickerFormatTypePng, Url: "https://media.discordapp.net/stickers/10.png"}},
+		},
+		Message{
+			Id:            4,
+			Type:          message.MessageTypeChannelPinnedMessage,
+			Author:        123456789012345678,
+			Time:          time.Date(2024, 1, 1, 12, 3, 0, 0, time.UTC).UnixMilli(),
+			SystemContent: "alice pinned a message to this channel.",
+		},
+		Message{
+			Id:        5,
+			Type:      message.MessageTypeReply,
+			Author:    123456789012345678,
+			Time:      time.Date(2024, 1, 1, 12, 4, 0, 0, time.UTC).UnixMilli(),
+			Content:   "what?",
+			Reference: &Reference{MessageId: 100},
+		},
+	)
+
+	return payload
+}
+
+func TestExportTextMessageTypes(t *testing.T) {
+	exported, err := Export(testMessageTypesPayload(), FormatText)
+	assert.NoError(t, err)
+
+	text := string(exported)
+	assert.Contains(t, text, "alice (replying to alice): agreed (edited)")
+	assert.Contains(t, text, "[Sticker] wave (https://media.discordapp.net/stickers/10.png)")
+	assert.Contains(t, text, "alice: alice pinned a message to this channel.")
+	assert.Contains(t, text, "alice (replying to a deleted message): what?")
+}
+
+func TestExportJsonMessageTypes(t *testing.T) {
+	exported, err := Export(testMessageTypesPayload(), FormatJson)
+	assert.NoError(t, err)
+
+	var transcript exportedTranscript
+	assert.NoError(t, json.Unmarshal(exported, &transcript))
+
+	reply := transcript.Messages[1]
+	assert.Equal(t, message.MessageTypeReply, reply.Type)
+	assert.Equal(t, &exportedReply{MessageId: 1, Username: "alice"}, reply.ReplyTo)
+	assert.NotNil(t, reply.EditedAt)
+	assert.Len(t, transcript.Messages[2].Stickers, 1)
+}
+
+func TestExportHtmlMessageTypes(t *testing.T) {
+	exported, err := Export(testMessageTypesPayload(), FormatHtml)
+	assert.NoError(t, err)
+
+	html := string(exported)
+	assert.Contains(t, html, `<span class="reply-username">alice</span>`)
+	assert.Contains(t, html, "(edited)")
+	assert.Contains(t, html, `<img class="sticker" src="https://media.discordapp.net/stickers/10.png"`)
+	assert.Contains(t, html, `<div class="content system">alice pinned a message to this channel.</div>`)
+	assert.Contains(t, html, "Original message was deleted")
+}
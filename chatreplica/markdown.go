@@ -0,0 +1,142 @@
+package chatreplica
+
+import (
+	"fmt"
+	"html"
+	"html/template"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	codeBlockRegex  = regexp.MustCompile("(?s)```(?:[\\w+-]*\\n)?(.*?)```")
+	inlineCodeRegex = regexp.MustCompile("`([^`\\n]+)`")
+
+	// The remaining patterns are matched against escaped content
+	maskedLinkRegex            = regexp.MustCompile(`\[([^\[\]\n]+)]\((https?://[^\s)]+)\)`)
+	urlRegex                   = regexp.MustCompile(`https?://(?:[^\s<&]|&amp;)*(?:[^\s<&.,:;!?)\]]|&amp;)`)
+	customEmojiRegex           = regexp.MustCompile(`&lt;(a?):(\w+):(\d{16,20})&gt;`)
+	timestampRegex             = regexp.MustCompile(`&lt;t:(-?\d{1,13})(?::[tTdDfFR])?&gt;`)
+	escapedUserMentionRegex    = regexp.MustCompile(`&lt;@!?(\d{16,20})&gt;`)
+	escapedRoleMentionRegex    = regexp.MustCompile(`&lt;@&amp;(\d{16,20})&gt;`)
+	escapedChannelMentionRegex = regexp.MustCompile(`&lt;#(\d{16,20})&gt;`)
+
+	boldRegex      = regexp.MustCompile(`\*\*(.+?)\*\*`)
+	underlineRegex = regexp.MustCompile(`__(.+?)__`)
+	italicRegex    = regexp.MustCompile(`\*([^*\n]+?)\*|\b_([^_\n]+?)_\b`)
+	strikeRegex    = regexp.MustCompile(`~~(.+?)~~`)
+	spoilerRegex   = regexp.MustCompile(`\|\|(.+?)\|\|`)
+	headingRegex   = regexp.MustCompile(`(?m)^(#{1,3}) (.+)$`)
+	quoteRegex     = regexp.MustCompile(`(?m)^&gt; (.*)$`)
+)
+
+// renderMarkdown converts Discord flavoured markdown into HTML. All content is escaped before any tags are added.
+func renderMarkdown(content string, entities Entities) template.HTML {
+	// Code is extracted first, so that its content is not formatted, and is restored at the end. Generated links are
+	// stored the same way, so that URLs are not matched again.
+	var tokens []string
+	store := func(rendered string) string {
+		tokens = append(tokens, rendered)
+		return fmt.Sprintf("\x00%d\x00", len(tokens)-1)
+	}
+
+	content = codeBlockRegex.ReplaceAllStringFunc(content, func(match string) string {
+		code := codeBlockRegex.FindStringSubmatch(match)[1]
+		return store(fmt.Sprintf(`<pre class="code-block"><code>%s</code></pre>`, html.EscapeString(code)))
+	})
+
+	content = inlineCodeRegex.ReplaceAllStringFunc(content, func(match string) string {
+		code := inlineCodeRegex.FindStringSubmatch(match)[1]
+		return store(fmt.Sprintf(`<code class="inline-code">%s</code>`, html.EscapeString(code)))
+	})
+
+	content = html.EscapeString(content)
+
+	content = maskedLinkRegex.ReplaceAllStringFunc(content, func(match string) string {
+		groups := maskedLinkRegex.FindStringSubmatch(match)
+		return store(fmt.Sprintf(`<a href="%s" target="_blank" rel="noopener noreferrer">%s</a>`, groups[2], groups[1]))
+	})
+
+	content = urlRegex.ReplaceAllStringFunc(content, func(match string) string {
+		return store(fmt.Sprintf(`<a href="%s" target="_blank" rel="noopener noreferrer">%s</a>`, match, match))
+	})
+
+	content = customEmojiRegex.ReplaceAllStringFunc(content, func(match string) string {
+		groups := customEmojiRegex.FindStringSubmatch(match)
+
+		extension := "png"
+		if groups[1] == "a" {
+			extension = "gif"
+		}
+
+		return store(fmt.Sprintf(
+			`<img class="emoji" src="https://cdn.discordapp.com/emojis/%s.%s" alt=":%s:" title=":%s:">`,
+			groups[3], extension, groups[2], groups[2],
+		))
+	})
+
+	content = timestampRegex.ReplaceAllStringFunc(content, func(match string) string {
+		seconds, err := strconv.ParseInt(timestampRegex.FindStringSubmatch(match)[1], 10, 64)
+		if err != nil {
+			return match
+		}
+
+		return fmt.Sprintf(`<span class="timestamp-mention">%s</span>`, formatTime(time.Unix(seconds, 0)))
+	})
+
+	content = escapedUserMentionRegex.ReplaceAllStringFunc(content, func(match string) string {
+		name := "Unknown User"
+		if user, ok := entities.Users[escapedUserMentionRegex.FindStringSubmatch(match)[1]]; ok {
+			name = user.Username
+		}
+
+		return fmt.Sprintf(`<span class="mention">@%s</span>`, html.EscapeString(name))
+	})
+
+	content = escapedRoleMentionRegex.ReplaceAllStringFunc(content, func(match string) string {
+		role, ok := entities.Roles[escapedRoleMentionRegex.FindStringSubmatch(match)[1]]
+		if !ok {
+			return `<span class="mention">@Unknown Role</span>`
+		}
+
+		if role.Color == 0 {
+			return fmt.Sprintf(`<span class="mention">@%s</span>`, html.EscapeString(role.Name))
+		}
+
+		return fmt.Sprintf(
+			`<span class="mention" style="color: %s">@%s</span>`,
+			formatColour(role.Color), html.EscapeString(role.Name),
+		)
+	})
+
+	content = escapedChannelMentionRegex.ReplaceAllStringFunc(content, func(match string) string {
+		name := "unknown-channel"
+		if channel, ok := entities.Channels[escapedChannelMentionRegex.FindStringSubmatch(match)[1]]; ok {
+			name = channel.Name
+		}
+
+		return fmt.Sprintf(`<span class="mention">#%s</span>`, html.EscapeString(name))
+	})
+
+	content = boldRegex.ReplaceAllString(content, "<strong>$1</strong>")
+	content = underlineRegex.ReplaceAllString(content, "<u>$1</u>")
+	content = italicRegex.ReplaceAllString(content, "<em>$1$2</em>")
+	content = strikeRegex.ReplaceAllString(content, "<s>$1</s>")
+	content = spoilerRegex.ReplaceAllString(content, `<span class="spoiler">$1</span>`)
+
+	content = headingRegex.ReplaceAllStringFunc(content, func(match string) string {
+		groups := headingRegex.FindStringSubmatch(match)
+		return fmt.Sprintf("<h%d>%s</h%d>", len(groups[1])+2, groups[2], len(groups[1])+2)
+	})
+
+	content = quoteRegex.ReplaceAllString(content, `<span class="quote">$1</span>`)
+
+	// Tokens may contain tokens stored before them, so must be restored in reverse
+	for i := len(tokens) - 1; i >= 0; i-- {
+		content = strings.Replace(content, fmt.Sprintf("\x00%d\x00", i), tokens[i], 1)
+	}
+
+	return template.HTML(content)
+}
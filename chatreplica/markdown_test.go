@@ -0,0 +1,37 @@
+package chatreplica
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMarkdownEscapes(t *testing.T) {
+	assert.Equal(t, "&lt;b&gt;hi&lt;/b&gt;", string(renderMarkdown("<b>hi</b>", Entities{})))
+}
+
+func TestMarkdownFormatting(t *testing.T) {
+	rendered := string(renderMarkdown("**bold** *italic* __under__ ~~strike~~ ||secret||", Entities{}))
+	assert.Equal(t, `<strong>bold</strong> <em>italic</em> <u>under</u> <s>strike</s> <span class="spoiler">secret</span>`, rendered)
+}
+
+func TestMarkdownCodeIsNotFormatted(t *testing.T) {
+	rendered := string(renderMarkdown("`**x**` ```\n<a> **y**```", Entities{}))
+	assert.Equal(t, `<code class="inline-code">**x**</code> <pre class="code-block"><code>&lt;a&gt; **y**</code></pre>`, rendered)
+}
+
+func TestMarkdownLinks(t *testing.T) {
+	rendered := string(renderMarkdown("see https://example.com/a?b=1&c=2. or [docs](https://example.com/docs)", Entities{}))
+	assert.Equal(t, `see <a href="https://example.com/a?b=1&amp;c=2" target="_blank" rel="noopener noreferrer">https://example.com/a?b=1&amp;c=2</a>. or <a href="https://example.com/docs" target="_blank" rel="noopener noreferrer">docs</a>`, rendered)
+}
+
+func TestMarkdownMentions(t *testing.T) {
+	entities := Entities{
+		Users:    map[string]User{"123456789012345678": {Username: "<alice>"}},
+		Roles:    map[string]Role{"223456789012345678": {Name: "Support", Color: 0xff0000}},
+		Channels: map[string]Channel{},
+	}
+
+	rendered := string(renderMarkdown("<@123456789012345678> <@&223456789012345678> <#323456789012345678>", entities))
+	assert.Equal(t, `<span class="mention">@&lt;alice&gt;</span> <span class="mention" style="color: #ff0000">@Support</span> <span class="mention">#unknown-channel</span>`, rendered)
+}
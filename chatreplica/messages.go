@@ -0,0 +1,175 @@
+package chatreplica
+
+import (
+	"fmt"
+
+	v2 "github.com/TicketsBot/logarchiver/pkg/model/v2"
+	"github.com/rxdn/gdl/objects/channel/message"
+)
+
+type (
+	StickerItem struct {
+		Id         uint64            `json:"id,string"`
+		Name       string            `json:"name"`
+		FormatType StickerFormatType `json:"format_type"`
+	}
+
+	Sticker struct {
+		Id         uint64            `json:"id,string"`
+		Name       string            `json:"name"`
+		FormatType StickerFormatType `json:"format_type"`
+		Url        string            `json:"url"`
+	}
+
+	// Reference points to the message being replied to, or the message that a thread was started from
+	Reference struct {
+		MessageId uint64 `json:"message_id,string"`
+	}
+)
+
+type StickerFormatType int
+
+const (
+	StickerFormatTypePng StickerFormatType = iota + 1
+	StickerFormatTypeApng
+	StickerFormatTypeLottie
+	StickerFormatTypeGif
+)
+
+func (s StickerItem) Url() string {
+	var extension string
+	switch s.FormatType {
+	case StickerFormatTypeLottie:
+		extension = "json"
+	case StickerFormatTypeGif:
+		extension = "gif"
+	default:
+		extension = "png"
+	}
+
+	return fmt.Sprintf("https://media.discordapp.net/stickers/%d.%s", s.Id, extension)
+}
+
+// IsImage returns false for Lottie stickers, which are animations that browsers can't display without a player
+func (s Sticker) IsImage() bool {
+	return s.FormatType != StickerFormatTypeLottie
+}
+
+func messageFromTranscript(msg TranscriptMessage, users map[uint64]v2.User) Message {
+	wrapped := Message{
+		Id:            msg.Id,
+		Type:          msg.Type,
+		Author:        msg.AuthorId,
+		Time:          msg.Timestamp.UnixMilli(),
+		Content:       msg.Content,
+		Embeds:        msg.Embeds,
+		Attachments:   msg.Attachments,
+		SystemContent: systemContent(msg, users),
+	}
+
+	if msg.EditedTimestamp != nil {
+		edited := msg.EditedTimestamp.UnixMilli()
+		wrapped.EditedTime = &edited
+	}
+
+	if (msg.Type == message.MessageTypeReply || msg.Type == message.MessageTypeThreadStarterMessage) &&
+		msg.Reference != nil && msg.Reference.MessageId != 0 {
+		wrapped.Reference = &Reference{MessageId: msg.Reference.MessageId}
+	}
+
+	for _, sticker := range msg.StickerItems {
+		wrapped.Stickers = append(wrapped.Stickers, Sticker{
+			Id:         sticker.Id,
+			Name:       sticker.Name,
+			FormatType: sticker.FormatType,
+			Url:        sticker.Url(),
+		})
+	}
+
+	return wrapped
+}
+
+// systemContent returns the text that Discord shows for system messages, or an empty string for messages that are
+// displayed using their content
+func systemContent(msg TranscriptMessage, users map[uint64]v2.User) string {
+	author := username(users, msg.AuthorId)
+
+	switch msg.Type {
+	case message.MessageTypeRecipientAdd:
+		return fmt.Sprintf("%s added %s to the thread.", author, mentionedUsername(msg))
+	case message.MessageTypeRecipientRemove:
+		return fmt.Sprintf("%s removed %s from the thread.", author, mentionedUsername(msg))
+	case message.MessageTypeCall:
+		return fmt.Sprintf("%s started a call.", author)
+	case message.MessageTypeChannelNameChange:
+		return fmt.Sprintf("%s changed the channel name: %s", author, msg.Content)
+	case message.MessageTypeChannelIconChange:
+		return fmt.Sprintf("%s changed the channel icon.", author)
+	case message.MessageTypeChannelPinnedMessage:
+		return fmt.Sprintf("%s pinned a message to this channel.", author)
+	case message.MessageTypeGuildMemberJoin:
+		return fmt.Sprintf("%s joined the server.", author)
+	case message.MessageTypeUserPremiumGuildSubscription:
+		return fmt.Sprintf("%s just boosted the server!", author)
+	case message.MessageTypeUserPremiumGuildSubscriptionTier1:
+		return fmt.Sprintf("%s just boosted the server! The server has achieved Level 1!", author)
+	case message.MessageTypeUserPremiumGuildSubscriptionTier2:
+		return fmt.Sprintf("%s just boosted the server! The server has achieved Level 2!", author)
+	case message.MessageTypeUserPremiumGuildSubscriptionTier3:
+		return fmt.Sprintf("%s just boosted the server! The server has achieved Level 3!", author)
+	case message.MessageTypeChannelFollowAdd:
+		return fmt.Sprintf("%s has added %s to this channel.", author, msg.Content)
+	case message.MessageTypeGuildDiscoveryDisqualified:
+		return "This server has been removed from Server Discovery."
+	case message.MessageTypeGuildDiscoveryRequalified:
+		return "This server is eligible for Server Discovery again."
+	case message.MessageTypeGuildDiscoveryGracePeriodInitialWarning:
+		return "This server has failed Discovery activity requirements for 1 week."
+	case message.MessageTypeGuildDiscoveryGracePeriodFinalWarning:
+		return "This server has failed Discovery activity requirements for 3 weeks in a row."
+	case message.MessageTypeThreadCreated:
+		return fmt.Sprintf("%s started a thread: %s", author, msg.Content)
+	case message.MessageTypeThreadStarterMessage:
+		return "Thread starter message"
+	case message.MessageTypeGuildInviteReminder:
+		return "Invite your friends to this server."
+	default:
+		return ""
+	}
+}
+
+func username(users map[uint64]v2.User, userId uint64) string {
+	if user, ok := users[userId]; ok {
+		return user.Username
+	}
+
+	return "Unknown User"
+}
+
+func mentionedUsername(msg TranscriptMessage) string {
+	if len(msg.Mentions) == 0 {
+		return "Unknown User"
+	}
+
+	return msg.Mentions[0].Username
+}
+
+// hasDisplayableContent returns false for messages that the render service can't display, and would return a 400 for
+func (m Message) hasDisplayableContent() bool {
+	return m.Content != "" || len(m.Embeds) > 0 || len(m.Attachments) > 0
+}
+
+// forRenderService returns a copy of the payload without the messages that the render service can't display, such as
+// sticker-only and system messages
+func (p Payload) forRenderService() Payload {
+	// If all 3 are missing, server will 400
+	messages := make([]Message, 0, len(p.Messages))
+	for _, msg := range p.Messages {
+		if msg.hasDisplayableContent() {
+			messages = append(messages, msg)
+		}
+	}
+
+	p.Messages = messages
+	return p
+}
@@ -0,0 +1,153 @@
+package chatreplica
+
+import (
+	"testing"
+	"time"
+
+	v2 "github.com/TicketsBot/logarchiver/pkg/model/v2"
+	"github.com/rxdn/gdl/objects/channel/message"
+	"github.com/stretchr/testify/assert"
+)
+
+var (
+	testMessageTime = time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	testUsers       = map[uint64]v2.User{2: {Id: 2, Username: "alice"}}
+)
+
+func testTranscriptMessage(messageType message.MessageType, content string) TranscriptMessage {
+	return TranscriptMessage{
+		Message: v2.Message{
+			Id:        1,
+			AuthorId:  2,
+			Timestamp: testMessageTime,
+			Content:   content,
+		},
+		Type: messageType,
+	}
+}
+
+func TestConvertDefaultMessage(t *testing.T) {
+	msg := messageFromTranscript(testTranscriptMessage(message.MessageTypeDefault, "hello"), testUsers)
+	assert.Equal(t, Message{
+		Id:      1,
+		Type:    message.MessageTypeDefault,
+		Author:  2,
+		Time:    testMessageTime.UnixMilli(),
+		Content: "hello",
+	}, msg)
+}
+
+func TestConvertReply(t *testing.T) {
+	transcriptMessage := testTranscriptMessage(message.MessageTypeReply, "agreed")
+	transcriptMessage.Reference = &message.MessageReference{MessageId: 100}
+
+	msg := messageFromTranscript(transcriptMessage, testUsers)
+	assert.Equal(t, message.MessageTypeReply, msg.Type)
+	assert.Equal(t, &Reference{MessageId: 100}, msg.Reference)
+	assert.Empty(t, msg.SystemContent)
+}
+
+func TestConvertReplyToDeletedMessage(t *testing.T) {
+	msg := messageFromTranscript(testTranscriptMessage(message.MessageTypeReply, "agreed"), testUsers)
+	assert.Nil(t, msg.Reference)
+}
+
+func TestConvertPin(t *testing.T) {
+	msg := messageFromTranscript(testTranscriptMessage(message.MessageTypeChannelPinnedMessage, ""), testUsers)
+	assert.Equal(t, message.MessageTypeChannelPinnedMessage, msg.Type)
+	assert.Equal(t, "alice pinned a message to this channel.", msg.SystemContent)
+}
+
+func TestConvertThreadCreated(t *testing.T) {
+	msg := messageFromTranscript(testTranscriptMessage(message.MessageTypeThreadCreated, "escalation"), testUsers)
+	assert.Equal(t, "alice started a thread: escalation", msg.SystemContent)
+}
+
+func TestConvertThreadStarter(t *testing.T) {
+	transcriptMessage := testTranscriptMessage(message.MessageTypeThreadStarterMessage, "")
+	transcriptMessage.Reference = &message.MessageReference{MessageId: 100}
+
+	msg := messageFromTranscript(transcriptMessage, testUsers)
+	assert.Equal(t, &Reference{MessageId: 100}, msg.Reference)
+	assert.Equal(t, "Thread starter message", msg.SystemContent)
+}
+
+func TestConvertRecipientAdd(t *testing.T) {
+	transcriptMessage := testTranscriptMessage(message.MessageTypeRecipientAdd, "")
+	transcriptMessage.Mentions = []v2.User{{Id: 3, Username: "bob"}}
+
+	msg := messageFromTranscript(transcriptMessage, testUsers)
+	assert.Equal(t, "alice added bob to the thread.", msg.SystemContent)
+}
+
+func TestConvertMemberJoinUnknownUser(t *testing.T) {
+	msg := messageFromTranscript(testTranscriptMessage(message.MessageTypeGuildMemberJoin, ""), nil)
+	assert.Equal(t, "Unknown User joined the server.", msg.SystemContent)
+}
+
+func TestConvertBoost(t *testing.T) {
+	msg := messageFromTranscript(testTranscriptMessage(message.MessageTypeUserPremiumGuildSubscriptionTier2, ""), testUsers)
+	assert.Equal(t, "alice just boosted the server! The server has achieved Level 2!", msg.SystemContent)
+}
+
+func TestConvertApplicationCommand(t *testing.T) {
+	msg := messageFromTranscript(testTranscriptMessage(message.MessageTypeApplicationCommand, "Ticket closed"), testUsers)
+	assert.Equal(t, message.MessageTypeApplicationCommand, msg.Type)
+	assert.Equal(t, "Ticket closed", msg.Content)
+	assert.Empty(t, msg.SystemContent)
+}
+
+func TestConvertSticker(t *testing.T) {
+	transcriptMessage := testTranscriptMessage(message.MessageTypeDefault, "")
+	transcriptMessage.StickerItems = []StickerItem{
+		{Id: 10, Name: "wave", FormatType: StickerFormatTypePng},
+		{Id: 11, Name: "dance", FormatType: StickerFormatTypeLottie},
+	}
+
+	msg := messageFromTranscript(transcriptMessage, testUsers)
+	assert.Equal(t, []Sticker{
+		{Id: 10, Name: "wave", FormatType: StickerFormatTypePng, Url: "https://media.discordapp.net/stickers/10.png"},
+		{Id: 11, Name: "dance", FormatType: StickerFormatTypeLottie, Url: "https://media.discordapp.net/stickers/11.json"},
+	}, msg.Stickers)
+	assert.Empty(t, msg.SystemContent)
+}
+
+func TestConvertEdited(t *testing.T) {
+	edited := testMessageTime.Add(time.Minute)
+
+	transcriptMessage := testTranscriptMessage(message.MessageTypeDefault, "fixed typo")
+	transcriptMessage.EditedTimestamp = &edited
+
+	msg := messageFromTranscript(transcriptMessage, testUsers)
+	assert.Equal(t, edited.UnixMilli(), *msg.EditedTime)
+}
+
+func TestMessagesFromTranscriptDropsEmptyMessages(t *testing.T) {
+	sticker := testTranscriptMessage(message.MessageTypeDefault, "")
+	sticker.StickerItems = []StickerItem{{Id: 10, Name: "wave"}}
+
+	messages := MessagesFromTranscript([]TranscriptMessage{
+		testTranscriptMessage(message.MessageTypeDefault, "hello"),
+		testTranscriptMessage(message.MessageTypeDefault, ""),
+		testTranscriptMessage(message.MessageTypeChannelPinnedMessage, ""),
+		sticker,
+	}, testUsers)
+
+	assert.Len(t, messages, 3)
+	assert.Equal(t, "hello", messages[0].Content)
+	assert.Equal(t, message.MessageTypeChannelPinnedMessage, messages[1].Type)
+	assert.Len(t, messages[2].Stickers, 1)
+}
+
+func TestForRenderServiceDropsUndisplayableMessages(t *testing.T) {
+	payload := Payload{
+		Messages: []Message{
+			{Content: "hello"},
+			{SystemContent: "alice pinned a message to this channel."},
+			{Stickers: []Sticker{{Id: 10, Name: "wave"}}},
+		},
+	}
+
+	assert.Equal(t, []Message{{Content: "hello"}}, payload.forRenderService().Messages)
+	assert.Len(t, payload.Messages, 3)
+}
@@ -0,0 +1,198 @@
+package chatreplica
+
+import (
+	"bytes"
+	_ "embed"
+	"fmt"
+	"html/template"
+	"path"
+	"strings"
+	"time"
+)
+
+type (
+	nativeTranscript struct {
+		ChannelName string
+		Messages    []nativeMessage
+	}
+
+	nativeMessage struct {
+		Author      exportedUser
+		Timestamp   time.Time
+		EditedAt    *time.Time
+		ReplyTo     *nativeReply
+		Content     template.HTML
+		IsSystem    bool
+		Embeds      []nativeEmbed
+		Attachments []nativeAttachment
+		Stickers    []Sticker
+	}
+
+	nativeReply struct {
+		Username string // Empty if the message is not in the transcript
+		Content  string
+	}
+
+	nativeEmbed struct {
+		Colour        string
+		AuthorName    string
+		AuthorIconUrl string
+		Title         string
+		Url           string
+		Description   template.HTML
+		Fields        []nativeEmbedField
+		ImageUrl      string
+		ThumbnailUrl  string
+		Footer        string
+	}
+
+	nativeEmbedField struct {
+		Name   string
+		Value  template.HTML
+		Inline bool
+	}
+
+	nativeAttachment struct {
+		Filename string
+		Url      string
+		Size     string
+		IsImage  bool
+	}
+)
+
+var (
+	//go:embed templates/transcript.html
+	transcriptHtmlTemplate string
+	transcriptTemplate     = template.Must(template.New("transcript").Funcs(template.FuncMap{
+		"formatTime": formatTime,
+	}).Parse(transcriptHtmlTemplate))
+
+	imageExtensions = []string{".png", ".jpg", ".jpeg", ".gif", ".webp"}
+)
+
+// maxReplyPreviewLength is the number of characters of the replied to message that are shown above a reply
+const maxReplyPreviewLength = 100
+
+// RenderNative renders the transcript to a self-contained HTML document, without using the render service
+func RenderNative(payload Payload) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := transcriptTemplate.Execute(&buf, toNative(payload)); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+func toNative(payload Payload) nativeTranscript {
+	normalised := normalise(payload)
+
+	messages := make([]nativeMessage, len(normalised.Messages))
+	for i, msg := range normalised.Messages {
+		embeds := make([]nativeEmbed, len(msg.Embeds))
+		for j, e := range msg.Embeds {
+			embeds[j] = nativeEmbed{
+				Title:       e.Title,
+				Url:         e.Url,
+				Description: renderMarkdown(e.Description, payload.Entities),
+			}
+
+			if e.Color != 0 {
+				embeds[j].Colour = formatColour(e.Color)
+			}
+
+			if e.Author != nil {
+				embeds[j].AuthorName = e.Author.Name
+				embeds[j].AuthorIconUrl = e.Author.IconUrl
+			}
+
+			for _, field := range e.Fields {
+				embeds[j].Fields = append(embeds[j].Fields, nativeEmbedField{
+					Name:   field.Name,
+					Value:  renderMarkdown(field.Value, payload.Entities),
+					Inline: field.Inline,
+				})
+			}
+
+			if e.Image != nil {
+				embeds[j].ImageUrl = e.Image.Url
+			}
+
+			if e.Thumbnail != nil {
+				embeds[j].ThumbnailUrl = e.Thumbnail.Url
+			}
+
+			if e.Footer != nil {
+				embeds[j].Footer = e.Footer.Text
+			}
+		}
+
+		attachments := make([]nativeAttachment, len(msg.Attachments))
+		for j, attachment := range msg.Attachments {
+			attachments[j] = nativeAttachment{
+				Filename: attachment.Filename,
+				Url:      attachment.Url,
+				Size:     formatSize(attachment.Size),
+				IsImage:  isImage(attachment.Filename),
+			}
+		}
+
+		content := renderMarkdown(msg.Content, payload.Entities)
+		if msg.SystemContent != "" {
+			content = template.HTML(template.HTMLEscapeString(msg.SystemContent))
+		}
+
+		messages[i] = nativeMessage{
+			Author:      msg.Author,
+			Timestamp:   msg.Timestamp,
+			EditedAt:    msg.EditedAt,
+			Content:     content,
+			IsSystem:    msg.SystemContent != "",
+			Embeds:      embeds,
+			Attachments: attachments,
+			Stickers:    msg.Stickers,
+		}
+
+		if msg.ReplyTo != nil {
+			messages[i].ReplyTo = &nativeReply{
+				Username: msg.ReplyTo.Username,
+				Content:  truncateRunes(msg.ReplyTo.Content, maxReplyPreviewLength),
+			}
+		}
+	}
+
+	return nativeTranscript{
+		ChannelName: normalised.ChannelName,
+		Messages:    messages,
+	}
+}
+
+func truncateRunes(s string, length int) string {
+	runes := []rune(s)
+	if len(runes) <= length {
+		return s
+	}
+
+	return string(runes[:length]) + "…"
+}
+
+func isImage(filename string) bool {
+	extension := strings.ToLower(path.Ext(filename))
+	for _, imageExtension := range imageExtensions {
+		if extension == imageExtension {
+			return true
+		}
+	}
+
+	return false
+}
+
+func formatSize(bytes int) string {
+	switch {
+	case bytes >= 1024*1024:
+		return fmt.Sprintf("%.2f MB", float64(bytes)/1024/1024)
+	case bytes >= 1024:
+		return fmt.Sprintf("%.2f KB", float64(bytes)/1024)
+	default:
+		return fmt.Sprintf("%d bytes", bytes)
+	}
+}
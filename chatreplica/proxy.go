@@ -9,7 +9,9 @@ import (
 	"time"
 
 	"github.com/TicketsBot-cloud/dashboard/config"
+	"github.com/TicketsBot-cloud/dashboard/log"
 	"github.com/getsentry/sentry-go"
+	"go.uber.org/zap"
 )
 
 var client = &http.Client{
@@ -19,8 +21,49 @@ var client = &http.Client{
 	Timeout: time.Second * 3,
 }
 
-func Render(payload Payload) ([]byte, error) {
-	encoded, err := json.Marshal(payload)
+const (
+	RendererRemote = "remote"
+	RendererNative = "native"
+
+	// Incremented whenever the output of the renderer changes, so that cached transcripts are rendered again
+	remoteRendererVersion = 2
+	nativeRendererVersion = 2
+)
+
+// RendererVersion returns the version of the configured renderer
+func RendererVersion() string {
+	if useNativeRenderer() {
+		return fmt.Sprintf("%s-%d", RendererNative, nativeRendererVersion)
+	} else {
+		return fmt.Sprintf("%s-%d", RendererRemote, remoteRendererVersion)
+	}
+}
+
+// Render renders the transcript using the configured renderer, returning the version of the renderer that was used.
+// If the render service fails, the native renderer is used instead, so that the transcript can still be viewed.
+func Render(payload Payload) ([]byte, string, error) {
+	if useNativeRenderer() {
+		html, err := RenderNative(payload)
+		return html, RendererVersion(), err
+	}
+
+	html, err := renderRemote(payload)
+	if err != nil {
+		log.Logger.Warn("Render service failed, falling back to native renderer", zap.Error(err))
+
+		html, err := RenderNative(payload)
+		return html, fmt.Sprintf("%s-%d", RendererNative, nativeRendererVersion), err
+	}
+
+	return html, RendererVersion(), nil
+}
+
+func useNativeRenderer() bool {
+	return config.Conf.Bot.TranscriptRenderer == RendererNative || config.Conf.Bot.RenderServiceUrl == ""
+}
+
+func renderRemote(payload Payload) ([]byte, error) {
+	encoded, err := json.Marshal(payload.forRenderService())
 	if err != nil {
 		return nil, err
 	}
@@ -45,6 +45,12 @@ type (
 		Content     string               `json:"content"`
 		Embeds      []embed.Embed        `json:"embeds,omitempty"`
 		Attachments []channel.Attachment `json:"attachments,omitempty"`
+		Reference   *Reference           `json:"reference,omitempty"`
+		Stickers    []Sticker            `json:"stickers,omitempty"`
+		EditedTime  *int64               `json:"edited_time,omitempty"` // Unix milliseconds
+
+		// SystemContent is the text Discord shows in place of the content for system messages, such as pins
+		SystemContent string `json:"system_content,omitempty"`
 	}
 )
 
@@ -59,24 +65,19 @@ func badgePtr(b Badge) *Badge {
 	return &b
 }
 
-func MessagesFromTranscript(messages []v2.Message) []Message {
+// MessagesFromTranscript converts messages from a transcript, keeping their type, the message they reply to, their
+// stickers and when they were last edited. Messages that have nothing to display are dropped.
+func MessagesFromTranscript(messages []TranscriptMessage, users map[uint64]v2.User) []Message {
 	// Can't assign length as we might filter
 	var wrappedMessages []Message
 
 	for _, msg := range messages {
-		if msg.Content == "" && len(msg.Embeds) == 0 && len(msg.Attachments) == 0 {
+		wrapped := messageFromTranscript(msg, users)
+		if !wrapped.hasDisplayableContent() && len(wrapped.Stickers) == 0 && wrapped.SystemContent == "" {
 			continue
 		}
 
-		wrappedMessages = append(wrappedMessages, Message{
-			Id:          msg.Id,
-			Type:        message.MessageTypeDefault,
-			Author:      msg.AuthorId,
-			Time:        msg.Timestamp.UnixMilli(),
-			Content:     msg.Content,
-			Embeds:      msg.Embeds,
-			Attachments: msg.Attachments,
-		})
+		wrappedMessages = append(wrappedMessages, wrapped)
 	}
 
 	return wrappedMessages
@@ -0,0 +1,96 @@
+package chatreplica
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/TicketsBot/logarchiver/pkg/model"
+	v2 "github.com/TicketsBot/logarchiver/pkg/model/v2"
+	"github.com/rxdn/gdl/objects/channel/message"
+)
+
+type (
+	// Transcript is an archived transcript. Unlike v2.Transcript, it keeps the fields of each message that are needed
+	// to display replies, stickers, edits and system messages. Only v1 archives contain these fields: the archiver's
+	// v2.NewTranscript discards them, so messages from v2 archives are always displayed as plain messages.
+	Transcript struct {
+		Version  model.Version       `json:"version"`
+		Entities v2.Entities         `json:"entities"`
+		Messages []TranscriptMessage `json:"messages"`
+	}
+
+	// TranscriptMessage is a v2 message, with the fields that v2.Message discards, which are only set for messages
+	// converted from v1 archives. Type uses gdl's numbering, as v1 archives do.
+	TranscriptMessage struct {
+		v2.Message
+		Type            message.MessageType       `json:"type,omitempty"`
+		Reference       *message.MessageReference `json:"message_reference,omitempty"`
+		StickerItems    []StickerItem             `json:"sticker_items,omitempty"`
+		Mentions        []v2.User                 `json:"mentions,omitempty"`
+		EditedTimestamp *time.Time                `json:"edited_timestamp,omitempty"`
+	}
+
+	// v1Message is a full Discord message object, as stored by v1 archives. gdl does not decode stickers, so they are
+	// decoded alongside the message.
+	v1Message struct {
+		message.Message
+		StickerItems []StickerItem `json:"sticker_items,omitempty"`
+	}
+)
+
+// DecodeTranscript decodes a decrypted archive, in either the v1 or v2 format. v1 archives are converted to the v2
+// format, keeping the fields of TranscriptMessage that v2 archives do not store.
+func DecodeTranscript(data []byte) (Transcript, error) {
+	switch version := model.GetVersion(data); version {
+	case model.V1:
+		var messages []v1Message
+		if err := json.Unmarshal(data, &messages); err != nil {
+			return Transcript{}, err
+		}
+
+		return transcriptFromV1(messages), nil
+	case model.V2:
+		var transcript Transcript
+		if err := json.Unmarshal(data, &transcript); err != nil {
+			return Transcript{}, err
+		}
+
+		return transcript, nil
+	default:
+		return Transcript{}, fmt.Errorf("unknown transcript version %d", version)
+	}
+}
+
+func transcriptFromV1(messages []v1Message) Transcript {
+	converted := make([]TranscriptMessage, len(messages))
+	users := make(map[uint64]v2.User)
+
+	for i, msg := range messages {
+		converted[i] = TranscriptMessage{
+			Message:         v2.MessageFromGdl(msg.Message),
+			Type:            msg.Type,
+			StickerItems:    msg.StickerItems,
+			EditedTimestamp: msg.EditedTimestamp,
+		}
+
+		if msg.MessageReference.MessageId != 0 {
+			reference := msg.MessageReference
+			converted[i].Reference = &reference
+		}
+
+		for _, mention := range msg.Mentions {
+			converted[i].Mentions = append(converted[i].Mentions, v2.UserFromGdl(mention.User))
+		}
+
+		users[msg.Author.Id] = v2.UserFromGdl(msg.Author)
+	}
+
+	return Transcript{
+		Version: model.V2,
+		Entities: v2.Entities{
+			Users: users,
+		},
+		Messages: converted,
+	}
+}
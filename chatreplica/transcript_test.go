@@ -0,0 +1,67 @@
+package chatreplica
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/TicketsBot/logarchiver/pkg/model"
+	v2 "github.com/TicketsBot/logarchiver/pkg/model/v2"
+	"github.com/rxdn/gdl/objects/channel"
+	"github.com/rxdn/gdl/objects/channel/message"
+	"github.com/rxdn/gdl/objects/guild"
+	"github.com/rxdn/gdl/objects/user"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDecodeV2Transcript(t *testing.T) {
+	timestamp := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	edited := timestamp.Add(time.Minute * 2)
+	author := user.User{Id: 2, Username: "alice"}
+
+	// v2 archives are written by the archiver's v2.NewTranscript, which discards replies, stickers, edits and types
+	messages := []message.Message{
+		{Id: 1, Author: author, Content: "hello", Timestamp: timestamp},
+		{
+			Id:               3,
+			Author:           author,
+			Content:          "agreed",
+			Timestamp:        timestamp.Add(time.Minute),
+			Type:             message.MessageTypeReply,
+			MessageReference: message.MessageReference{MessageId: 1},
+			EditedTimestamp:  &edited,
+		},
+	}
+
+	data, err := json.Marshal(v2.NewTranscript(messages, v2.NoopRetriever[user.User], v2.NoopRetriever[channel.Channel], v2.NoopRetriever[guild.Role]))
+	assert.NoError(t, err)
+
+	transcript, err := DecodeTranscript(data)
+	assert.NoError(t, err)
+	assert.Equal(t, model.V2, transcript.Version)
+	assert.Equal(t, "alice", transcript.Entities.Users[2].Username)
+	assert.Len(t, transcript.Messages, 2)
+
+	reply := transcript.Messages[1]
+	assert.Equal(t, "agreed", reply.Content)
+	assert.Equal(t, message.MessageTypeDefault, reply.Type)
+	assert.Nil(t, reply.Reference)
+	assert.Nil(t, reply.EditedTimestamp)
+}
+
+func TestDecodeV1Transcript(t *testing.T) {
+	data := `[
+		{"id": "1", "message_type": 6, "content": "", "timestamp": "2024-01-01T12:00:00Z", "author": {"id": "2", "username": "alice"}},
+		{"id": "3", "message_type": 0, "content": "", "timestamp": "2024-01-01T12:01:00Z", "author": {"id": "2", "username": "alice"}, "sticker_items": [{"id": "10", "name": "wave", "format_type": 4}]}
+	]`
+
+	transcript, err := DecodeTranscript([]byte(data))
+	assert.NoError(t, err)
+	assert.Equal(t, model.V2, transcript.Version)
+	assert.Equal(t, "alice", transcript.Entities.Users[2].Username)
+
+	assert.Equal(t, message.MessageTypeChannelPinnedMessage, transcript.Messages[0].Type)
+	assert.Equal(t, uint64(2), transcript.Messages[0].AuthorId)
+	assert.Nil(t, transcript.Messages[0].Reference)
+	assert.Equal(t, []StickerItem{{Id: 10, Name: "wave", FormatType: StickerFormatTypeGif}}, transcript.Messages[1].StickerItems)
+}
@@ -1,9 +1,11 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"net/http"
 	"net/http/pprof"
+	"time"
 
 	"github.com/TicketsBot-cloud/archiverclient"
 	"github.com/TicketsBot-cloud/common/chatrelay"
@@ -11,6 +13,7 @@ import (
 	"github.com/TicketsBot-cloud/common/observability"
 	"github.com/TicketsBot-cloud/common/premium"
 	"github.com/TicketsBot-cloud/common/secureproxy"
+	"github.com/TicketsBot-cloud/dashboard/app/background"
 	app "github.com/TicketsBot-cloud/dashboard/app/http"
 	"github.com/TicketsBot-cloud/dashboard/app/http/endpoints/api/ticket/livechat"
 	"github.com/TicketsBot-cloud/dashboard/config"
@@ -87,7 +90,8 @@ func main() {
 	s3.ConnectS3(config.Conf.S3Import.Endpoint, config.Conf.S3Import.AccessKey, config.Conf.S3Import.SecretKey)
 
 	logger.Info("Initialising microservice clients")
-	utils.ArchiverClient = archiverclient.NewArchiverClient(archiverclient.NewProxyRetriever(config.Conf.Bot.ObjectStore), []byte(config.Conf.Bot.AesKey))
+	utils.ArchiveRetriever = archiverclient.NewProxyRetriever(config.Conf.Bot.ObjectStore)
+	utils.ArchiverClient = archiverclient.NewArchiverClient(utils.ArchiveRetriever, []byte(config.Conf.Bot.AesKey))
 	utils.SecureProxyClient = secureproxy.NewSecureProxy(config.Conf.SecureProxyUrl)
 
 	utils.LoadEmoji()
@@ -106,6 +110,13 @@ func main() {
 
 	go ListenChat(redis.Client, socketManager)
 
+	go background.RunScheduledCloses(context.Background(), logger)
+	go background.RunTranscriptIndexer(context.Background(), logger)
+	go background.RunTranscriptExports(context.Background(), logger)
+	go background.RunTranscriptRetention(context.Background(), logger)
+	go background.RunAttachmentMirroring(context.Background(), logger)
+	go background.RunPanelAvailability(context.Background(), logger)
+
 	if !config.Conf.Debug {
 		rpc.PremiumClient = premium.NewPremiumLookupClient(
 			redis.Client.Client,
@@ -127,6 +138,19 @@ func ListenChat(client *redis.RedisClient, sm *livechat.SocketManager) {
 
 	for event := range ch {
 		sm.BroadcastMessage(event)
+
+		if !background.IsOpenerReply(event) {
+			continue
+		}
+
+		go func() {
+			ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+			defer cancel()
+
+			if err := background.CancelScheduledCloseOnReply(ctx, event); err != nil {
+				log.Logger.Error("Failed to cancel scheduled close", zap.Error(err))
+			}
+		}()
 	}
 }
 
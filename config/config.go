@@ -43,7 +43,9 @@ type Config struct {
 		AesKey                               string `env:"LOG_AES_KEY" toml:"aes-key"`
 		ProxyUrl                             string `env:"DISCORD_PROXY_URL" toml:"discord-proxy-url"`
 		RenderServiceUrl                     string `env:"RENDER_SERVICE_URL" toml:"render-service-url"`
+		TranscriptRenderer                   string `env:"TRANSCRIPT_RENDERER" envDefault:"remote" toml:"transcript-renderer"`
 		ImageProxySecret                     string `env:"IMAGE_PROXY_SECRET" toml:"image-proxy-secret"`
+		ImageProxyUrl                        string `env:"IMAGE_PROXY_URL" envDefault:"https://image-cdn.ticketsbot.cloud" toml:"image-proxy-url"`
 		PublicIntegrationRequestWebhookId    uint64 `env:"PUBLIC_INTEGRATION_REQUEST_WEBHOOK_ID" toml:"public-integration-request-webhook-id"`
 		PublicIntegrationRequestWebhookToken string `env:"PUBLIC_INTEGRATION_REQUEST_WEBHOOK_TOKEN" toml:"public-integration-request-webhook-token"`
 	}
@@ -63,6 +65,8 @@ type Config struct {
 		SecretKey        string `env:"SECRET_KEY,required"`
 		TranscriptBucket string `env:"TRANSCRIPT_BUCKET,required"`
 		DataBucket       string `env:"DATA_BUCKET,required"`
+		ExportBucket     string `env:"EXPORT_BUCKET"`     // Defaults to DataBucket
+		AttachmentBucket string `env:"ATTACHMENT_BUCKET"` // Defaults to DataBucket
 	} `envPrefix:"S3_IMPORT_"`
 }
 
@@ -0,0 +1,91 @@
+package database
+
+import (
+	"context"
+
+	"github.com/TicketsBot-cloud/database"
+	"github.com/jackc/pgx/v4"
+	"github.com/jackc/pgx/v4/pgxpool"
+)
+
+// DashboardDatabase contains the tables that are owned by the dashboard, rather than the shared database module
+type DashboardDatabase struct {
+	pool                   *pgxpool.Pool
+	TicketLabels           *TicketLabelsTable
+	TicketLabelAssignments *TicketLabelAssignmentsTable
+	TicketPriority         *TicketPriorityTable
+	TicketQuery            *TicketQueryTable
+	ScheduledClose         *ScheduledCloseTable
+	PanelSla               *PanelSlaTable
+	FirstResponseTime      *FirstResponseTimeQueryTable
+	TranscriptSearch       *TranscriptSearchTable
+	ImportLogs             *ImportLogsQueryTable
+	TranscriptExportJobs   *TranscriptExportJobTable
+	TranscriptShareLinks   *TranscriptShareLinkTable
+	UserTickets            *UserTicketsQueryTable
+	TranscriptRedactions   *TranscriptRedactionTable
+	TranscriptRetention    *TranscriptRetentionTable
+	AttachmentMirrors      *TranscriptAttachmentMirrorTable
+	TicketStats            *TicketStatsQueryTable
+	DashboardMessages      *DashboardMessageTable
+	Feedback               *FeedbackQueryTable
+	PanelTemplates         *PanelTemplateTable
+	PanelAvailability      *PanelAvailabilityTable
+}
+
+func NewDashboardDatabase(pool *pgxpool.Pool) *DashboardDatabase {
+	return &DashboardDatabase{
+		pool:                   pool,
+		TicketLabels:           newTicketLabelsTable(pool),
+		TicketLabelAssignments: newTicketLabelAssignmentsTable(pool),
+		TicketPriority:         newTicketPriorityTable(pool),
+		TicketQuery:            newTicketQueryTable(pool),
+		ScheduledClose:         newScheduledCloseTable(pool),
+		PanelSla:               newPanelSlaTable(pool),
+		FirstResponseTime:      newFirstResponseTimeQueryTable(pool),
+		TranscriptSearch:       newTranscriptSearchTable(pool),
+		ImportLogs:             newImportLogsQueryTable(pool),
+		TranscriptExportJobs:   newTranscriptExportJobTable(pool),
+		TranscriptShareLinks:   newTranscriptShareLinkTable(pool),
+		UserTickets:            newUserTicketsQueryTable(pool),
+		TranscriptRedactions:   newTranscriptRedactionTable(pool),
+		TranscriptRetention:    newTranscriptRetentionTable(pool),
+		AttachmentMirrors:      newTranscriptAttachmentMirrorTable(pool),
+		TicketStats:            newTicketStatsQueryTable(pool),
+		DashboardMessages:      newDashboardMessageTable(pool),
+		Feedback:               newFeedbackQueryTable(pool),
+		PanelTemplates:         newPanelTemplateTable(pool),
+		PanelAvailability:      newPanelAvailabilityTable(pool),
+	}
+}
+
+func (d *DashboardDatabase) WithTx(ctx context.Context, f func(tx pgx.Tx) error) error {
+	return d.pool.BeginFunc(ctx, f)
+}
+
+func (d *DashboardDatabase) CreateTables(ctx context.Context) {
+	mustCreate(ctx, d.pool,
+		d.TicketLabels,
+		d.TicketLabelAssignments, // depends on ticket_labels
+		d.TicketPriority,
+		d.ScheduledClose,
+		d.PanelSla,
+		d.TranscriptSearch,
+		d.TranscriptExportJobs,
+		d.TranscriptShareLinks,
+		d.TranscriptRedactions,
+		d.TranscriptRetention,
+		d.AttachmentMirrors,
+		d.DashboardMessages,
+		d.PanelAvailability,
+		d.UserTickets,
+	)
+}
+
+func mustCreate(ctx context.Context, pool *pgxpool.Pool, tables ...database.Table) {
+	for _, table := range tables {
+		if _, err := pool.Exec(ctx, table.Schema()); err != nil {
+			panic(err)
+		}
+	}
+}
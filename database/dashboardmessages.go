@@ -0,0 +1,41 @@
+package database
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v4/pgxpool"
+)
+
+// DashboardMessageTable records the messages that staff send to tickets through the dashboard, for the staff report.
+// Message content is not stored.
+type DashboardMessageTable struct {
+	*pgxpool.Pool
+}
+
+func newDashboardMessageTable(db *pgxpool.Pool) *DashboardMessageTable {
+	return &DashboardMessageTable{
+		db,
+	}
+}
+
+func (t DashboardMessageTable) Schema() string {
+	return `
+CREATE TABLE IF NOT EXISTS dashboard_messages(
+	"id" SERIAL NOT NULL UNIQUE,
+	"guild_id" int8 NOT NULL,
+	"ticket_id" int4 NOT NULL,
+	"user_id" int8 NOT NULL,
+	"sent_at" timestamptz NOT NULL DEFAULT NOW(),
+	FOREIGN KEY("guild_id", "ticket_id") REFERENCES tickets("guild_id", "id") ON DELETE CASCADE,
+	PRIMARY KEY("id")
+);
+CREATE INDEX IF NOT EXISTS dashboard_messages_guild_sent_at ON dashboard_messages("guild_id", "sent_at");
+CREATE INDEX IF NOT EXISTS dashboard_messages_guild_ticket ON dashboard_messages("guild_id", "ticket_id");
+`
+}
+
+func (t *DashboardMessageTable) Record(ctx context.Context, guildId uint64, ticketId int, userId uint64) (err error) {
+	query := `INSERT INTO dashboard_messages("guild_id", "ticket_id", "user_id") VALUES($1, $2, $3);`
+	_, err = t.Exec(ctx, query, guildId, ticketId, userId)
+	return
+}
@@ -15,6 +15,9 @@ import (
 
 var Client *database.Database
 
+// Dashboard contains tables that are only used by the dashboard
+var Dashboard *DashboardDatabase
+
 func ConnectToDatabase() {
 	config, err := pgxpool.ParseConfig(config.Conf.Database.Uri)
 	if err != nil {
@@ -38,4 +41,7 @@ func ConnectToDatabase() {
 	}
 
 	Client = database.NewDatabase(pool)
+
+	Dashboard = NewDashboardDatabase(pool)
+	Dashboard.CreateTables(context.Background())
 }
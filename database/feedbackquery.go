@@ -0,0 +1,336 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgtype"
+	"github.com/jackc/pgx/v4"
+	"github.com/jackc/pgx/v4/pgxpool"
+)
+
+type (
+	// FeedbackQueryOptions filters the feedback left on tickets closed within [From, To). Feedback is a service rating,
+	// exit survey answers, or both.
+	FeedbackQueryOptions struct {
+		GuildId       uint64
+		From          time.Time
+		To            time.Time
+		PanelId       *int
+		FormId        *int
+		MinRating     *uint8
+		MaxRating     *uint8
+		WithResponses bool   // Only include tickets with exit survey answers
+		Search        string // Case-insensitive substring match on exit survey answers
+		Limit         int
+		Offset        int
+	}
+
+	FeedbackEntry struct {
+		TicketId   int                `json:"ticket_id"`
+		UserId     uint64             `json:"user_id,string"`
+		ClaimedBy  *uint64            `json:"claimed_by,string"`
+		PanelId    *int               `json:"panel_id"`
+		PanelTitle *string            `json:"panel_title"`
+		CloseTime  time.Time          `json:"close_time"`
+		Rating     *uint8             `json:"rating"`
+		FormId     *int               `json:"form_id"`
+		FormTitle  *string            `json:"form_title"`
+		Responses  []FeedbackResponse `json:"responses"`
+	}
+
+	FeedbackResponse struct {
+		QuestionId int    `json:"question_id"`
+		Question   string `json:"question"`
+		Response   string `json:"response"`
+	}
+
+	// FeedbackDistribution summarises all feedback matching the filters, ignoring the limit and offset. Ratings[i] is
+	// the number of tickets rated i+1 stars.
+	FeedbackDistribution struct {
+		Total         int      `json:"total"`
+		Ratings       [5]int   `json:"ratings"`
+		RatingCount   int      `json:"rating_count"`
+		RatingAverage *float64 `json:"rating_average"`
+		WithResponses int      `json:"with_responses"`
+	}
+)
+
+// FeedbackQueryTable reads the service_ratings and exit_survey_responses tables, which are owned by the shared
+// database module
+type FeedbackQueryTable struct {
+	*pgxpool.Pool
+}
+
+func newFeedbackQueryTable(db *pgxpool.Pool) *FeedbackQueryTable {
+	return &FeedbackQueryTable{
+		db,
+	}
+}
+
+// Get returns the feedback matching the options, most recently closed first, with the exit survey answers of each
+// ticket in question order
+func (t *FeedbackQueryTable) Get(ctx context.Context, options FeedbackQueryOptions) ([]FeedbackEntry, error) {
+	query, args := options.buildQuery(nil)
+	if options.Limit != 0 {
+		args = append(args, options.Limit)
+		query += fmt.Sprintf(` LIMIT $%d `, len(args))
+	}
+
+	if options.Offset != 0 {
+		args = append(args, options.Offset)
+		query += fmt.Sprintf(` OFFSET $%d `, len(args))
+	}
+
+	entries, err := t.query(ctx, query+";", args...)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := t.fillResponses(ctx, options.GuildId, entries); err != nil {
+		return nil, err
+	}
+
+	return entries, nil
+}
+
+// Export passes all feedback matching the options, ignoring the limit and offset, to f in batches of up to batchSize.
+// Each batch is read with its own keyset query, so no connection is held while f runs.
+func (t *FeedbackQueryTable) Export(ctx context.Context, options FeedbackQueryOptions, batchSize int, f func([]FeedbackEntry) error) error {
+	var after *FeedbackEntry
+	for {
+		query, args := options.buildQuery(after)
+		args = append(args, batchSize)
+		query += fmt.Sprintf(` LIMIT $%d;`, len(args))
+
+		batch, err := t.query(ctx, query, args...)
+		if err != nil {
+			return err
+		}
+
+		if len(batch) == 0 {
+			return nil
+		}
+
+		if err := t.fillResponses(ctx, options.GuildId, batch); err != nil {
+			return err
+		}
+
+		if err := f(batch); err != nil {
+			return err
+		}
+
+		if len(batch) < batchSize {
+			return nil
+		}
+
+		after = &batch[len(batch)-1]
+	}
+}
+
+func (t *FeedbackQueryTable) GetDistribution(ctx context.Context, options FeedbackQueryOptions) (FeedbackDistribution, error) {
+	joins, conditions, args := options.buildFilters()
+
+	query := `
+SELECT COUNT(*),
+	COUNT(*) FILTER (WHERE service_ratings.rating = 1),
+	COUNT(*) FILTER (WHERE service_ratings.rating = 2),
+	COUNT(*) FILTER (WHERE service_ratings.rating = 3),
+	COUNT(*) FILTER (WHERE service_ratings.rating = 4),
+	COUNT(*) FILTER (WHERE service_ratings.rating = 5),
+	COUNT(service_ratings.rating),
+	AVG(service_ratings.rating)::float8,
+	COUNT(survey.form_id)
+FROM tickets` + joins + " WHERE " + strings.Join(conditions, " AND ") + ";"
+
+	var distribution FeedbackDistribution
+	if err := t.QueryRow(ctx, query, args...).Scan(
+		&distribution.Total,
+		&distribution.Ratings[0], &distribution.Ratings[1], &distribution.Ratings[2], &distribution.Ratings[3], &distribution.Ratings[4],
+		&distribution.RatingCount,
+		&distribution.RatingAverage,
+		&distribution.WithResponses,
+	); err != nil {
+		return FeedbackDistribution{}, err
+	}
+
+	return distribution, nil
+}
+
+func (t *FeedbackQueryTable) query(ctx context.Context, query string, args ...interface{}) ([]FeedbackEntry, error) {
+	rows, err := t.Query(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	entries := make([]FeedbackEntry, 0)
+	for rows.Next() {
+		entry, err := scanFeedbackEntry(rows)
+		if err != nil {
+			return nil, err
+		}
+
+		entries = append(entries, entry)
+	}
+
+	return entries, rows.Err()
+}
+
+func scanFeedbackEntry(rows pgx.Rows) (FeedbackEntry, error) {
+	entry := FeedbackEntry{
+		Responses: make([]FeedbackResponse, 0),
+	}
+
+	err := rows.Scan(
+		&entry.TicketId,
+		&entry.UserId,
+		&entry.ClaimedBy,
+		&entry.PanelId,
+		&entry.PanelTitle,
+		&entry.CloseTime,
+		&entry.Rating,
+		&entry.FormId,
+		&entry.FormTitle,
+	)
+
+	return entry, err
+}
+
+func (t *FeedbackQueryTable) fillResponses(ctx context.Context, guildId uint64, entries []FeedbackEntry) error {
+	if len(entries) == 0 {
+		return nil
+	}
+
+	indexes := make(map[int]int, len(entries))
+	ticketIds := make([]int, len(entries))
+	for i, entry := range entries {
+		indexes[entry.TicketId] = i
+		ticketIds[i] = entry.TicketId
+	}
+
+	ticketIdArray := &pgtype.Int4Array{}
+	if err := ticketIdArray.Set(ticketIds); err != nil {
+		return err
+	}
+
+	query := `
+SELECT exit_survey_responses.ticket_id, exit_survey_responses.question_id, form_input.label, exit_survey_responses.response
+FROM exit_survey_responses
+INNER JOIN form_input ON form_input.id = exit_survey_responses.question_id
+WHERE exit_survey_responses.guild_id = $1 AND exit_survey_responses.ticket_id = ANY($2)
+	AND exit_survey_responses.response IS NOT NULL
+ORDER BY exit_survey_responses.ticket_id, form_input.position;`
+
+	rows, err := t.Query(ctx, query, guildId, ticketIdArray)
+	if err != nil {
+		return err
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+		var ticketId int
+		var response FeedbackResponse
+		if err := rows.Scan(&ticketId, &response.QuestionId, &response.Question, &response.Response); err != nil {
+			return err
+		}
+
+		if i, ok := indexes[ticketId]; ok {
+			entries[i].Responses = append(entries[i].Responses, response)
+		}
+	}
+
+	return rows.Err()
+}
+
+// buildQuery returns the query for the feedback matching the options, without a limit or offset. If after is not nil,
+// only the feedback that comes after it in the ordering is returned.
+func (o FeedbackQueryOptions) buildQuery(after *FeedbackEntry) (query string, args []interface{}) {
+	joins, conditions, args := o.buildFilters()
+	if after != nil {
+		args = append(args, after.CloseTime, after.TicketId)
+		conditions = append(conditions, fmt.Sprintf("(tickets.close_time, tickets.id) < ($%d, $%d)", len(args)-1, len(args)))
+	}
+
+	query = `
+SELECT tickets.id,
+	tickets.user_id,
+	ticket_claims.user_id,
+	tickets.panel_id,
+	panels.title,
+	tickets.close_time,
+	service_ratings.rating,
+	survey.form_id,
+	forms.title
+FROM tickets` + joins + `
+LEFT JOIN ticket_claims ON tickets.guild_id = ticket_claims.guild_id AND tickets.id = ticket_claims.ticket_id
+LEFT JOIN panels ON tickets.panel_id = panels.panel_id
+LEFT JOIN forms ON survey.form_id = forms.form_id
+WHERE ` + strings.Join(conditions, " AND ") + `
+ORDER BY tickets.close_time DESC, tickets.id DESC`
+
+	return
+}
+
+// buildFilters returns the JOIN clauses, WHERE conditions and arguments shared by all queries on the options. The
+// service_ratings table and the survey relation, containing the form that the ticket's exit survey answers are for,
+// are always joined.
+func (o FeedbackQueryOptions) buildFilters() (joins string, conditions []string, args []interface{}) {
+	joins = `
+LEFT JOIN service_ratings ON tickets.guild_id = service_ratings.guild_id AND tickets.id = service_ratings.ticket_id
+LEFT JOIN LATERAL (
+	SELECT exit_survey_responses.form_id
+	FROM exit_survey_responses
+	WHERE exit_survey_responses.guild_id = tickets.guild_id AND exit_survey_responses.ticket_id = tickets.id
+	LIMIT 1
+) survey ON true`
+
+	args = []interface{}{o.GuildId, o.From, o.To}
+	conditions = []string{
+		"tickets.guild_id = $1",
+		"tickets.open = false",
+		"tickets.close_time >= $2",
+		"tickets.close_time < $3",
+		"(service_ratings.rating IS NOT NULL OR survey.form_id IS NOT NULL)",
+	}
+
+	if o.PanelId != nil {
+		args = append(args, *o.PanelId)
+		conditions = append(conditions, fmt.Sprintf("tickets.panel_id = $%d", len(args)))
+	}
+
+	if o.FormId != nil {
+		args = append(args, *o.FormId)
+		conditions = append(conditions, fmt.Sprintf("survey.form_id = $%d", len(args)))
+	}
+
+	if o.MinRating != nil {
+		args = append(args, int(*o.MinRating))
+		conditions = append(conditions, fmt.Sprintf("service_ratings.rating >= $%d", len(args)))
+	}
+
+	if o.MaxRating != nil {
+		args = append(args, int(*o.MaxRating))
+		conditions = append(conditions, fmt.Sprintf("service_ratings.rating <= $%d", len(args)))
+	}
+
+	if o.WithResponses {
+		conditions = append(conditions, "survey.form_id IS NOT NULL")
+	}
+
+	if o.Search != "" {
+		args = append(args, "%"+escapeLike(o.Search)+"%")
+		conditions = append(conditions, fmt.Sprintf(`EXISTS (
+	SELECT 1
+	FROM exit_survey_responses response_search
+	WHERE response_search.guild_id = tickets.guild_id AND response_search.ticket_id = tickets.id
+		AND response_search.response ILIKE $%d
+)`, len(args)))
+	}
+
+	return
+}
@@ -0,0 +1,51 @@
+package database
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFeedbackQueryDefaults(t *testing.T) {
+	from := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	to := from.AddDate(0, 1, 0)
+
+	query, args := FeedbackQueryOptions{GuildId: 1, From: from, To: to}.buildQuery(nil)
+	assert.Equal(t, []interface{}{uint64(1), from, to}, args)
+	assert.Contains(t, query, "(service_ratings.rating IS NOT NULL OR survey.form_id IS NOT NULL)")
+	assert.Contains(t, query, "ORDER BY tickets.close_time DESC, tickets.id DESC")
+}
+
+func TestFeedbackQueryFilters(t *testing.T) {
+	panelId, formId := 2, 3
+	minRating, maxRating := uint8(1), uint8(3)
+
+	_, conditions, args := FeedbackQueryOptions{
+		GuildId:       1,
+		PanelId:       &panelId,
+		FormId:        &formId,
+		MinRating:     &minRating,
+		MaxRating:     &maxRating,
+		WithResponses: true,
+		Search:        "50%",
+	}.buildFilters()
+
+	assert.Equal(t, []interface{}{2, 3, 1, 3, `%50\%%`}, args[3:])
+	assert.Contains(t, conditions, "tickets.panel_id = $4")
+	assert.Contains(t, conditions, "survey.form_id = $5")
+	assert.Contains(t, conditions, "service_ratings.rating >= $6")
+	assert.Contains(t, conditions, "service_ratings.rating <= $7")
+	assert.Contains(t, conditions, "survey.form_id IS NOT NULL")
+	assert.Contains(t, conditions[len(conditions)-1], "response_search.response ILIKE $8")
+}
+
+func TestFeedbackQueryAfter(t *testing.T) {
+	from := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	to := from.AddDate(0, 1, 0)
+	closeTime := from.AddDate(0, 0, 10)
+
+	query, args := FeedbackQueryOptions{GuildId: 1, From: from, To: to}.buildQuery(&FeedbackEntry{TicketId: 5, CloseTime: closeTime})
+	assert.Equal(t, []interface{}{uint64(1), from, to, closeTime, 5}, args)
+	assert.Contains(t, query, "(tickets.close_time, tickets.id) < ($4, $5)")
+}
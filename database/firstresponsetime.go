@@ -0,0 +1,49 @@
+package database
+
+import (
+	"context"
+
+	"github.com/jackc/pgtype"
+	"github.com/jackc/pgx/v4/pgxpool"
+)
+
+// FirstResponseTimeQueryTable provides additional queries on the first_response_time table, which is owned by the
+// shared database module
+type FirstResponseTimeQueryTable struct {
+	*pgxpool.Pool
+}
+
+func newFirstResponseTimeQueryTable(db *pgxpool.Pool) *FirstResponseTimeQueryTable {
+	return &FirstResponseTimeQueryTable{
+		db,
+	}
+}
+
+// GetResponded returns the subset of the given tickets that have received a first response from staff
+func (f *FirstResponseTimeQueryTable) GetResponded(ctx context.Context, guildId uint64, ticketIds []int) (map[int]bool, error) {
+	query := `SELECT "ticket_id" FROM first_response_time WHERE "guild_id" = $1 AND "ticket_id" = ANY($2);`
+
+	array := &pgtype.Int4Array{}
+	if err := array.Set(ticketIds); err != nil {
+		return nil, err
+	}
+
+	rows, err := f.Query(ctx, query, guildId, array)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	responded := make(map[int]bool)
+	for rows.Next() {
+		var ticketId int
+		if err := rows.Scan(&ticketId); err != nil {
+			return nil, err
+		}
+
+		responded[ticketId] = true
+	}
+
+	return responded, nil
+}
@@ -0,0 +1,33 @@
+package database
+
+import (
+	"context"
+	"time"
+
+	"github.com/jackc/pgx/v4/pgxpool"
+)
+
+// ImportLogsQueryTable provides additional queries on the import_logs table, which is owned by the shared database
+// module
+type ImportLogsQueryTable struct {
+	*pgxpool.Pool
+}
+
+func newImportLogsQueryTable(db *pgxpool.Pool) *ImportLogsQueryTable {
+	return &ImportLogsQueryTable{
+		db,
+	}
+}
+
+// GetLastActivity returns the time of the most recent import log for the guild, or nil if the guild has never
+// imported data
+func (i *ImportLogsQueryTable) GetLastActivity(ctx context.Context, guildId uint64) (*time.Time, error) {
+	query := `SELECT MAX("date") FROM import_logs WHERE "guild_id" = $1;`
+
+	var lastActivity *time.Time
+	if err := i.QueryRow(ctx, query, guildId).Scan(&lastActivity); err != nil {
+		return nil, err
+	}
+
+	return lastActivity, nil
+}
@@ -0,0 +1,372 @@
+package database
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/jackc/pgx/v4"
+	"github.com/jackc/pgx/v4/pgxpool"
+)
+
+type (
+	// PanelAvailability is a weekly schedule for when a panel accepts new tickets. Outside of the schedule, the panel
+	// is disabled, and the closed message, if any, is shown on the panel message.
+	PanelAvailability struct {
+		PanelId       int                       `json:"panel_id"`
+		Schedule      PanelAvailabilitySchedule `json:"schedule"`
+		ClosedMessage *string                   `json:"closed_message"`
+
+		// ClosedBySchedule is whether the panel is currently disabled by the schedule, rather than manually. Panels that
+		// were disabled manually are not re-enabled when the schedule opens.
+		ClosedBySchedule bool `json:"closed_by_schedule"`
+
+		// NextTransition is when the schedule next needs to be applied
+		NextTransition time.Time `json:"next_transition"`
+
+		// Version is incremented each time the schedule is set, so that a schedule that was replaced while it was being
+		// applied is not given the old schedule's next transition
+		Version int `json:"-"`
+	}
+
+	PanelAvailabilitySchedule struct {
+		Timezone string                `json:"timezone"`
+		Windows  []AvailabilityWindow  `json:"windows"`
+		Holidays []AvailabilityHoliday `json:"holidays"`
+	}
+
+	// AvailabilityWindow is a period of a day in which the panel is open. Times are in HH:MM format, in the schedule's
+	// timezone. The close time is exclusive, and may be 24:00 to close at the end of the day.
+	AvailabilityWindow struct {
+		Day   time.Weekday `json:"day"`
+		Open  string       `json:"open"`
+		Close string       `json:"close"`
+	}
+
+	// AvailabilityHoliday is a date, in YYYY-MM-DD format, on which the panel is closed all day
+	AvailabilityHoliday struct {
+		Date string `json:"date"`
+		Name string `json:"name,omitempty"`
+	}
+)
+
+const (
+	availabilityDateFormat = "2006-01-02"
+
+	// availabilityLookahead is how far ahead the next transition is searched for. Schedules with no transition in this
+	// period, such as those closed by a long run of holidays, are checked again once it has passed.
+	availabilityLookahead = 366
+
+	maxAvailabilityWindows  = 50
+	maxAvailabilityHolidays = 100
+)
+
+// Validate checks that the timezone exists, and that every window and holiday is well-formed
+func (s PanelAvailabilitySchedule) Validate() error {
+	if _, err := time.LoadLocation(s.Timezone); err != nil || s.Timezone == "" || s.Timezone == "Local" {
+		return fmt.Errorf("Unknown timezone \"%s\"", s.Timezone)
+	}
+
+	if len(s.Windows) > maxAvailabilityWindows {
+		return fmt.Errorf("A schedule can't have more than %d opening times", maxAvailabilityWindows)
+	}
+
+	if len(s.Holidays) > maxAvailabilityHolidays {
+		return fmt.Errorf("A schedule can't have more than %d holidays", maxAvailabilityHolidays)
+	}
+
+	for _, window := range s.Windows {
+		if window.Day < time.Sunday || window.Day > time.Saturday {
+			return fmt.Errorf("Invalid day %d", window.Day)
+		}
+
+		open, err := parseAvailabilityTime(window.Open)
+		if err != nil {
+			return err
+		}
+
+		closeTime, err := parseAvailabilityTime(window.Close)
+		if err != nil {
+			return err
+		}
+
+		if open >= closeTime {
+			return fmt.Errorf("Opening time %s must be before closing time %s", window.Open, window.Close)
+		}
+	}
+
+	for _, holiday := range s.Holidays {
+		if _, err := time.Parse(availabilityDateFormat, holiday.Date); err != nil {
+			return fmt.Errorf("Invalid holiday date \"%s\"", holiday.Date)
+		}
+	}
+
+	return nil
+}
+
+// IsOpen returns whether the panel is open at the given time. The schedule must be valid.
+func (s PanelAvailabilitySchedule) IsOpen(at time.Time) bool {
+	location, err := time.LoadLocation(s.Timezone)
+	if err != nil {
+		return true
+	}
+
+	return s.isOpenAt(at.In(location))
+}
+
+// isOpenAt returns whether the panel is open at the given time, which must be in the schedule's timezone
+func (s PanelAvailabilitySchedule) isOpenAt(local time.Time) bool {
+	date := local.Format(availabilityDateFormat)
+	for _, holiday := range s.Holidays {
+		if holiday.Date == date {
+			return false
+		}
+	}
+
+	minutes := local.Hour()*60 + local.Minute()
+	for _, window := range s.Windows {
+		if window.Day != local.Weekday() {
+			continue
+		}
+
+		open, _ := parseAvailabilityTime(window.Open)
+		closeTime, _ := parseAvailabilityTime(window.Close)
+		if minutes >= open && minutes < closeTime {
+			return true
+		}
+	}
+
+	return false
+}
+
+// NextTransition returns the first time after the given time at which the panel opens or closes. If there is no
+// transition within availabilityLookahead days, the end of that period is returned with false.
+func (s PanelAvailabilitySchedule) NextTransition(after time.Time) (time.Time, bool) {
+	location, err := time.LoadLocation(s.Timezone)
+	if err != nil {
+		return time.Time{}, false
+	}
+
+	local := after.In(location)
+	open := s.isOpenAt(local)
+
+	// Windows open and close, and holidays start and end, at these times of day
+	boundaries := []int{0}
+	for _, window := range s.Windows {
+		start, _ := parseAvailabilityTime(window.Open)
+		end, _ := parseAvailabilityTime(window.Close)
+		boundaries = append(boundaries, start, end)
+	}
+
+	sort.Ints(boundaries)
+
+	for day := 0; day <= availabilityLookahead; day++ {
+		for _, minutes := range boundaries {
+			candidate := time.Date(local.Year(), local.Month(), local.Day()+day, 0, minutes, 0, 0, location)
+			if !candidate.After(after) {
+				continue
+			}
+
+			if s.isOpenAt(candidate) != open {
+				return candidate, true
+			}
+		}
+	}
+
+	return time.Date(local.Year(), local.Month(), local.Day()+availabilityLookahead, 0, 0, 0, 0, location), false
+}
+
+// parseAvailabilityTime returns the number of minutes since midnight
+func parseAvailabilityTime(value string) (int, error) {
+	if len(value) != 5 || value[2] != ':' {
+		return 0, fmt.Errorf("Invalid time \"%s\", expected HH:MM", value)
+	}
+
+	hours, err := strconv.Atoi(value[:2])
+	if err != nil {
+		return 0, fmt.Errorf("Invalid time \"%s\", expected HH:MM", value)
+	}
+
+	minutes, err := strconv.Atoi(value[3:])
+	if err != nil || hours < 0 || minutes < 0 || minutes > 59 || hours*60+minutes > 24*60 {
+		return 0, fmt.Errorf("Invalid time \"%s\", expected HH:MM", value)
+	}
+
+	return hours*60 + minutes, nil
+}
+
+type PanelAvailabilityTable struct {
+	*pgxpool.Pool
+}
+
+func newPanelAvailabilityTable(db *pgxpool.Pool) *PanelAvailabilityTable {
+	return &PanelAvailabilityTable{
+		db,
+	}
+}
+
+func (p PanelAvailabilityTable) Schema() string {
+	return `
+CREATE TABLE IF NOT EXISTS panel_availability(
+	"panel_id" int NOT NULL,
+	"schedule" jsonb NOT NULL,
+	"closed_message" TEXT,
+	"closed_by_schedule" bool NOT NULL DEFAULT false,
+	"next_transition" timestamptz NOT NULL,
+	"version" int4 NOT NULL DEFAULT 1,
+	FOREIGN KEY("panel_id") REFERENCES panels("panel_id") ON DELETE CASCADE ON UPDATE CASCADE,
+	PRIMARY KEY("panel_id")
+);
+CREATE INDEX IF NOT EXISTS panel_availability_next_transition ON panel_availability("next_transition");
+`
+}
+
+func (p *PanelAvailabilityTable) Get(ctx context.Context, panelId int) (PanelAvailability, bool, error) {
+	query := `
+SELECT "panel_id", "schedule", "closed_message", "closed_by_schedule", "next_transition", "version"
+FROM panel_availability
+WHERE "panel_id" = $1;
+`
+
+	availability, err := scanPanelAvailability(p.QueryRow(ctx, query, panelId))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return PanelAvailability{}, false, nil
+		} else {
+			return PanelAvailability{}, false, err
+		}
+	}
+
+	return availability, true, nil
+}
+
+// Set stores the schedule and closed message, and marks the schedule as due, so that it is applied straight away. The
+// version is incremented, so that a replica applying the previous schedule does not overwrite the next transition.
+func (p *PanelAvailabilityTable) Set(ctx context.Context, availability PanelAvailability) error {
+	query := `
+INSERT INTO panel_availability("panel_id", "schedule", "closed_message", "next_transition")
+VALUES($1, $2, $3, NOW())
+ON CONFLICT("panel_id") DO UPDATE SET "schedule" = $2, "closed_message" = $3, "next_transition" = NOW(), "version" = panel_availability."version" + 1;
+`
+
+	schedule, err := json.Marshal(availability.Schedule)
+	if err != nil {
+		return err
+	}
+
+	_, err = p.Exec(ctx, query, availability.PanelId, schedule, availability.ClosedMessage)
+	return err
+}
+
+// Delete removes the schedule. If the panel was closed by the schedule, it is re-enabled, and true is returned.
+func (p *PanelAvailabilityTable) Delete(ctx context.Context, panelId int) (bool, error) {
+	var reopened bool
+	err := p.BeginFunc(ctx, func(tx pgx.Tx) error {
+		query := `DELETE FROM panel_availability WHERE "panel_id" = $1 RETURNING "closed_by_schedule";`
+		if err := tx.QueryRow(ctx, query, panelId).Scan(&reopened); err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				return nil
+			}
+
+			return err
+		}
+
+		if reopened {
+			if _, err := tx.Exec(ctx, `UPDATE panels SET "disabled" = false WHERE "panel_id" = $1;`, panelId); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+
+	return reopened, err
+}
+
+// ClaimDue returns up to limit schedules that are due to be applied. Each is leased for the given duration, so that
+// other replicas skip it, and it is retried once the lease expires if Transition is not called.
+func (p *PanelAvailabilityTable) ClaimDue(ctx context.Context, limit int, lease time.Duration) ([]PanelAvailability, error) {
+	query := `
+UPDATE panel_availability
+SET "next_transition" = NOW() + make_interval(secs => $2)
+WHERE "panel_id" IN (
+	SELECT "panel_id"
+	FROM panel_availability
+	WHERE "next_transition" <= NOW()
+	ORDER BY "next_transition" ASC
+	LIMIT $1
+	FOR UPDATE SKIP LOCKED
+)
+RETURNING "panel_id", "schedule", "closed_message", "closed_by_schedule", "next_transition", "version";
+`
+
+	rows, err := p.Query(ctx, query, limit, lease.Seconds())
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	var due []PanelAvailability
+	for rows.Next() {
+		availability, err := scanPanelAvailability(rows)
+		if err != nil {
+			return nil, err
+		}
+
+		due = append(due, availability)
+	}
+
+	return due, rows.Err()
+}
+
+// Transition records the result of applying the given version of the schedule, and when it next needs to be applied.
+// If the schedule has been set again since that version was claimed, the next transition is left as-is, so that the
+// new schedule is still applied on the next run. If disabled is not nil, the panel is enabled or disabled in the same
+// transaction.
+func (p *PanelAvailabilityTable) Transition(ctx context.Context, panelId, version int, disabled *bool, closedBySchedule bool, nextTransition time.Time) error {
+	return p.BeginFunc(ctx, func(tx pgx.Tx) error {
+		query := `
+UPDATE panel_availability
+SET "closed_by_schedule" = $2,
+	"next_transition" = CASE WHEN "version" = $4 THEN $3 ELSE "next_transition" END
+WHERE "panel_id" = $1;
+`
+		if _, err := tx.Exec(ctx, query, panelId, closedBySchedule, nextTransition, version); err != nil {
+			return err
+		}
+
+		if disabled != nil {
+			if _, err := tx.Exec(ctx, `UPDATE panels SET "disabled" = $2 WHERE "panel_id" = $1;`, panelId, *disabled); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
+
+func scanPanelAvailability(row pgx.Row) (PanelAvailability, error) {
+	var availability PanelAvailability
+	var schedule []byte
+	if err := row.Scan(
+		&availability.PanelId,
+		&schedule,
+		&availability.ClosedMessage,
+		&availability.ClosedBySchedule,
+		&availability.NextTransition,
+		&availability.Version,
+	); err != nil {
+		return PanelAvailability{}, err
+	}
+
+	if err := json.Unmarshal(schedule, &availability.Schedule); err != nil {
+		return PanelAvailability{}, err
+	}
+
+	return availability, nil
+}
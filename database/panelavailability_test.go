@@ -0,0 +1,97 @@
+package database
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func testAvailabilitySchedule() PanelAvailabilitySchedule {
+	return PanelAvailabilitySchedule{
+		Timezone: "Europe/London",
+		Windows: []AvailabilityWindow{
+			{Day: time.Monday, Open: "09:00", Close: "17:00"},
+			{Day: time.Tuesday, Open: "09:00", Close: "24:00"},
+			{Day: time.Wednesday, Open: "00:00", Close: "12:00"},
+		},
+		Holidays: []AvailabilityHoliday{{Date: "2024-01-01", Name: "New Year's Day"}},
+	}
+}
+
+func TestPanelAvailabilityValidate(t *testing.T) {
+	assert.NoError(t, testAvailabilitySchedule().Validate())
+
+	tests := map[string]func(*PanelAvailabilitySchedule){
+		"unknown timezone": func(s *PanelAvailabilitySchedule) { s.Timezone = "Mars/Olympus_Mons" },
+		"empty timezone":   func(s *PanelAvailabilitySchedule) { s.Timezone = "" },
+		"invalid day":      func(s *PanelAvailabilitySchedule) { s.Windows[0].Day = 7 },
+		"invalid time":     func(s *PanelAvailabilitySchedule) { s.Windows[0].Open = "9:00" },
+		"past midnight":    func(s *PanelAvailabilitySchedule) { s.Windows[0].Close = "24:01" },
+		"open after close": func(s *PanelAvailabilitySchedule) { s.Windows[0].Open = "18:00" },
+		"invalid holiday":  func(s *PanelAvailabilitySchedule) { s.Holidays[0].Date = "01/01/2024" },
+	}
+
+	for name, modify := range tests {
+		t.Run(name, func(t *testing.T) {
+			schedule := testAvailabilitySchedule()
+			modify(&schedule)
+			assert.Error(t, schedule.Validate())
+		})
+	}
+}
+
+func TestPanelAvailabilityIsOpen(t *testing.T) {
+	schedule := testAvailabilitySchedule()
+	london, _ := time.LoadLocation("Europe/London")
+
+	// 2024-03-04 is a Monday
+	assert.False(t, schedule.IsOpen(time.Date(2024, 3, 4, 8, 59, 0, 0, london)))
+	assert.True(t, schedule.IsOpen(time.Date(2024, 3, 4, 9, 0, 0, 0, london)))
+	assert.False(t, schedule.IsOpen(time.Date(2024, 3, 4, 17, 0, 0, 0, london)))
+	assert.True(t, schedule.IsOpen(time.Date(2024, 3, 5, 23, 59, 0, 0, london)))
+	assert.False(t, schedule.IsOpen(time.Date(2024, 3, 7, 10, 0, 0, 0, london)))
+
+	// Times are compared in the schedule's timezone
+	assert.True(t, schedule.IsOpen(time.Date(2024, 3, 4, 4, 0, 0, 0, time.FixedZone("EST", -5*60*60))))
+
+	// 2024-01-01 is a Monday, but a holiday
+	assert.False(t, schedule.IsOpen(time.Date(2024, 1, 1, 12, 0, 0, 0, london)))
+}
+
+func TestPanelAvailabilityNextTransition(t *testing.T) {
+	schedule := testAvailabilitySchedule()
+	london, _ := time.LoadLocation("Europe/London")
+
+	next, ok := schedule.NextTransition(time.Date(2024, 3, 4, 12, 0, 0, 0, london))
+	assert.True(t, ok)
+	assert.Equal(t, time.Date(2024, 3, 4, 17, 0, 0, 0, london), next)
+
+	next, ok = schedule.NextTransition(time.Date(2024, 3, 4, 17, 0, 0, 0, london))
+	assert.True(t, ok)
+	assert.Equal(t, time.Date(2024, 3, 5, 9, 0, 0, 0, london), next)
+
+	// Windows running into the next day are merged
+	next, ok = schedule.NextTransition(time.Date(2024, 3, 5, 12, 0, 0, 0, london))
+	assert.True(t, ok)
+	assert.Equal(t, time.Date(2024, 3, 6, 12, 0, 0, 0, london), next)
+
+	// Skips the New Year's Day holiday, through to the Tuesday
+	next, ok = schedule.NextTransition(time.Date(2023, 12, 31, 12, 0, 0, 0, london))
+	assert.True(t, ok)
+	assert.Equal(t, time.Date(2024, 1, 2, 9, 0, 0, 0, london), next)
+
+	// Clocks go forward on 2024-03-31, so 09:00 on the Monday is 08:00 UTC
+	next, ok = schedule.NextTransition(time.Date(2024, 3, 30, 12, 0, 0, 0, london))
+	assert.True(t, ok)
+	assert.Equal(t, time.Date(2024, 4, 1, 8, 0, 0, 0, time.UTC), next.UTC())
+}
+
+func TestPanelAvailabilityNoTransition(t *testing.T) {
+	schedule := PanelAvailabilitySchedule{Timezone: "UTC"}
+
+	from := time.Date(2024, 3, 4, 12, 0, 0, 0, time.UTC)
+	next, ok := schedule.NextTransition(from)
+	assert.False(t, ok)
+	assert.Equal(t, time.Date(2025, 3, 5, 0, 0, 0, 0, time.UTC), next)
+}
@@ -0,0 +1,124 @@
+package database
+
+import (
+	"context"
+	"time"
+
+	"github.com/jackc/pgx/v4"
+	"github.com/jackc/pgx/v4/pgxpool"
+)
+
+// PanelSla holds the response time targets for tickets opened from a panel. A nil target is not enforced.
+type PanelSla struct {
+	PanelId           int
+	FirstResponseTime *time.Duration
+	FollowUpTime      *time.Duration
+}
+
+type PanelSlaTable struct {
+	*pgxpool.Pool
+}
+
+func newPanelSlaTable(db *pgxpool.Pool) *PanelSlaTable {
+	return &PanelSlaTable{
+		db,
+	}
+}
+
+func (p PanelSlaTable) Schema() string {
+	return `
+CREATE TABLE IF NOT EXISTS panel_sla(
+	"panel_id" int NOT NULL,
+	"first_response_seconds" int4,
+	"follow_up_seconds" int4,
+	FOREIGN KEY("panel_id") REFERENCES panels("panel_id") ON DELETE CASCADE ON UPDATE CASCADE,
+	PRIMARY KEY("panel_id")
+);
+`
+}
+
+func (p *PanelSlaTable) Get(ctx context.Context, panelId int) (PanelSla, bool, error) {
+	query := `SELECT "panel_id", "first_response_seconds", "follow_up_seconds" FROM panel_sla WHERE "panel_id" = $1;`
+
+	var firstResponse, followUp *int
+	sla := PanelSla{PanelId: panelId}
+	if err := p.QueryRow(ctx, query, panelId).Scan(&sla.PanelId, &firstResponse, &followUp); err != nil {
+		if err == pgx.ErrNoRows {
+			return sla, false, nil
+		} else {
+			return PanelSla{}, false, err
+		}
+	}
+
+	sla.FirstResponseTime = secondsToDuration(firstResponse)
+	sla.FollowUpTime = secondsToDuration(followUp)
+
+	return sla, true, nil
+}
+
+// GetByGuild returns a map of panel ID -> SLA settings, for panels that have SLA settings
+func (p *PanelSlaTable) GetByGuild(ctx context.Context, guildId uint64) (map[int]PanelSla, error) {
+	query := `
+SELECT panel_sla.panel_id, panel_sla.first_response_seconds, panel_sla.follow_up_seconds
+FROM panel_sla
+INNER JOIN panels ON panel_sla.panel_id = panels.panel_id
+WHERE panels.guild_id = $1;
+`
+
+	rows, err := p.Query(ctx, query, guildId)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	settings := make(map[int]PanelSla)
+	for rows.Next() {
+		var sla PanelSla
+		var firstResponse, followUp *int
+		if err := rows.Scan(&sla.PanelId, &firstResponse, &followUp); err != nil {
+			return nil, err
+		}
+
+		sla.FirstResponseTime = secondsToDuration(firstResponse)
+		sla.FollowUpTime = secondsToDuration(followUp)
+		settings[sla.PanelId] = sla
+	}
+
+	return settings, nil
+}
+
+func (p *PanelSlaTable) Set(ctx context.Context, sla PanelSla) (err error) {
+	query := `
+INSERT INTO panel_sla("panel_id", "first_response_seconds", "follow_up_seconds")
+VALUES($1, $2, $3)
+ON CONFLICT("panel_id") DO UPDATE SET "first_response_seconds" = $2, "follow_up_seconds" = $3;
+`
+
+	_, err = p.Exec(ctx, query, sla.PanelId, durationToSeconds(sla.FirstResponseTime), durationToSeconds(sla.FollowUpTime))
+	return
+}
+
+func (p *PanelSlaTable) Delete(ctx context.Context, panelId int) (err error) {
+	query := `DELETE FROM panel_sla WHERE "panel_id" = $1;`
+	_, err = p.Exec(ctx, query, panelId)
+	return
+}
+
+func secondsToDuration(seconds *int) *time.Duration {
+	if seconds == nil {
+		return nil
+	}
+
+	duration := time.Duration(*seconds) * time.Second
+	return &duration
+}
+
+func durationToSeconds(duration *time.Duration) *int {
+	if duration == nil {
+		return nil
+	}
+
+	seconds := int(duration.Seconds())
+	return &seconds
+}
@@ -0,0 +1,89 @@
+package database
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/TicketsBot-cloud/database"
+	"github.com/jackc/pgx/v4"
+	"github.com/jackc/pgx/v4/pgxpool"
+)
+
+// PanelTemplateTable provides the inserts needed to import a panel template that the shared database module does
+// not offer within a transaction. It does not own any tables, and its queries must write the same columns as the
+// shared module's, which is checked against the shared schema in the tests.
+// TODO: Replace with FormsTable.CreateWithTx and MultiPanelTable.CreateWithTx once the shared module provides them
+type PanelTemplateTable struct {
+	*pgxpool.Pool
+}
+
+func newPanelTemplateTable(db *pgxpool.Pool) *PanelTemplateTable {
+	return &PanelTemplateTable{
+		db,
+	}
+}
+
+const (
+	createFormQuery = `
+INSERT INTO forms("guild_id", "title", "custom_id")
+VALUES($1, $2, $3)
+RETURNING "form_id";
+`
+
+	createMultiPanelQuery = `
+INSERT INTO multi_panels("message_id", "channel_id", "guild_id", "select_menu", "select_menu_placeholder", "embed")
+VALUES($1, $2, $3, $4, $5, $6)
+RETURNING "id";
+`
+
+	insertMultiPanelTargetQuery = `
+INSERT INTO multi_panel_targets("multi_panel_id", "panel_id")
+VALUES($1, $2)
+ON CONFLICT("multi_panel_id", "panel_id") DO NOTHING;
+`
+)
+
+// CreateFormWithTx is the transactional equivalent of FormsTable.Create
+func (p *PanelTemplateTable) CreateFormWithTx(ctx context.Context, tx pgx.Tx, guildId uint64, title, customId string) (int, error) {
+	var id int
+	if err := tx.QueryRow(ctx, createFormQuery, guildId, title, customId).Scan(&id); err != nil {
+		return 0, err
+	}
+
+	return id, nil
+}
+
+// CreateMultiPanelWithTx stores the multi-panel, and the panels it contains, in the same format as
+// MultiPanelTable.Create and MultiPanelTargets.Insert
+func (p *PanelTemplateTable) CreateMultiPanelWithTx(ctx context.Context, tx pgx.Tx, multiPanel database.MultiPanel, panelIds []int) (int, error) {
+	var embed *string
+	if multiPanel.Embed != nil {
+		encoded, err := json.Marshal(multiPanel.Embed)
+		if err != nil {
+			return 0, err
+		}
+
+		str := string(encoded)
+		embed = &str
+	}
+
+	var multiPanelId int
+	if err := tx.QueryRow(ctx, createMultiPanelQuery,
+		multiPanel.MessageId,
+		multiPanel.ChannelId,
+		multiPanel.GuildId,
+		multiPanel.SelectMenu,
+		multiPanel.SelectMenuPlaceholder,
+		embed,
+	).Scan(&multiPanelId); err != nil {
+		return 0, err
+	}
+
+	for _, panelId := range panelIds {
+		if _, err := tx.Exec(ctx, insertMultiPanelTargetQuery, multiPanelId, panelId); err != nil {
+			return 0, err
+		}
+	}
+
+	return multiPanelId, nil
+}
@@ -0,0 +1,37 @@
+package database
+
+import (
+	"regexp"
+	"testing"
+
+	"github.com/TicketsBot-cloud/database"
+	"github.com/stretchr/testify/assert"
+)
+
+var insertColumnsPattern = regexp.MustCompile(`INSERT INTO \w+\(([^)]*)\)`)
+var columnPattern = regexp.MustCompile(`"\w+"`)
+
+// The panel template queries duplicate inserts from the shared database module, so check that every column they write
+// still exists in its schema
+func TestPanelTemplateQueriesMatchSharedSchema(t *testing.T) {
+	cases := []struct {
+		query  string
+		schema string
+	}{
+		{createFormQuery, database.FormsTable{}.Schema()},
+		{createMultiPanelQuery, database.MultiPanelTable{}.Schema()},
+		{insertMultiPanelTargetQuery, database.MultiPanelTargets{}.Schema()},
+	}
+
+	for _, c := range cases {
+		match := insertColumnsPattern.FindStringSubmatch(c.query)
+		if !assert.NotNil(t, match, c.query) {
+			continue
+		}
+
+		for _, column := range columnPattern.FindAllString(match[1], -1) {
+			definition := regexp.MustCompile(`(?m)^\s*` + column + `\s`)
+			assert.Regexp(t, definition, c.schema, "column %s missing from shared schema", column)
+		}
+	}
+}
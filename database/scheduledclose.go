@@ -0,0 +1,194 @@
+package database
+
+import (
+	"context"
+	"time"
+
+	"github.com/jackc/pgx/v4"
+	"github.com/jackc/pgx/v4/pgxpool"
+)
+
+type ScheduledClose struct {
+	GuildId       uint64    `json:"guild_id,string"`
+	TicketId      int       `json:"ticket_id"`
+	UserId        uint64    `json:"user_id,string"`
+	Reason        *string   `json:"reason"`
+	CloseAt       time.Time `json:"close_at"`
+	CancelOnReply bool      `json:"cancel_on_reply"`
+	CreatedAt     time.Time `json:"created_at"`
+
+	// Attempts is the number of times closing the ticket has failed
+	Attempts int `json:"-"`
+}
+
+type ScheduledCloseTable struct {
+	*pgxpool.Pool
+}
+
+func newScheduledCloseTable(db *pgxpool.Pool) *ScheduledCloseTable {
+	return &ScheduledCloseTable{
+		db,
+	}
+}
+
+func (s ScheduledCloseTable) Schema() string {
+	return `
+CREATE TABLE IF NOT EXISTS ticket_scheduled_close(
+	"guild_id" int8 NOT NULL,
+	"ticket_id" int4 NOT NULL,
+	"user_id" int8 NOT NULL,
+	"reason" TEXT,
+	"close_at" timestamptz NOT NULL,
+	"cancel_on_reply" bool NOT NULL,
+	"created_at" timestamptz NOT NULL DEFAULT NOW(),
+	"attempts" int4 NOT NULL DEFAULT 0,
+	FOREIGN KEY("guild_id", "ticket_id") REFERENCES tickets("guild_id", "id") ON DELETE CASCADE,
+	PRIMARY KEY("guild_id", "ticket_id")
+);
+CREATE INDEX IF NOT EXISTS ticket_scheduled_close_close_at ON ticket_scheduled_close("close_at");
+`
+}
+
+func (s *ScheduledCloseTable) Get(ctx context.Context, guildId uint64, ticketId int) (ScheduledClose, bool, error) {
+	query := `
+SELECT "guild_id", "ticket_id", "user_id", "reason", "close_at", "cancel_on_reply", "created_at"
+FROM ticket_scheduled_close
+WHERE "guild_id" = $1 AND "ticket_id" = $2;
+`
+
+	var data ScheduledClose
+	if err := s.QueryRow(ctx, query, guildId, ticketId).Scan(
+		&data.GuildId, &data.TicketId, &data.UserId, &data.Reason, &data.CloseAt, &data.CancelOnReply, &data.CreatedAt,
+	); err != nil {
+		if err == pgx.ErrNoRows {
+			return ScheduledClose{}, false, nil
+		} else {
+			return ScheduledClose{}, false, err
+		}
+	}
+
+	return data, true, nil
+}
+
+// GetByGuild returns a map of ticket ID -> scheduled close
+func (s *ScheduledCloseTable) GetByGuild(ctx context.Context, guildId uint64) (map[int]ScheduledClose, error) {
+	query := `
+SELECT "guild_id", "ticket_id", "user_id", "reason", "close_at", "cancel_on_reply", "created_at"
+FROM ticket_scheduled_close
+WHERE "guild_id" = $1;
+`
+
+	rows, err := s.Query(ctx, query, guildId)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	scheduled := make(map[int]ScheduledClose)
+	for rows.Next() {
+		var data ScheduledClose
+		if err := rows.Scan(
+			&data.GuildId, &data.TicketId, &data.UserId, &data.Reason, &data.CloseAt, &data.CancelOnReply, &data.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+
+		scheduled[data.TicketId] = data
+	}
+
+	return scheduled, nil
+}
+
+func (s *ScheduledCloseTable) Set(ctx context.Context, data ScheduledClose) (err error) {
+	query := `
+INSERT INTO ticket_scheduled_close("guild_id", "ticket_id", "user_id", "reason", "close_at", "cancel_on_reply", "created_at")
+VALUES($1, $2, $3, $4, $5, $6, NOW())
+ON CONFLICT("guild_id", "ticket_id") DO UPDATE SET
+	"user_id" = $3,
+	"reason" = $4,
+	"close_at" = $5,
+	"cancel_on_reply" = $6,
+	"created_at" = NOW(),
+	"attempts" = 0;
+`
+
+	_, err = s.Exec(ctx, query, data.GuildId, data.TicketId, data.UserId, data.Reason, data.CloseAt, data.CancelOnReply)
+	return
+}
+
+func (s *ScheduledCloseTable) Delete(ctx context.Context, guildId uint64, ticketId int) (err error) {
+	query := `DELETE FROM ticket_scheduled_close WHERE "guild_id" = $1 AND "ticket_id" = $2;`
+	_, err = s.Exec(ctx, query, guildId, ticketId)
+	return
+}
+
+// Retry puts back a claimed close that failed, to be retried at the given time. The original creation time is kept, so
+// that replies sent since the close was scheduled still cancel it. If the close has been rescheduled in the meantime,
+// the new close is kept instead.
+func (s *ScheduledCloseTable) Retry(ctx context.Context, data ScheduledClose, retryAt time.Time) (err error) {
+	query := `
+INSERT INTO ticket_scheduled_close("guild_id", "ticket_id", "user_id", "reason", "close_at", "cancel_on_reply", "created_at", "attempts")
+VALUES($1, $2, $3, $4, $5, $6, $7, $8)
+ON CONFLICT("guild_id", "ticket_id") DO NOTHING;
+`
+
+	_, err = s.Exec(ctx, query, data.GuildId, data.TicketId, data.UserId, data.Reason, retryAt, data.CancelOnReply,
+		data.CreatedAt, data.Attempts+1)
+	return
+}
+
+// CancelOnReply removes the scheduled close for the ticket if it was scheduled to be cancelled when the ticket opener
+// responds, returning whether a scheduled close was removed
+func (s *ScheduledCloseTable) CancelOnReply(ctx context.Context, guildId uint64, ticketId int, repliedAt time.Time) (bool, error) {
+	query := `
+DELETE FROM ticket_scheduled_close
+WHERE "guild_id" = $1 AND "ticket_id" = $2 AND "cancel_on_reply" = true AND "created_at" < $3;
+`
+
+	res, err := s.Exec(ctx, query, guildId, ticketId, repliedAt)
+	if err != nil {
+		return false, err
+	}
+
+	return res.RowsAffected() > 0, nil
+}
+
+// ClaimDue atomically removes and returns up to limit scheduled closes that are due. Rows locked by another replica
+// are skipped, so each scheduled close is only returned once, even when many replicas are polling concurrently.
+func (s *ScheduledCloseTable) ClaimDue(ctx context.Context, limit int) ([]ScheduledClose, error) {
+	query := `
+DELETE FROM ticket_scheduled_close
+WHERE ("guild_id", "ticket_id") IN (
+	SELECT "guild_id", "ticket_id"
+	FROM ticket_scheduled_close
+	WHERE "close_at" <= NOW()
+	ORDER BY "close_at" ASC
+	LIMIT $1
+	FOR UPDATE SKIP LOCKED
+)
+RETURNING "guild_id", "ticket_id", "user_id", "reason", "close_at", "cancel_on_reply", "created_at", "attempts";
+`
+
+	rows, err := s.Query(ctx, query, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	var due []ScheduledClose
+	for rows.Next() {
+		var data ScheduledClose
+		if err := rows.Scan(
+			&data.GuildId, &data.TicketId, &data.UserId, &data.Reason, &data.CloseAt, &data.CancelOnReply, &data.CreatedAt,
+			&data.Attempts,
+		); err != nil {
+			return nil, err
+		}
+
+		due = append(due, data)
+	}
+
+	return due, rows.Err()
+}
@@ -0,0 +1,206 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/TicketsBot-cloud/database"
+)
+
+type TicketExportRow struct {
+	TicketId    int        `json:"ticket_id"`
+	UserId      uint64     `json:"user_id,string"`
+	ClaimedBy   *uint64    `json:"claimed_by,string"`
+	PanelId     *int       `json:"panel_id"`
+	PanelTitle  *string    `json:"panel_title"`
+	Open        bool       `json:"open"`
+	OpenTime    time.Time  `json:"open_time"`
+	CloseTime   *time.Time `json:"close_time"`
+	CloseReason *string    `json:"close_reason"`
+	ClosedBy    *uint64    `json:"closed_by,string"`
+	Rating      *uint8     `json:"rating"`
+}
+
+// exportCursor is the position of the last ticket in the previous batch of an export
+type exportCursor struct {
+	ticketId int
+	value    interface{} // The value of the sort column, or nil if it is NULL
+}
+
+// Export reads every ticket matching the options, ignoring the limit and offset, passing them to f in batches of up
+// to batchSize. Each batch is read with a separate query, using the position of the last ticket in the previous batch,
+// so that a connection is not held while f runs.
+func (t *TicketQueryTable) Export(ctx context.Context, options TicketQueryOptions, batchSize int, f func([]TicketExportRow) error) error {
+	var cursor *exportCursor
+	for {
+		batch, err := t.exportBatch(ctx, options, cursor, batchSize)
+		if err != nil {
+			return err
+		}
+
+		if len(batch) > 0 {
+			if err := f(batch); err != nil {
+				return err
+			}
+		}
+
+		if len(batch) < batchSize {
+			return nil
+		}
+
+		last := batch[len(batch)-1]
+		cursor = &exportCursor{
+			ticketId: last.TicketId,
+			value:    options.SortBy.exportValue(last),
+		}
+	}
+}
+
+func (t *TicketQueryTable) exportBatch(ctx context.Context, options TicketQueryOptions, cursor *exportCursor, limit int) ([]TicketExportRow, error) {
+	query, args, err := options.buildExportQuery(cursor, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := t.Query(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	batch := make([]TicketExportRow, 0, limit)
+	for rows.Next() {
+		var row TicketExportRow
+		if err := rows.Scan(
+			&row.TicketId,
+			&row.UserId,
+			&row.ClaimedBy,
+			&row.PanelId,
+			&row.PanelTitle,
+			&row.Open,
+			&row.OpenTime,
+			&row.CloseTime,
+			&row.CloseReason,
+			&row.ClosedBy,
+			&row.Rating,
+		); err != nil {
+			return nil, err
+		}
+
+		batch = append(batch, row)
+	}
+
+	return batch, rows.Err()
+}
+
+// exportExpression returns the sort column in the export query, or an empty string when sorting by ticket ID
+func (c TicketSortColumn) exportExpression() string {
+	switch c {
+	case TicketSortColumnOpenTime:
+		return "tickets.open_time"
+	case TicketSortColumnCloseTime:
+		return "tickets.close_time"
+	case TicketSortColumnRating:
+		return "export_ratings.rating"
+	default:
+		return ""
+	}
+}
+
+// exportValue returns the value of the sort column for the row, or nil if it is NULL
+func (c TicketSortColumn) exportValue(row TicketExportRow) interface{} {
+	switch c {
+	case TicketSortColumnOpenTime:
+		return row.OpenTime
+	case TicketSortColumnCloseTime:
+		if row.CloseTime == nil {
+			return nil
+		}
+
+		return *row.CloseTime
+	case TicketSortColumnRating:
+		if row.Rating == nil {
+			return nil
+		}
+
+		return int(*row.Rating)
+	default:
+		return nil
+	}
+}
+
+// The export tables are aliased, as the filters may also join the same tables
+func (o TicketQueryOptions) buildExportQuery(cursor *exportCursor, limit int) (query string, args []interface{}, _err error) {
+	query = `
+SELECT tickets.id,
+	tickets.user_id,
+	export_claims.user_id,
+	tickets.panel_id,
+	export_panels.title,
+	tickets.open,
+	tickets.open_time,
+	tickets.close_time,
+	export_close_reason.close_reason,
+	export_close_reason.closed_by,
+	export_ratings.rating
+FROM tickets
+LEFT JOIN ticket_claims export_claims ON tickets.guild_id = export_claims.guild_id AND tickets.id = export_claims.ticket_id
+LEFT JOIN panels export_panels ON tickets.panel_id = export_panels.panel_id
+LEFT JOIN close_reason export_close_reason ON tickets.guild_id = export_close_reason.guild_id AND tickets.id = export_close_reason.ticket_id
+LEFT JOIN service_ratings export_ratings ON tickets.guild_id = export_ratings.guild_id AND tickets.id = export_ratings.ticket_id`
+
+	joins, conditions, args, err := o.buildFilters()
+	if err != nil {
+		return "", nil, err
+	}
+
+	query += joins
+
+	// Cannot use prepared statement for this value
+	order := o.Order
+	if order != database.OrderTypeAscending && order != database.OrderTypeDescending {
+		order = database.OrderTypeAscending
+	}
+
+	comparison := ">"
+	if order == database.OrderTypeDescending {
+		comparison = "<"
+	}
+
+	expression := o.SortBy.exportExpression()
+
+	// Continue from the last ticket of the previous batch. Tickets without a value for the sort column come last.
+	if cursor != nil {
+		args = append(args, cursor.ticketId)
+		idArg := len(args)
+
+		if expression == "" {
+			conditions = append(conditions, fmt.Sprintf(`tickets.id %s $%d`, comparison, idArg))
+		} else if cursor.value == nil {
+			conditions = append(conditions, fmt.Sprintf(`(%s IS NULL AND tickets.id %s $%d)`, expression, comparison, idArg))
+		} else {
+			args = append(args, cursor.value)
+			conditions = append(conditions, fmt.Sprintf(
+				`(%[1]s %[2]s $%[4]d OR (%[1]s = $%[4]d AND tickets.id %[2]s $%[3]d) OR %[1]s IS NULL)`,
+				expression, comparison, idArg, len(args),
+			))
+		}
+	}
+
+	if len(conditions) > 0 {
+		query += " WHERE " + strings.Join(conditions, " AND ")
+	}
+
+	if expression == "" {
+		query += fmt.Sprintf(" ORDER BY tickets.id %s", order)
+	} else {
+		query += fmt.Sprintf(" ORDER BY %s %s NULLS LAST, tickets.id %s", expression, order, order)
+	}
+
+	args = append(args, limit)
+	query += fmt.Sprintf(" LIMIT $%d;", len(args))
+	return
+}
@@ -0,0 +1,59 @@
+package database
+
+import (
+	"testing"
+	"time"
+
+	"github.com/TicketsBot-cloud/database"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBuildExportQueryFirstBatch(t *testing.T) {
+	opts := TicketQueryOptions{
+		TicketQueryOptions: database.TicketQueryOptions{GuildId: 1, Order: database.OrderTypeDescending},
+	}
+
+	query, args, err := opts.buildExportQuery(nil, 500)
+	assert.NoError(t, err)
+	assert.Contains(t, query, "WHERE tickets.guild_id = $1 ORDER BY tickets.id DESC LIMIT $2;")
+	assert.Equal(t, []interface{}{uint64(1), 500}, args)
+}
+
+func TestBuildExportQueryContinuesById(t *testing.T) {
+	opts := TicketQueryOptions{
+		TicketQueryOptions: database.TicketQueryOptions{GuildId: 1, Order: database.OrderTypeAscending},
+	}
+
+	query, args, err := opts.buildExportQuery(&exportCursor{ticketId: 10}, 500)
+	assert.NoError(t, err)
+	assert.Contains(t, query, "tickets.id > $2 ORDER BY tickets.id ASC LIMIT $3;")
+	assert.Equal(t, []interface{}{uint64(1), 10, 500}, args)
+}
+
+func TestBuildExportQueryContinuesBySortColumn(t *testing.T) {
+	closeTime := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	opts := TicketQueryOptions{
+		TicketQueryOptions: database.TicketQueryOptions{GuildId: 1, Order: database.OrderTypeDescending},
+		SortBy:             TicketSortColumnCloseTime,
+	}
+
+	query, args, err := opts.buildExportQuery(&exportCursor{ticketId: 10, value: closeTime}, 500)
+	assert.NoError(t, err)
+	assert.Contains(t, query, "(tickets.close_time < $3 OR (tickets.close_time = $3 AND tickets.id < $2) OR tickets.close_time IS NULL)")
+	assert.Contains(t, query, "ORDER BY tickets.close_time DESC NULLS LAST, tickets.id DESC LIMIT $4;")
+	assert.Equal(t, []interface{}{uint64(1), 10, closeTime, 500}, args)
+
+	// Once the tickets without a close time are reached, only those remain
+	query, _, err = opts.buildExportQuery(&exportCursor{ticketId: 10}, 500)
+	assert.NoError(t, err)
+	assert.Contains(t, query, "(tickets.close_time IS NULL AND tickets.id < $2)")
+}
+
+func TestExportValue(t *testing.T) {
+	rating := uint8(4)
+	row := TicketExportRow{TicketId: 1, Rating: &rating}
+
+	assert.Equal(t, 4, TicketSortColumnRating.exportValue(row))
+	assert.Nil(t, TicketSortColumnCloseTime.exportValue(row))
+	assert.Nil(t, TicketSortColumnId.exportValue(row))
+}
@@ -0,0 +1,121 @@
+package database
+
+import (
+	"context"
+
+	"github.com/jackc/pgtype"
+	"github.com/jackc/pgx/v4"
+	"github.com/jackc/pgx/v4/pgxpool"
+)
+
+type TicketLabelAssignmentsTable struct {
+	*pgxpool.Pool
+}
+
+func newTicketLabelAssignmentsTable(db *pgxpool.Pool) *TicketLabelAssignmentsTable {
+	return &TicketLabelAssignmentsTable{
+		db,
+	}
+}
+
+func (t TicketLabelAssignmentsTable) Schema() string {
+	return `
+CREATE TABLE IF NOT EXISTS ticket_label_assignments(
+	"guild_id" int8 NOT NULL,
+	"ticket_id" int4 NOT NULL,
+	"label_id" int4 NOT NULL,
+	FOREIGN KEY("guild_id", "ticket_id") REFERENCES tickets("guild_id", "id") ON DELETE CASCADE,
+	FOREIGN KEY("label_id") REFERENCES ticket_labels("label_id") ON DELETE CASCADE,
+	PRIMARY KEY("guild_id", "ticket_id", "label_id")
+);
+CREATE INDEX IF NOT EXISTS ticket_label_assignments_label_id ON ticket_label_assignments("label_id");
+`
+}
+
+func (t *TicketLabelAssignmentsTable) Get(ctx context.Context, guildId uint64, ticketId int) ([]int, error) {
+	query := `
+SELECT "label_id"
+FROM ticket_label_assignments
+WHERE "guild_id" = $1 AND "ticket_id" = $2
+ORDER BY "label_id" ASC;
+`
+
+	rows, err := t.Query(ctx, query, guildId, ticketId)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	labelIds := make([]int, 0)
+	for rows.Next() {
+		var labelId int
+		if err := rows.Scan(&labelId); err != nil {
+			return nil, err
+		}
+
+		labelIds = append(labelIds, labelId)
+	}
+
+	return labelIds, nil
+}
+
+// GetMulti returns a map of ticket ID -> label IDs. Tickets without any labels are not included in the map.
+func (t *TicketLabelAssignmentsTable) GetMulti(ctx context.Context, guildId uint64, ticketIds []int) (map[int][]int, error) {
+	query := `
+SELECT "ticket_id", "label_id"
+FROM ticket_label_assignments
+WHERE "guild_id" = $1 AND "ticket_id" = ANY($2)
+ORDER BY "label_id" ASC;
+`
+
+	array := &pgtype.Int4Array{}
+	if err := array.Set(ticketIds); err != nil {
+		return nil, err
+	}
+
+	rows, err := t.Query(ctx, query, guildId, array)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	labels := make(map[int][]int)
+	for rows.Next() {
+		var ticketId, labelId int
+		if err := rows.Scan(&ticketId, &labelId); err != nil {
+			return nil, err
+		}
+
+		labels[ticketId] = append(labels[ticketId], labelId)
+	}
+
+	return labels, nil
+}
+
+func (t *TicketLabelAssignmentsTable) Replace(ctx context.Context, guildId uint64, ticketId int, labelIds []int) error {
+	return t.BeginFunc(ctx, func(tx pgx.Tx) error {
+		return t.ReplaceWithTx(ctx, tx, guildId, ticketId, labelIds)
+	})
+}
+
+func (t *TicketLabelAssignmentsTable) ReplaceWithTx(ctx context.Context, tx pgx.Tx, guildId uint64, ticketId int, labelIds []int) error {
+	if _, err := tx.Exec(ctx, `DELETE FROM ticket_label_assignments WHERE "guild_id" = $1 AND "ticket_id" = $2;`, guildId, ticketId); err != nil {
+		return err
+	}
+
+	query := `
+INSERT INTO ticket_label_assignments("guild_id", "ticket_id", "label_id")
+VALUES($1, $2, $3)
+ON CONFLICT("guild_id", "ticket_id", "label_id") DO NOTHING;
+`
+
+	for _, labelId := range labelIds {
+		if _, err := tx.Exec(ctx, query, guildId, ticketId, labelId); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
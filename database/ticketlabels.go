@@ -0,0 +1,162 @@
+package database
+
+import (
+	"context"
+	"errors"
+
+	"github.com/jackc/pgconn"
+	"github.com/jackc/pgtype"
+	"github.com/jackc/pgx/v4"
+	"github.com/jackc/pgx/v4/pgxpool"
+)
+
+type TicketLabel struct {
+	Id      int    `json:"id"`
+	GuildId uint64 `json:"guild_id,string"`
+	Name    string `json:"name"`
+	Colour  uint32 `json:"colour"`
+}
+
+// ErrLabelNameTaken is returned when the guild already has a label with the same name, ignoring case
+var ErrLabelNameTaken = errors.New("a label with this name already exists")
+
+type TicketLabelsTable struct {
+	*pgxpool.Pool
+}
+
+func newTicketLabelsTable(db *pgxpool.Pool) *TicketLabelsTable {
+	return &TicketLabelsTable{
+		db,
+	}
+}
+
+func (t TicketLabelsTable) Schema() string {
+	return `
+CREATE TABLE IF NOT EXISTS ticket_labels(
+	"label_id" SERIAL NOT NULL UNIQUE,
+	"guild_id" int8 NOT NULL,
+	"name" VARCHAR(32) NOT NULL,
+	"colour" int4 NOT NULL,
+	PRIMARY KEY("label_id")
+);
+CREATE INDEX IF NOT EXISTS ticket_labels_guild_id ON ticket_labels("guild_id");
+CREATE UNIQUE INDEX IF NOT EXISTS ticket_labels_guild_id_name ON ticket_labels("guild_id", lower("name"));
+`
+}
+
+func (t *TicketLabelsTable) Get(ctx context.Context, guildId uint64, labelId int) (TicketLabel, bool, error) {
+	query := `
+SELECT "label_id", "guild_id", "name", "colour"
+FROM ticket_labels
+WHERE "guild_id" = $1 AND "label_id" = $2;
+`
+
+	var label TicketLabel
+	if err := t.QueryRow(ctx, query, guildId, labelId).Scan(&label.Id, &label.GuildId, &label.Name, &label.Colour); err != nil {
+		if err == pgx.ErrNoRows {
+			return TicketLabel{}, false, nil
+		} else {
+			return TicketLabel{}, false, err
+		}
+	}
+
+	return label, true, nil
+}
+
+func (t *TicketLabelsTable) GetByGuild(ctx context.Context, guildId uint64) ([]TicketLabel, error) {
+	query := `
+SELECT "label_id", "guild_id", "name", "colour"
+FROM ticket_labels
+WHERE "guild_id" = $1
+ORDER BY "label_id" ASC;
+`
+
+	rows, err := t.Query(ctx, query, guildId)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	labels := make([]TicketLabel, 0)
+	for rows.Next() {
+		var label TicketLabel
+		if err := rows.Scan(&label.Id, &label.GuildId, &label.Name, &label.Colour); err != nil {
+			return nil, err
+		}
+
+		labels = append(labels, label)
+	}
+
+	return labels, nil
+}
+
+func (t *TicketLabelsTable) GetCount(ctx context.Context, guildId uint64) (count int, err error) {
+	query := `SELECT COUNT(*) FROM ticket_labels WHERE "guild_id" = $1;`
+	err = t.QueryRow(ctx, query, guildId).Scan(&count)
+	return
+}
+
+func (t *TicketLabelsTable) AllExistForGuild(ctx context.Context, guildId uint64, labelIds []int) (valid bool, err error) {
+	query := `
+SELECT COUNT(DISTINCT "label_id") = cardinality($2::int4[])
+FROM ticket_labels
+WHERE "guild_id" = $1 AND "label_id" = ANY($2);
+`
+
+	array := &pgtype.Int4Array{}
+	if err := array.Set(labelIds); err != nil {
+		return false, err
+	}
+
+	err = t.QueryRow(ctx, query, guildId, array).Scan(&valid)
+	return
+}
+
+// Create inserts the label, returning ErrLabelNameTaken if the name is already in use
+func (t *TicketLabelsTable) Create(ctx context.Context, guildId uint64, name string, colour uint32) (int, error) {
+	query := `
+INSERT INTO ticket_labels("guild_id", "name", "colour")
+VALUES($1, $2, $3)
+RETURNING "label_id";
+`
+
+	var id int
+	if err := t.QueryRow(ctx, query, guildId, name, colour).Scan(&id); err != nil {
+		return 0, wrapLabelNameTaken(err)
+	}
+
+	return id, nil
+}
+
+// Update returns ErrLabelNameTaken if the new name is already in use by another label
+func (t *TicketLabelsTable) Update(ctx context.Context, label TicketLabel) error {
+	query := `
+UPDATE ticket_labels
+SET "name" = $3, "colour" = $4
+WHERE "guild_id" = $1 AND "label_id" = $2;
+`
+
+	_, err := t.Exec(ctx, query, label.GuildId, label.Id, label.Name, label.Colour)
+	return wrapLabelNameTaken(err)
+}
+
+// Delete returns false if the guild has no label with the given ID
+func (t *TicketLabelsTable) Delete(ctx context.Context, guildId uint64, labelId int) (bool, error) {
+	query := `DELETE FROM ticket_labels WHERE "guild_id" = $1 AND "label_id" = $2;`
+	res, err := t.Exec(ctx, query, guildId, labelId)
+	if err != nil {
+		return false, err
+	}
+
+	return res.RowsAffected() > 0, nil
+}
+
+func wrapLabelNameTaken(err error) error {
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == "23505" {
+		return ErrLabelNameTaken
+	}
+
+	return err
+}
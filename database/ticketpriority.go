@@ -0,0 +1,105 @@
+package database
+
+import (
+	"context"
+
+	"github.com/jackc/pgtype"
+	"github.com/jackc/pgx/v4"
+	"github.com/jackc/pgx/v4/pgxpool"
+)
+
+type TicketPriority int16
+
+const (
+	TicketPriorityLow TicketPriority = iota
+	TicketPriorityNormal
+	TicketPriorityHigh
+	TicketPriorityUrgent
+)
+
+func (p TicketPriority) IsValid() bool {
+	return p >= TicketPriorityLow && p <= TicketPriorityUrgent
+}
+
+type TicketPriorityTable struct {
+	*pgxpool.Pool
+}
+
+func newTicketPriorityTable(db *pgxpool.Pool) *TicketPriorityTable {
+	return &TicketPriorityTable{
+		db,
+	}
+}
+
+func (t TicketPriorityTable) Schema() string {
+	return `
+CREATE TABLE IF NOT EXISTS ticket_priority(
+	"guild_id" int8 NOT NULL,
+	"ticket_id" int4 NOT NULL,
+	"priority" int2 NOT NULL,
+	FOREIGN KEY("guild_id", "ticket_id") REFERENCES tickets("guild_id", "id") ON DELETE CASCADE,
+	PRIMARY KEY("guild_id", "ticket_id")
+);
+`
+}
+
+func (t *TicketPriorityTable) Get(ctx context.Context, guildId uint64, ticketId int) (TicketPriority, bool, error) {
+	query := `SELECT "priority" FROM ticket_priority WHERE "guild_id" = $1 AND "ticket_id" = $2;`
+
+	var priority TicketPriority
+	if err := t.QueryRow(ctx, query, guildId, ticketId).Scan(&priority); err != nil {
+		if err == pgx.ErrNoRows {
+			return 0, false, nil
+		} else {
+			return 0, false, err
+		}
+	}
+
+	return priority, true, nil
+}
+
+func (t *TicketPriorityTable) GetMulti(ctx context.Context, guildId uint64, ticketIds []int) (map[int]TicketPriority, error) {
+	query := `SELECT "ticket_id", "priority" FROM ticket_priority WHERE "guild_id" = $1 AND "ticket_id" = ANY($2);`
+
+	array := &pgtype.Int4Array{}
+	if err := array.Set(ticketIds); err != nil {
+		return nil, err
+	}
+
+	rows, err := t.Query(ctx, query, guildId, array)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	priorities := make(map[int]TicketPriority)
+	for rows.Next() {
+		var ticketId int
+		var priority TicketPriority
+		if err := rows.Scan(&ticketId, &priority); err != nil {
+			return nil, err
+		}
+
+		priorities[ticketId] = priority
+	}
+
+	return priorities, nil
+}
+
+func (t *TicketPriorityTable) Set(ctx context.Context, guildId uint64, ticketId int, priority TicketPriority) (err error) {
+	query := `
+INSERT INTO ticket_priority("guild_id", "ticket_id", "priority")
+VALUES($1, $2, $3)
+ON CONFLICT("guild_id", "ticket_id") DO UPDATE SET "priority" = $3;
+`
+
+	_, err = t.Exec(ctx, query, guildId, ticketId, priority)
+	return
+}
+
+func (t *TicketPriorityTable) Delete(ctx context.Context, guildId uint64, ticketId int) (err error) {
+	query := `DELETE FROM ticket_priority WHERE "guild_id" = $1 AND "ticket_id" = $2;`
+	_, err = t.Exec(ctx, query, guildId, ticketId)
+	return
+}
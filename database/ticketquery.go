@@ -0,0 +1,299 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/TicketsBot-cloud/database"
+	"github.com/jackc/pgtype"
+	"github.com/jackc/pgx/v4/pgxpool"
+)
+
+// TicketQueryOptions extends the shared database.TicketQueryOptions with filters on dashboard owned tables
+type TicketQueryOptions struct {
+	database.TicketQueryOptions
+	LabelIds     []int            `json:"label_ids"`
+	Priority     *TicketPriority  `json:"priority"`
+	OpenedAfter  *time.Time       `json:"opened_after"`
+	OpenedBefore *time.Time       `json:"opened_before"`
+	ClosedAfter  *time.Time       `json:"closed_after"`
+	ClosedBefore *time.Time       `json:"closed_before"`
+	CloseReason  string           `json:"close_reason"` // Case-insensitive substring match
+	SortBy       TicketSortColumn `json:"sort_by"`      // Defaults to the ticket ID, using the direction in Order
+}
+
+type TicketSortColumn string
+
+const (
+	TicketSortColumnId        TicketSortColumn = "id"
+	TicketSortColumnOpenTime  TicketSortColumn = "open_time"
+	TicketSortColumnCloseTime TicketSortColumn = "close_time"
+	TicketSortColumnRating    TicketSortColumn = "rating"
+)
+
+func (c TicketSortColumn) IsValid() bool {
+	return c == TicketSortColumnId || c == TicketSortColumnOpenTime || c == TicketSortColumnCloseTime || c == TicketSortColumnRating
+}
+
+func (c TicketSortColumn) expression() string {
+	switch c {
+	case TicketSortColumnOpenTime:
+		return "tickets.open_time"
+	case TicketSortColumnCloseTime:
+		return "tickets.close_time"
+	case TicketSortColumnRating:
+		return "(SELECT service_ratings.rating FROM service_ratings WHERE service_ratings.guild_id = tickets.guild_id AND service_ratings.ticket_id = tickets.id)"
+	default:
+		return "tickets.id"
+	}
+}
+
+type TicketQueryTable struct {
+	*pgxpool.Pool
+}
+
+func newTicketQueryTable(db *pgxpool.Pool) *TicketQueryTable {
+	return &TicketQueryTable{
+		db,
+	}
+}
+
+func (t *TicketQueryTable) GetByOptions(ctx context.Context, options TicketQueryOptions) ([]database.Ticket, error) {
+	query, args, err := options.BuildQuery()
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := t.Query(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	var tickets []database.Ticket
+	for rows.Next() {
+		var ticket database.Ticket
+		if err := rows.Scan(
+			&ticket.Id,
+			&ticket.GuildId,
+			&ticket.ChannelId,
+			&ticket.UserId,
+			&ticket.Open,
+			&ticket.OpenTime,
+			&ticket.WelcomeMessageId,
+			&ticket.PanelId,
+			&ticket.HasTranscript,
+			&ticket.CloseTime,
+			&ticket.IsThread,
+			&ticket.JoinMessageId,
+			&ticket.NotesThreadId,
+			&ticket.Status,
+		); err != nil {
+			return nil, err
+		}
+
+		tickets = append(tickets, ticket)
+	}
+
+	return tickets, rows.Err()
+}
+
+// Count returns the total number of tickets matching the options, ignoring the limit and offset
+func (t *TicketQueryTable) Count(ctx context.Context, options TicketQueryOptions) (int, error) {
+	joins, conditions, args, err := options.buildFilters()
+	if err != nil {
+		return 0, err
+	}
+
+	query := `SELECT COUNT(*) FROM tickets` + joins
+	if len(conditions) > 0 {
+		query += " WHERE " + strings.Join(conditions, " AND ")
+	}
+
+	var count int
+	if err := t.QueryRow(ctx, query, args...).Scan(&count); err != nil {
+		return 0, err
+	}
+
+	return count, nil
+}
+
+func (o TicketQueryOptions) BuildQuery() (query string, args []interface{}, _err error) {
+	query = `
+SELECT tickets.id,
+	tickets.guild_id,
+	tickets.channel_id,
+	tickets.user_id,
+	tickets.open,
+	tickets.open_time,
+	tickets.welcome_message_id,
+	tickets.panel_id,
+	tickets.has_transcript,
+	tickets.close_time,
+	tickets.is_thread,
+	tickets.join_message_id,
+	tickets.notes_thread_id,
+	tickets.status
+FROM tickets`
+
+	joins, conditions, args, err := o.buildFilters()
+	if err != nil {
+		return "", nil, err
+	}
+
+	query += joins
+
+	if len(conditions) > 0 {
+		query += " WHERE " + strings.Join(conditions, " AND ")
+	}
+
+	// Cannot use prepared statement for this value
+	if o.Order == database.OrderTypeAscending || o.Order == database.OrderTypeDescending {
+		if o.SortBy.IsValid() && o.SortBy != TicketSortColumnId {
+			// Break ties, and order tickets without a value consistently, by ID
+			query += fmt.Sprintf(` ORDER BY %s %s NULLS LAST, tickets.id %s `, o.SortBy.expression(), o.Order, o.Order)
+		} else {
+			query += fmt.Sprintf(` ORDER BY tickets.id %s `, o.Order)
+		}
+	}
+
+	if o.Limit != 0 {
+		args = append(args, o.Limit)
+		query += fmt.Sprintf(` LIMIT $%d `, len(args))
+	}
+
+	if o.Offset != 0 {
+		args = append(args, o.Offset)
+		query += fmt.Sprintf(` OFFSET $%d `, len(args))
+	}
+
+	query += ";"
+	return
+}
+
+// buildFilters returns the JOIN clauses, WHERE conditions and arguments shared by all queries on the options
+func (o TicketQueryOptions) buildFilters() (joins string, conditions []string, args []interface{}, _err error) {
+	if o.Rating != 0 {
+		joins += " INNER JOIN service_ratings ON tickets.guild_id = service_ratings.guild_id AND tickets.id = service_ratings.ticket_id "
+	}
+
+	if o.ClosedById != 0 {
+		joins += " INNER JOIN close_reason ON tickets.guild_id = close_reason.guild_id AND tickets.id = close_reason.ticket_id "
+	}
+
+	if o.ClaimedById != 0 {
+		joins += " INNER JOIN ticket_claims ON tickets.guild_id = ticket_claims.guild_id AND tickets.id = ticket_claims.ticket_id "
+	}
+
+	if o.Priority != nil {
+		joins += " INNER JOIN ticket_priority ON tickets.guild_id = ticket_priority.guild_id AND tickets.id = ticket_priority.ticket_id "
+	}
+
+	if o.Id != 0 {
+		args = append(args, o.Id)
+		conditions = append(conditions, fmt.Sprintf(`tickets.id = $%d`, len(args)))
+	}
+
+	if o.GuildId != 0 {
+		args = append(args, o.GuildId)
+		conditions = append(conditions, fmt.Sprintf(`tickets.guild_id = $%d`, len(args)))
+	}
+
+	if o.ClosedById != 0 {
+		args = append(args, o.ClosedById)
+		conditions = append(conditions, fmt.Sprintf(`close_reason.closed_by = $%d`, len(args)))
+	}
+
+	if o.ClaimedById != 0 {
+		args = append(args, o.ClaimedById)
+		conditions = append(conditions, fmt.Sprintf(`ticket_claims.user_id = $%d`, len(args)))
+	}
+
+	if len(o.UserIds) > 0 {
+		userIdArray := &pgtype.Int8Array{}
+		if err := userIdArray.Set(o.UserIds); err != nil {
+			return "", nil, nil, err
+		}
+
+		args = append(args, userIdArray)
+		conditions = append(conditions, fmt.Sprintf(`tickets.user_id = ANY($%d)`, len(args)))
+	}
+
+	if o.Open != nil {
+		args = append(args, *o.Open)
+		conditions = append(conditions, fmt.Sprintf(`tickets.open = $%d`, len(args)))
+	}
+
+	if o.PanelId > 0 {
+		args = append(args, o.PanelId)
+		conditions = append(conditions, fmt.Sprintf(`tickets.panel_id = $%d`, len(args)))
+	}
+
+	if o.Rating > 0 {
+		args = append(args, o.Rating)
+		conditions = append(conditions, fmt.Sprintf(`service_ratings.rating = $%d`, len(args)))
+	}
+
+	if o.Priority != nil {
+		args = append(args, *o.Priority)
+		conditions = append(conditions, fmt.Sprintf(`ticket_priority.priority = $%d`, len(args)))
+	}
+
+	if o.OpenedAfter != nil {
+		args = append(args, *o.OpenedAfter)
+		conditions = append(conditions, fmt.Sprintf(`tickets.open_time >= $%d`, len(args)))
+	}
+
+	if o.OpenedBefore != nil {
+		args = append(args, *o.OpenedBefore)
+		conditions = append(conditions, fmt.Sprintf(`tickets.open_time < $%d`, len(args)))
+	}
+
+	if o.ClosedAfter != nil {
+		args = append(args, *o.ClosedAfter)
+		conditions = append(conditions, fmt.Sprintf(`tickets.close_time >= $%d`, len(args)))
+	}
+
+	if o.ClosedBefore != nil {
+		args = append(args, *o.ClosedBefore)
+		conditions = append(conditions, fmt.Sprintf(`tickets.close_time < $%d`, len(args)))
+	}
+
+	if len(o.CloseReason) > 0 {
+		args = append(args, "%"+escapeLike(o.CloseReason)+"%")
+		conditions = append(conditions, fmt.Sprintf(`EXISTS (
+	SELECT 1
+	FROM close_reason AS reason_search
+	WHERE reason_search.guild_id = tickets.guild_id
+		AND reason_search.ticket_id = tickets.id
+		AND reason_search.close_reason ILIKE $%d
+)`, len(args)))
+	}
+
+	// Tickets must have every label that is being filtered on
+	if len(o.LabelIds) > 0 {
+		labelIdArray := &pgtype.Int4Array{}
+		if err := labelIdArray.Set(o.LabelIds); err != nil {
+			return "", nil, nil, err
+		}
+
+		args = append(args, labelIdArray)
+		conditions = append(conditions, fmt.Sprintf(`(
+	SELECT COUNT(DISTINCT ticket_label_assignments.label_id)
+	FROM ticket_label_assignments
+	WHERE ticket_label_assignments.guild_id = tickets.guild_id
+		AND ticket_label_assignments.ticket_id = tickets.id
+		AND ticket_label_assignments.label_id = ANY($%d)
+) = cardinality($%d::int4[])`, len(args), len(args)))
+	}
+
+	return
+}
+
+// escapeLike escapes the wildcard characters in a LIKE pattern, so that the value is matched literally
+func escapeLike(value string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(value)
+}
@@ -0,0 +1,54 @@
+package database
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/TicketsBot-cloud/database"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEscapeLike(t *testing.T) {
+	assert.Equal(t, `100\% done`, escapeLike("100% done"))
+	assert.Equal(t, `a\_b`, escapeLike("a_b"))
+	assert.Equal(t, `C:\\path`, escapeLike(`C:\path`))
+}
+
+func TestBuildQuerySortsById(t *testing.T) {
+	opts := TicketQueryOptions{
+		TicketQueryOptions: database.TicketQueryOptions{GuildId: 1, Order: database.OrderTypeDescending},
+	}
+
+	query, _, err := opts.BuildQuery()
+	assert.NoError(t, err)
+	assert.Contains(t, query, "ORDER BY tickets.id DESC")
+}
+
+func TestBuildQuerySortsByColumn(t *testing.T) {
+	opts := TicketQueryOptions{
+		TicketQueryOptions: database.TicketQueryOptions{GuildId: 1, Order: database.OrderTypeAscending},
+		SortBy:             TicketSortColumnCloseTime,
+	}
+
+	query, _, err := opts.BuildQuery()
+	assert.NoError(t, err)
+	assert.Contains(t, query, "ORDER BY tickets.close_time ASC NULLS LAST, tickets.id ASC")
+
+	// Unknown columns must never be interpolated into the query
+	opts.SortBy = "id; DROP TABLE tickets"
+	query, _, err = opts.BuildQuery()
+	assert.NoError(t, err)
+	assert.False(t, strings.Contains(query, "DROP"))
+}
+
+func TestBuildQueryCloseReason(t *testing.T) {
+	opts := TicketQueryOptions{
+		TicketQueryOptions: database.TicketQueryOptions{GuildId: 1},
+		CloseReason:        "spam_bot",
+	}
+
+	query, args, err := opts.BuildQuery()
+	assert.NoError(t, err)
+	assert.Contains(t, query, "reason_search.close_reason ILIKE $2")
+	assert.Equal(t, []interface{}{uint64(1), `%spam\_bot%`}, args)
+}
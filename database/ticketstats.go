@@ -0,0 +1,284 @@
+package database
+
+import (
+	"context"
+	"time"
+
+	"github.com/jackc/pgx/v4/pgxpool"
+)
+
+type (
+	// DailyTicketCounts is the number of tickets opened and closed on a day, in the timezone that was queried
+	DailyTicketCounts struct {
+		Date   string `json:"date"` // YYYY-MM-DD
+		Opened int    `json:"opened"`
+		Closed int    `json:"closed"`
+	}
+
+	// DurationStats summarises a set of durations, in seconds. The fields are nil if there were no durations.
+	DurationStats struct {
+		Count  int      `json:"count"`
+		Median *float64 `json:"median"`
+		P90    *float64 `json:"p90"`
+	}
+
+	RatingStats struct {
+		Count   int      `json:"count"`
+		Average *float64 `json:"average"`
+	}
+
+	TicketStatsSummary struct {
+		Opened         int           `json:"opened"`
+		Closed         int           `json:"closed"`
+		FirstResponse  DurationStats `json:"first_response"`
+		ResolutionTime DurationStats `json:"resolution_time"`
+		Rating         RatingStats   `json:"rating"`
+	}
+
+	PanelTicketStats struct {
+		PanelId       *int          `json:"panel_id"` // Nil for tickets not opened from a panel
+		Title         *string       `json:"title"`
+		Opened        int           `json:"opened"`
+		Closed        int           `json:"closed"`
+		FirstResponse DurationStats `json:"first_response"`
+		Rating        RatingStats   `json:"rating"`
+	}
+
+	StaffTicketStats struct {
+		UserId            uint64        `json:"user_id,string"`
+		Claimed           int           `json:"claimed"`
+		Closed            int           `json:"closed"`
+		ClaimedRating     RatingStats   `json:"claimed_rating"`
+		FirstResponse     DurationStats `json:"first_response"`
+		DashboardMessages int           `json:"dashboard_messages"`
+	}
+)
+
+// TicketStatsQueryTable aggregates statistics over the tickets, first_response_time, service_ratings, ticket_claims and
+// close_reason tables, which are owned by the shared database module. Tickets are counted by when they were opened,
+// except for closures and resolution times, which are counted by when the ticket was closed.
+type TicketStatsQueryTable struct {
+	*pgxpool.Pool
+}
+
+func newTicketStatsQueryTable(db *pgxpool.Pool) *TicketStatsQueryTable {
+	return &TicketStatsQueryTable{
+		db,
+	}
+}
+
+// GetDaily returns the number of tickets opened and closed on each day within [from, to), with days bounded by
+// midnight in the given IANA timezone. Every day in the range is included, even if no tickets were opened or closed.
+func (t *TicketStatsQueryTable) GetDaily(ctx context.Context, guildId uint64, from, to time.Time, timezone string) ([]DailyTicketCounts, error) {
+	query := `
+SELECT to_char(days.day, 'YYYY-MM-DD'), COALESCE(opened.count, 0), COALESCE(closed.count, 0)
+FROM (
+	SELECT generate_series(
+		($2::timestamptz AT TIME ZONE $4)::date,
+		(($3::timestamptz - interval '1 microsecond') AT TIME ZONE $4)::date,
+		interval '1 day'
+	)::date AS day
+) days
+LEFT OUTER JOIN (
+	SELECT (open_time AT TIME ZONE $4)::date AS day, COUNT(*) AS count
+	FROM tickets
+	WHERE guild_id = $1 AND open_time >= $2 AND open_time < $3
+	GROUP BY 1
+) opened ON opened.day = days.day
+LEFT OUTER JOIN (
+	SELECT (close_time AT TIME ZONE $4)::date AS day, COUNT(*) AS count
+	FROM tickets
+	WHERE guild_id = $1 AND open = false AND close_time >= $2 AND close_time < $3
+	GROUP BY 1
+) closed ON closed.day = days.day
+ORDER BY days.day;`
+
+	rows, err := t.Query(ctx, query, guildId, from, to, timezone)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	days := make([]DailyTicketCounts, 0)
+	for rows.Next() {
+		var day DailyTicketCounts
+		if err := rows.Scan(&day.Date, &day.Opened, &day.Closed); err != nil {
+			return nil, err
+		}
+
+		days = append(days, day)
+	}
+
+	return days, rows.Err()
+}
+
+// GetSummary returns totals, response and resolution times, and ratings for tickets within [from, to)
+func (t *TicketStatsQueryTable) GetSummary(ctx context.Context, guildId uint64, from, to time.Time) (TicketStatsSummary, error) {
+	query := `
+SELECT
+	(SELECT COUNT(*) FROM tickets WHERE guild_id = $1 AND open_time >= $2 AND open_time < $3),
+	(SELECT COUNT(*) FROM tickets WHERE guild_id = $1 AND open = false AND close_time >= $2 AND close_time < $3),
+	first_response.count, first_response.median, first_response.p90,
+	resolution.count, resolution.median, resolution.p90,
+	rating.count, rating.average
+FROM (
+	SELECT COUNT(*) AS count,
+		percentile_cont(0.5) WITHIN GROUP (ORDER BY EXTRACT(EPOCH FROM first_response_time.response_time)) AS median,
+		percentile_cont(0.9) WITHIN GROUP (ORDER BY EXTRACT(EPOCH FROM first_response_time.response_time)) AS p90
+	FROM first_response_time
+	INNER JOIN tickets ON tickets.guild_id = first_response_time.guild_id AND tickets.id = first_response_time.ticket_id
+	WHERE tickets.guild_id = $1 AND tickets.open_time >= $2 AND tickets.open_time < $3
+) first_response, (
+	SELECT COUNT(*) AS count,
+		percentile_cont(0.5) WITHIN GROUP (ORDER BY EXTRACT(EPOCH FROM close_time - open_time)) AS median,
+		percentile_cont(0.9) WITHIN GROUP (ORDER BY EXTRACT(EPOCH FROM close_time - open_time)) AS p90
+	FROM tickets
+	WHERE guild_id = $1 AND open = false AND close_time >= $2 AND close_time < $3
+) resolution, (
+	SELECT COUNT(*) AS count, AVG(service_ratings.rating)::float8 AS average
+	FROM service_ratings
+	INNER JOIN tickets ON tickets.guild_id = service_ratings.guild_id AND tickets.id = service_ratings.ticket_id
+	WHERE tickets.guild_id = $1 AND tickets.open = false AND tickets.close_time >= $2 AND tickets.close_time < $3
+) rating;`
+
+	var summary TicketStatsSummary
+	if err := t.QueryRow(ctx, query, guildId, from, to).Scan(
+		&summary.Opened, &summary.Closed,
+		&summary.FirstResponse.Count, &summary.FirstResponse.Median, &summary.FirstResponse.P90,
+		&summary.ResolutionTime.Count, &summary.ResolutionTime.Median, &summary.ResolutionTime.P90,
+		&summary.Rating.Count, &summary.Rating.Average,
+	); err != nil {
+		return TicketStatsSummary{}, err
+	}
+
+	return summary, nil
+}
+
+// GetByPanel breaks down the tickets opened within [from, to) by the panel that they were opened from, busiest first
+func (t *TicketStatsQueryTable) GetByPanel(ctx context.Context, guildId uint64, from, to time.Time) ([]PanelTicketStats, error) {
+	query := `
+SELECT tickets.panel_id,
+	panels.title,
+	COUNT(*),
+	COUNT(*) FILTER (WHERE tickets.open = false),
+	COUNT(first_response_time.response_time),
+	percentile_cont(0.5) WITHIN GROUP (ORDER BY EXTRACT(EPOCH FROM first_response_time.response_time)),
+	percentile_cont(0.9) WITHIN GROUP (ORDER BY EXTRACT(EPOCH FROM first_response_time.response_time)),
+	COUNT(service_ratings.rating),
+	AVG(service_ratings.rating)::float8
+FROM tickets
+LEFT OUTER JOIN panels ON panels.panel_id = tickets.panel_id
+LEFT OUTER JOIN first_response_time ON first_response_time.guild_id = tickets.guild_id AND first_response_time.ticket_id = tickets.id
+LEFT OUTER JOIN service_ratings ON service_ratings.guild_id = tickets.guild_id AND service_ratings.ticket_id = tickets.id
+WHERE tickets.guild_id = $1 AND tickets.open_time >= $2 AND tickets.open_time < $3
+GROUP BY tickets.panel_id, panels.title
+ORDER BY COUNT(*) DESC, tickets.panel_id;`
+
+	rows, err := t.Query(ctx, query, guildId, from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	panels := make([]PanelTicketStats, 0)
+	for rows.Next() {
+		var panel PanelTicketStats
+		if err := rows.Scan(
+			&panel.PanelId, &panel.Title, &panel.Opened, &panel.Closed,
+			&panel.FirstResponse.Count, &panel.FirstResponse.Median, &panel.FirstResponse.P90,
+			&panel.Rating.Count, &panel.Rating.Average,
+		); err != nil {
+			return nil, err
+		}
+
+		panels = append(panels, panel)
+	}
+
+	return panels, rows.Err()
+}
+
+// GetByStaff breaks down the work done by each staff member within [from, to), busiest first. Claims and first
+// responses are counted by when the ticket was opened, closures and the ratings of claimed tickets by when the ticket
+// was closed, and dashboard messages by when they were sent. Only users with at least one of these are included.
+func (t *TicketStatsQueryTable) GetByStaff(ctx context.Context, guildId uint64, from, to time.Time) ([]StaffTicketStats, error) {
+	query := `
+WITH claimed AS (
+	SELECT ticket_claims.user_id, COUNT(*) AS count
+	FROM ticket_claims
+	INNER JOIN tickets ON tickets.guild_id = ticket_claims.guild_id AND tickets.id = ticket_claims.ticket_id
+	WHERE tickets.guild_id = $1 AND tickets.open_time >= $2 AND tickets.open_time < $3
+	GROUP BY ticket_claims.user_id
+), closed AS (
+	SELECT close_reason.closed_by AS user_id, COUNT(*) AS count
+	FROM close_reason
+	INNER JOIN tickets ON tickets.guild_id = close_reason.guild_id AND tickets.id = close_reason.ticket_id
+	WHERE tickets.guild_id = $1 AND tickets.open = false AND tickets.close_time >= $2 AND tickets.close_time < $3
+		AND close_reason.closed_by IS NOT NULL
+	GROUP BY close_reason.closed_by
+), rating AS (
+	SELECT ticket_claims.user_id, COUNT(*) AS count, AVG(service_ratings.rating)::float8 AS average
+	FROM service_ratings
+	INNER JOIN ticket_claims ON ticket_claims.guild_id = service_ratings.guild_id AND ticket_claims.ticket_id = service_ratings.ticket_id
+	INNER JOIN tickets ON tickets.guild_id = service_ratings.guild_id AND tickets.id = service_ratings.ticket_id
+	WHERE tickets.guild_id = $1 AND tickets.open = false AND tickets.close_time >= $2 AND tickets.close_time < $3
+	GROUP BY ticket_claims.user_id
+), first_response AS (
+	SELECT first_response_time.user_id, COUNT(*) AS count,
+		percentile_cont(0.5) WITHIN GROUP (ORDER BY EXTRACT(EPOCH FROM first_response_time.response_time)) AS median,
+		percentile_cont(0.9) WITHIN GROUP (ORDER BY EXTRACT(EPOCH FROM first_response_time.response_time)) AS p90
+	FROM first_response_time
+	INNER JOIN tickets ON tickets.guild_id = first_response_time.guild_id AND tickets.id = first_response_time.ticket_id
+	WHERE tickets.guild_id = $1 AND tickets.open_time >= $2 AND tickets.open_time < $3
+	GROUP BY first_response_time.user_id
+), messages AS (
+	SELECT user_id, COUNT(*) AS count
+	FROM dashboard_messages
+	WHERE guild_id = $1 AND sent_at >= $2 AND sent_at < $3
+	GROUP BY user_id
+), staff AS (
+	SELECT user_id FROM claimed
+	UNION SELECT user_id FROM closed
+	UNION SELECT user_id FROM rating
+	UNION SELECT user_id FROM first_response
+	UNION SELECT user_id FROM messages
+)
+SELECT staff.user_id,
+	COALESCE(claimed.count, 0),
+	COALESCE(closed.count, 0),
+	COALESCE(rating.count, 0), rating.average,
+	COALESCE(first_response.count, 0), first_response.median, first_response.p90,
+	COALESCE(messages.count, 0)
+FROM staff
+LEFT OUTER JOIN claimed ON claimed.user_id = staff.user_id
+LEFT OUTER JOIN closed ON closed.user_id = staff.user_id
+LEFT OUTER JOIN rating ON rating.user_id = staff.user_id
+LEFT OUTER JOIN first_response ON first_response.user_id = staff.user_id
+LEFT OUTER JOIN messages ON messages.user_id = staff.user_id
+ORDER BY COALESCE(claimed.count, 0) + COALESCE(closed.count, 0) DESC, staff.user_id;`
+
+	rows, err := t.Query(ctx, query, guildId, from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	staff := make([]StaffTicketStats, 0)
+	for rows.Next() {
+		var member StaffTicketStats
+		if err := rows.Scan(
+			&member.UserId, &member.Claimed, &member.Closed,
+			&member.ClaimedRating.Count, &member.ClaimedRating.Average,
+			&member.FirstResponse.Count, &member.FirstResponse.Median, &member.FirstResponse.P90,
+			&member.DashboardMessages,
+		); err != nil {
+			return nil, err
+		}
+
+		staff = append(staff, member)
+	}
+
+	return staff, rows.Err()
+}
@@ -0,0 +1,284 @@
+package database
+
+import (
+	"context"
+	"time"
+
+	"github.com/jackc/pgtype"
+	"github.com/jackc/pgx/v4"
+	"github.com/jackc/pgx/v4/pgxpool"
+)
+
+type (
+	AttachmentMirror struct {
+		GuildId      uint64
+		TicketId     int
+		AttachmentId uint64
+		ObjectKey    string
+		ContentType  string
+		Size         int64
+		CreatedAt    time.Time
+	}
+
+	AttachmentMirrorTicket struct {
+		GuildId  uint64
+		TicketId int
+		Attempts int
+	}
+)
+
+const (
+	AttachmentMirrorStatusRunning   = "running"
+	AttachmentMirrorStatusCompleted = "completed"
+	AttachmentMirrorStatusFailed    = "failed"
+)
+
+// TranscriptAttachmentMirrorTable records the attachments of archived transcripts that have been copied from the
+// Discord CDN into our own storage, along with the progress of mirroring each ticket.
+type TranscriptAttachmentMirrorTable struct {
+	*pgxpool.Pool
+}
+
+func newTranscriptAttachmentMirrorTable(db *pgxpool.Pool) *TranscriptAttachmentMirrorTable {
+	return &TranscriptAttachmentMirrorTable{
+		db,
+	}
+}
+
+func (t TranscriptAttachmentMirrorTable) Schema() string {
+	return `
+CREATE TABLE IF NOT EXISTS transcript_attachment_mirror_jobs(
+	"guild_id" int8 NOT NULL,
+	"ticket_id" int4 NOT NULL,
+	"status" VARCHAR(16) NOT NULL,
+	"attempts" int4 NOT NULL DEFAULT 1,
+	"claimed_at" timestamptz NOT NULL DEFAULT NOW(),
+	FOREIGN KEY("guild_id", "ticket_id") REFERENCES tickets("guild_id", "id") ON DELETE CASCADE,
+	PRIMARY KEY("guild_id", "ticket_id")
+);
+CREATE INDEX IF NOT EXISTS transcript_attachment_mirror_jobs_status ON transcript_attachment_mirror_jobs("status") WHERE "status" != 'completed';
+CREATE TABLE IF NOT EXISTS transcript_attachment_mirrors(
+	"guild_id" int8 NOT NULL,
+	"ticket_id" int4 NOT NULL,
+	"attachment_id" int8 NOT NULL,
+	"object_key" TEXT NOT NULL,
+	"content_type" TEXT NOT NULL,
+	"size" int8 NOT NULL,
+	"created_at" timestamptz NOT NULL DEFAULT NOW(),
+	FOREIGN KEY("guild_id", "ticket_id") REFERENCES tickets("guild_id", "id") ON DELETE CASCADE,
+	PRIMARY KEY("guild_id", "ticket_id", "attachment_id")
+);
+`
+}
+
+// ClaimTickets marks up to limit tickets as being mirrored and returns them. Tickets whose previous attempt failed,
+// or whose claim went stale, are retried before new tickets, which must have been closed within maxAge. If multiple
+// replicas claim concurrently, each ticket is only returned to one of them.
+func (t *TranscriptAttachmentMirrorTable) ClaimTickets(
+	ctx context.Context,
+	maxAge, staleTimeout, retryAfter time.Duration,
+	maxAttempts, limit int,
+) ([]AttachmentMirrorTicket, error) {
+	retryQuery := `
+UPDATE transcript_attachment_mirror_jobs
+SET "status" = $1, "claimed_at" = NOW(), "attempts" = "attempts" + 1
+WHERE ("guild_id", "ticket_id") IN (
+	SELECT "guild_id", "ticket_id"
+	FROM transcript_attachment_mirror_jobs
+	WHERE ("status" = $1 AND "claimed_at" < NOW() - make_interval(secs => $2))
+		OR ("status" = $3 AND "attempts" < $4 AND "claimed_at" < NOW() - make_interval(secs => $5))
+	LIMIT $6
+	FOR UPDATE SKIP LOCKED
+)
+RETURNING "guild_id", "ticket_id", "attempts";`
+
+	claimed, err := t.claim(ctx, retryQuery,
+		AttachmentMirrorStatusRunning, staleTimeout.Seconds(), AttachmentMirrorStatusFailed, maxAttempts,
+		retryAfter.Seconds(), limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(claimed) >= limit {
+		return claimed, nil
+	}
+
+	// The primary key stops two replicas from claiming the same ticket
+	newQuery := `
+INSERT INTO transcript_attachment_mirror_jobs("guild_id", "ticket_id", "status")
+SELECT tickets.guild_id, tickets.id, $1
+FROM tickets
+WHERE tickets.open = false
+	AND tickets.has_transcript = true
+	AND tickets.close_time > NOW() - make_interval(secs => $2)
+	AND NOT EXISTS (
+		SELECT 1
+		FROM transcript_attachment_mirror_jobs
+		WHERE transcript_attachment_mirror_jobs.guild_id = tickets.guild_id
+			AND transcript_attachment_mirror_jobs.ticket_id = tickets.id
+	)
+ORDER BY tickets.close_time DESC
+LIMIT $3
+ON CONFLICT DO NOTHING
+RETURNING "guild_id", "ticket_id", "attempts";`
+
+	fresh, err := t.claim(ctx, newQuery, AttachmentMirrorStatusRunning, maxAge.Seconds(), limit-len(claimed))
+	if err != nil {
+		return nil, err
+	}
+
+	return append(claimed, fresh...), nil
+}
+
+func (t *TranscriptAttachmentMirrorTable) claim(ctx context.Context, query string, args ...interface{}) ([]AttachmentMirrorTicket, error) {
+	rows, err := t.Query(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	var claimed []AttachmentMirrorTicket
+	for rows.Next() {
+		var ticket AttachmentMirrorTicket
+		if err := rows.Scan(&ticket.GuildId, &ticket.TicketId, &ticket.Attempts); err != nil {
+			return nil, err
+		}
+
+		claimed = append(claimed, ticket)
+	}
+
+	return claimed, rows.Err()
+}
+
+const latestRedactionIdQuery = `SELECT COALESCE(MAX("id"), 0) FROM transcript_redactions WHERE "guild_id" = $1 AND "ticket_id" = $2;`
+
+// GetRedactionId returns the ID of the ticket's latest redaction, or 0 if it has never been redacted. It must be read
+// before the transcript is fetched. See Complete.
+func (t *TranscriptAttachmentMirrorTable) GetRedactionId(ctx context.Context, guildId uint64, ticketId int) (int, error) {
+	var redactionId int
+	if err := t.QueryRow(ctx, latestRedactionIdQuery, guildId, ticketId).Scan(&redactionId); err != nil {
+		return 0, err
+	}
+
+	return redactionId, nil
+}
+
+// Complete records the mirrored attachments and marks the ticket as mirrored. redactionId is the value GetRedactionId
+// returned before the transcript was read: if the transcript has been redacted since, nothing is recorded and false is
+// returned, as the mirrored files may contain redacted attachments. The ticket row is share locked while recording, so
+// a concurrent redaction is either seen here, or deletes the recorded attachments once it has been recorded.
+func (t *TranscriptAttachmentMirrorTable) Complete(ctx context.Context, guildId uint64, ticketId, redactionId int, mirrors []AttachmentMirror) (bool, error) {
+	var completed bool
+	err := t.BeginFunc(ctx, func(tx pgx.Tx) error {
+		if _, err := tx.Exec(ctx, `SELECT 1 FROM tickets WHERE "guild_id" = $1 AND "id" = $2 FOR SHARE;`, guildId, ticketId); err != nil {
+			return err
+		}
+
+		var latestRedactionId int
+		if err := tx.QueryRow(ctx, latestRedactionIdQuery, guildId, ticketId).Scan(&latestRedactionId); err != nil {
+			return err
+		}
+
+		if latestRedactionId != redactionId {
+			return nil
+		}
+
+		for _, mirror := range mirrors {
+			query := `
+INSERT INTO transcript_attachment_mirrors("guild_id", "ticket_id", "attachment_id", "object_key", "content_type", "size")
+VALUES($1, $2, $3, $4, $5, $6)
+ON CONFLICT("guild_id", "ticket_id", "attachment_id") DO UPDATE SET "object_key" = EXCLUDED.object_key, "content_type" = EXCLUDED.content_type, "size" = EXCLUDED.size, "created_at" = NOW();`
+
+			if _, err := tx.Exec(ctx, query, guildId, ticketId, mirror.AttachmentId, mirror.ObjectKey, mirror.ContentType, mirror.Size); err != nil {
+				return err
+			}
+		}
+
+		query := `UPDATE transcript_attachment_mirror_jobs SET "status" = $1 WHERE "guild_id" = $2 AND "ticket_id" = $3;`
+		if _, err := tx.Exec(ctx, query, AttachmentMirrorStatusCompleted, guildId, ticketId); err != nil {
+			return err
+		}
+
+		completed = true
+		return nil
+	})
+
+	return completed, err
+}
+
+// Fail marks the attempt as failed, so that it is retried later, up to the maximum number of attempts
+func (t *TranscriptAttachmentMirrorTable) Fail(ctx context.Context, guildId uint64, ticketId int) (err error) {
+	query := `UPDATE transcript_attachment_mirror_jobs SET "status" = $1, "claimed_at" = NOW() WHERE "guild_id" = $2 AND "ticket_id" = $3;`
+	_, err = t.Exec(ctx, query, AttachmentMirrorStatusFailed, guildId, ticketId)
+	return
+}
+
+// Reset forgets that the ticket was claimed, so that it is mirrored again from scratch
+func (t *TranscriptAttachmentMirrorTable) Reset(ctx context.Context, guildId uint64, ticketId int) (err error) {
+	_, err = t.Exec(ctx, `DELETE FROM transcript_attachment_mirror_jobs WHERE "guild_id" = $1 AND "ticket_id" = $2;`, guildId, ticketId)
+	return
+}
+
+func (t *TranscriptAttachmentMirrorTable) Get(ctx context.Context, guildId uint64, ticketId int, attachmentId uint64) (AttachmentMirror, bool, error) {
+	query := `
+SELECT "guild_id", "ticket_id", "attachment_id", "object_key", "content_type", "size", "created_at"
+FROM transcript_attachment_mirrors
+WHERE "guild_id" = $1 AND "ticket_id" = $2 AND "attachment_id" = $3;`
+
+	var mirror AttachmentMirror
+	if err := t.QueryRow(ctx, query, guildId, ticketId, attachmentId).Scan(
+		&mirror.GuildId, &mirror.TicketId, &mirror.AttachmentId, &mirror.ObjectKey, &mirror.ContentType, &mirror.Size,
+		&mirror.CreatedAt,
+	); err != nil {
+		if err == pgx.ErrNoRows {
+			return AttachmentMirror{}, false, nil
+		} else {
+			return AttachmentMirror{}, false, err
+		}
+	}
+
+	return mirror, true, nil
+}
+
+func (t *TranscriptAttachmentMirrorTable) GetByTicket(ctx context.Context, guildId uint64, ticketId int) ([]AttachmentMirror, error) {
+	query := `
+SELECT "guild_id", "ticket_id", "attachment_id", "object_key", "content_type", "size", "created_at"
+FROM transcript_attachment_mirrors
+WHERE "guild_id" = $1 AND "ticket_id" = $2;`
+
+	rows, err := t.Query(ctx, query, guildId, ticketId)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	var mirrors []AttachmentMirror
+	for rows.Next() {
+		var mirror AttachmentMirror
+		if err := rows.Scan(
+			&mirror.GuildId, &mirror.TicketId, &mirror.AttachmentId, &mirror.ObjectKey, &mirror.ContentType,
+			&mirror.Size, &mirror.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+
+		mirrors = append(mirrors, mirror)
+	}
+
+	return mirrors, rows.Err()
+}
+
+// Delete removes the records of the given mirrored attachments. The stored files must be deleted separately.
+func (t *TranscriptAttachmentMirrorTable) Delete(ctx context.Context, guildId uint64, ticketId int, attachmentIds []uint64) error {
+	array := &pgtype.Int8Array{}
+	if err := array.Set(attachmentIds); err != nil {
+		return err
+	}
+
+	query := `DELETE FROM transcript_attachment_mirrors WHERE "guild_id" = $1 AND "ticket_id" = $2 AND "attachment_id" = ANY($3);`
+	_, err := t.Exec(ctx, query, guildId, ticketId, array)
+	return err
+}
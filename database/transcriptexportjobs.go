@@ -0,0 +1,330 @@
+package database
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/jackc/pgconn"
+	"github.com/jackc/pgx/v4"
+	"github.com/jackc/pgx/v4/pgxpool"
+)
+
+type TranscriptExportStatus string
+
+const (
+	TranscriptExportStatusPending   TranscriptExportStatus = "pending"
+	TranscriptExportStatusRunning   TranscriptExportStatus = "running"
+	TranscriptExportStatusCompleted TranscriptExportStatus = "completed"
+	TranscriptExportStatusFailed    TranscriptExportStatus = "failed"
+)
+
+type (
+	TranscriptExportJob struct {
+		Id          int                    `json:"id"`
+		GuildId     uint64                 `json:"guild_id,string"`
+		UserId      uint64                 `json:"user_id,string"`
+		Status      TranscriptExportStatus `json:"status"`
+		Format      string                 `json:"format"`
+		Filter      TranscriptExportFilter `json:"filter"`
+		Total       int                    `json:"total"`
+		Processed   int                    `json:"processed"`
+		ObjectKey   *string                `json:"-"`
+		Error       *string                `json:"error"`
+		CreatedAt   time.Time              `json:"created_at"`
+		UpdatedAt   time.Time              `json:"updated_at"`
+		CompletedAt *time.Time             `json:"completed_at"`
+	}
+
+	TranscriptExportFilter struct {
+		UserId       *uint64    `json:"user_id,string,omitempty"`
+		ClosedAfter  *time.Time `json:"closed_after,omitempty"`
+		ClosedBefore *time.Time `json:"closed_before,omitempty"`
+	}
+)
+
+// ErrExportAlreadyActive is returned when creating a job for a guild that already has a pending or running job
+var ErrExportAlreadyActive = errors.New("guild already has an active transcript export")
+
+// ErrExportNotRunning is returned when updating a job that is no longer running
+var ErrExportNotRunning = errors.New("transcript export is no longer running")
+
+// TranscriptExportJobTable stores bulk transcript export jobs. At most one job per guild may be pending or running.
+// Each job records the tickets whose transcripts its archive contains, so that only the archives containing a
+// transcript need to be deleted when the transcript is redacted or deleted.
+type TranscriptExportJobTable struct {
+	*pgxpool.Pool
+}
+
+func newTranscriptExportJobTable(db *pgxpool.Pool) *TranscriptExportJobTable {
+	return &TranscriptExportJobTable{
+		db,
+	}
+}
+
+func (t TranscriptExportJobTable) Schema() string {
+	return `
+CREATE TABLE IF NOT EXISTS transcript_export_jobs(
+	"id" SERIAL NOT NULL UNIQUE,
+	"guild_id" int8 NOT NULL,
+	"user_id" int8 NOT NULL,
+	"status" VARCHAR(16) NOT NULL,
+	"format" VARCHAR(16) NOT NULL,
+	"filter" jsonb NOT NULL,
+	"total" int4 NOT NULL DEFAULT 0,
+	"processed" int4 NOT NULL DEFAULT 0,
+	"ticket_ids" int4[] NOT NULL DEFAULT '{}',
+	"object_key" TEXT,
+	"error" TEXT,
+	"created_at" timestamptz NOT NULL DEFAULT NOW(),
+	"updated_at" timestamptz NOT NULL DEFAULT NOW(),
+	"completed_at" timestamptz,
+	PRIMARY KEY("id")
+);
+CREATE INDEX IF NOT EXISTS transcript_export_jobs_guild_id ON transcript_export_jobs("guild_id");
+CREATE UNIQUE INDEX IF NOT EXISTS transcript_export_jobs_active ON transcript_export_jobs("guild_id") WHERE "status" IN ('pending', 'running');
+`
+}
+
+const transcriptExportJobColumns = `"id", "guild_id", "user_id", "status", "format", "filter", "total", "processed", "object_key", "error", "created_at", "updated_at", "completed_at"`
+
+func scanTranscriptExportJob(row pgx.Row) (TranscriptExportJob, error) {
+	var job TranscriptExportJob
+	var filter []byte
+	if err := row.Scan(
+		&job.Id, &job.GuildId, &job.UserId, &job.Status, &job.Format, &filter, &job.Total, &job.Processed,
+		&job.ObjectKey, &job.Error, &job.CreatedAt, &job.UpdatedAt, &job.CompletedAt,
+	); err != nil {
+		return TranscriptExportJob{}, err
+	}
+
+	if err := json.Unmarshal(filter, &job.Filter); err != nil {
+		return TranscriptExportJob{}, err
+	}
+
+	return job, nil
+}
+
+// Create inserts a pending job, returning ErrExportAlreadyActive if the guild already has an active job
+func (t *TranscriptExportJobTable) Create(ctx context.Context, guildId, userId uint64, format string, filter TranscriptExportFilter) (TranscriptExportJob, error) {
+	encoded, err := json.Marshal(filter)
+	if err != nil {
+		return TranscriptExportJob{}, err
+	}
+
+	query := `
+INSERT INTO transcript_export_jobs("guild_id", "user_id", "status", "format", "filter")
+VALUES($1, $2, $3, $4, $5)
+RETURNING ` + transcriptExportJobColumns + `;`
+
+	job, err := scanTranscriptExportJob(t.QueryRow(ctx, query, guildId, userId, TranscriptExportStatusPending, format, encoded))
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == "23505" {
+			return TranscriptExportJob{}, ErrExportAlreadyActive
+		}
+
+		return TranscriptExportJob{}, err
+	}
+
+	return job, nil
+}
+
+func (t *TranscriptExportJobTable) Get(ctx context.Context, guildId uint64, id int) (TranscriptExportJob, bool, error) {
+	query := `SELECT ` + transcriptExportJobColumns + ` FROM transcript_export_jobs WHERE "guild_id" = $1 AND "id" = $2;`
+
+	job, err := scanTranscriptExportJob(t.QueryRow(ctx, query, guildId, id))
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return TranscriptExportJob{}, false, nil
+		} else {
+			return TranscriptExportJob{}, false, err
+		}
+	}
+
+	return job, true, nil
+}
+
+// GetByGuild returns the guild's most recent jobs, newest first
+func (t *TranscriptExportJobTable) GetByGuild(ctx context.Context, guildId uint64, limit int) ([]TranscriptExportJob, error) {
+	query := `
+SELECT ` + transcriptExportJobColumns + `
+FROM transcript_export_jobs
+WHERE "guild_id" = $1
+ORDER BY "id" DESC
+LIMIT $2;`
+
+	return t.queryJobs(ctx, query, guildId, limit)
+}
+
+// GetStoredContaining returns the guild's completed jobs whose archive is still stored in the bucket, and contains the
+// transcript of any of the given tickets
+func (t *TranscriptExportJobTable) GetStoredContaining(ctx context.Context, guildId uint64, ticketIds []int) ([]TranscriptExportJob, error) {
+	query := `
+SELECT ` + transcriptExportJobColumns + `
+FROM transcript_export_jobs
+WHERE "guild_id" = $1 AND "status" = $2 AND "object_key" IS NOT NULL AND "ticket_ids" && $3;`
+
+	return t.queryJobs(ctx, query, guildId, TranscriptExportStatusCompleted, ticketIds)
+}
+
+// GetExpired returns up to limit completed jobs that finished before the given time, and whose archive is still stored
+// in the bucket
+func (t *TranscriptExportJobTable) GetExpired(ctx context.Context, completedBefore time.Time, limit int) ([]TranscriptExportJob, error) {
+	query := `
+SELECT ` + transcriptExportJobColumns + `
+FROM transcript_export_jobs
+WHERE "status" = $1 AND "object_key" IS NOT NULL AND "completed_at" < $2
+ORDER BY "id"
+LIMIT $3;`
+
+	return t.queryJobs(ctx, query, TranscriptExportStatusCompleted, completedBefore, limit)
+}
+
+func (t *TranscriptExportJobTable) queryJobs(ctx context.Context, query string, args ...interface{}) ([]TranscriptExportJob, error) {
+	rows, err := t.Query(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	jobs := make([]TranscriptExportJob, 0)
+	for rows.Next() {
+		job, err := scanTranscriptExportJob(rows)
+		if err != nil {
+			return nil, err
+		}
+
+		jobs = append(jobs, job)
+	}
+
+	return jobs, rows.Err()
+}
+
+// ClaimPending marks the oldest pending job as running and returns it. If multiple replicas claim concurrently, each
+// job is only returned to one of them.
+func (t *TranscriptExportJobTable) ClaimPending(ctx context.Context) (TranscriptExportJob, bool, error) {
+	query := `
+UPDATE transcript_export_jobs
+SET "status" = $1, "updated_at" = NOW()
+WHERE "id" = (
+	SELECT "id"
+	FROM transcript_export_jobs
+	WHERE "status" = $2
+	ORDER BY "id"
+	LIMIT 1
+	FOR UPDATE SKIP LOCKED
+)
+RETURNING ` + transcriptExportJobColumns + `;`
+
+	job, err := scanTranscriptExportJob(t.QueryRow(ctx, query, TranscriptExportStatusRunning, TranscriptExportStatusPending))
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return TranscriptExportJob{}, false, nil
+		} else {
+			return TranscriptExportJob{}, false, err
+		}
+	}
+
+	return job, true, nil
+}
+
+// Start records the tickets whose transcripts will be written to a running job's archive, before any are fetched.
+// Returns ErrExportNotRunning if the job is no longer running.
+func (t *TranscriptExportJobTable) Start(ctx context.Context, id, total int, ticketIds []int) error {
+	query := `
+UPDATE transcript_export_jobs
+SET "total" = $2, "processed" = 0, "ticket_ids" = $3, "updated_at" = NOW()
+WHERE "id" = $1 AND "status" = $4;`
+
+	res, err := t.Exec(ctx, query, id, total, ticketIds, TranscriptExportStatusRunning)
+	if err != nil {
+		return err
+	}
+
+	if res.RowsAffected() == 0 {
+		return ErrExportNotRunning
+	}
+
+	return nil
+}
+
+// UpdateProgress records the progress of a running job. Updating the progress also acts as a heartbeat, see FailStale.
+// Returns ErrExportNotRunning if the job is no longer running, e.g. because it has been failed by FailStale.
+func (t *TranscriptExportJobTable) UpdateProgress(ctx context.Context, id, total, processed int) error {
+	query := `
+UPDATE transcript_export_jobs
+SET "total" = $2, "processed" = $3, "updated_at" = NOW()
+WHERE "id" = $1 AND "status" = $4;`
+
+	res, err := t.Exec(ctx, query, id, total, processed, TranscriptExportStatusRunning)
+	if err != nil {
+		return err
+	}
+
+	if res.RowsAffected() == 0 {
+		return ErrExportNotRunning
+	}
+
+	return nil
+}
+
+// Complete marks a running job as completed, returning false if the job is no longer running, in which case the
+// archive is not referenced by the job and should be removed by the caller.
+func (t *TranscriptExportJobTable) Complete(ctx context.Context, id int, objectKey string) (bool, error) {
+	query := `
+UPDATE transcript_export_jobs
+SET "status" = $2, "object_key" = $3, "processed" = "total", "updated_at" = NOW(), "completed_at" = NOW()
+WHERE "id" = $1 AND "status" = $4;`
+
+	res, err := t.Exec(ctx, query, id, TranscriptExportStatusCompleted, objectKey, TranscriptExportStatusRunning)
+	if err != nil {
+		return false, err
+	}
+
+	return res.RowsAffected() > 0, nil
+}
+
+// Expire removes the reference to a completed job's archive, once the archive has been deleted from the bucket. The
+// reason is shown to the user in place of the download link.
+func (t *TranscriptExportJobTable) Expire(ctx context.Context, id int, reason string) (err error) {
+	query := `UPDATE transcript_export_jobs SET "object_key" = NULL, "error" = $2, "updated_at" = NOW() WHERE "id" = $1;`
+	_, err = t.Exec(ctx, query, id, reason)
+	return
+}
+
+func (t *TranscriptExportJobTable) Fail(ctx context.Context, id int, reason string) (err error) {
+	query := `
+UPDATE transcript_export_jobs
+SET "status" = $2, "error" = $3, "updated_at" = NOW(), "completed_at" = NOW()
+WHERE "id" = $1 AND "status" = $4;`
+
+	_, err = t.Exec(ctx, query, id, TranscriptExportStatusFailed, reason, TranscriptExportStatusRunning)
+	return
+}
+
+// FailContaining fails the guild's running jobs that will contain the transcript of any of the given tickets, as the
+// transcript may already have been written to the archive. The archive is removed by the replica running the job.
+func (t *TranscriptExportJobTable) FailContaining(ctx context.Context, guildId uint64, ticketIds []int, reason string) (err error) {
+	query := `
+UPDATE transcript_export_jobs
+SET "status" = $3, "error" = $4, "updated_at" = NOW(), "completed_at" = NOW()
+WHERE "guild_id" = $1 AND "status" = $5 AND "ticket_ids" && $2;`
+
+	_, err = t.Exec(ctx, query, guildId, ticketIds, TranscriptExportStatusFailed, reason, TranscriptExportStatusRunning)
+	return
+}
+
+// FailStale fails running jobs that have not reported progress within the timeout, e.g. because the replica running
+// them was stopped, so that the guild is able to start a new job.
+func (t *TranscriptExportJobTable) FailStale(ctx context.Context, timeout time.Duration) (err error) {
+	query := `
+UPDATE transcript_export_jobs
+SET "status" = $1, "error" = 'Export was interrupted', "updated_at" = NOW(), "completed_at" = NOW()
+WHERE "status" = $2 AND "updated_at" < NOW() - make_interval(secs => $3);`
+
+	_, err = t.Exec(ctx, query, TranscriptExportStatusFailed, TranscriptExportStatusRunning, timeout.Seconds())
+	return
+}
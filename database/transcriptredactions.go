@@ -0,0 +1,169 @@
+package database
+
+import (
+	"context"
+	"time"
+
+	"github.com/jackc/pgtype"
+	"github.com/jackc/pgx/v4"
+	"github.com/jackc/pgx/v4/pgxpool"
+)
+
+type TranscriptRedaction struct {
+	Id                 int       `json:"id"`
+	GuildId            uint64    `json:"guild_id,string"`
+	TicketId           int       `json:"ticket_id"`
+	UserId             uint64    `json:"user_id,string"`
+	MessageIds         []uint64  `json:"message_ids"`
+	AttachmentIds      []uint64  `json:"attachment_ids"`
+	PatternCount       int       `json:"pattern_count"`
+	Reason             *string   `json:"reason"`
+	MessagesAffected   int       `json:"messages_affected"`
+	AttachmentsRemoved int       `json:"attachments_removed"`
+	CreatedAt          time.Time `json:"created_at"`
+}
+
+// TranscriptRedactionTable is an audit log of the redactions made to archived transcripts. The redacted content itself
+// is never stored, nor are the patterns used to find it, as they often contain the content being redacted.
+type TranscriptRedactionTable struct {
+	*pgxpool.Pool
+}
+
+func newTranscriptRedactionTable(db *pgxpool.Pool) *TranscriptRedactionTable {
+	return &TranscriptRedactionTable{
+		db,
+	}
+}
+
+func (t TranscriptRedactionTable) Schema() string {
+	return `
+CREATE TABLE IF NOT EXISTS transcript_redactions(
+	"id" SERIAL NOT NULL UNIQUE,
+	"guild_id" int8 NOT NULL,
+	"ticket_id" int4 NOT NULL,
+	"user_id" int8 NOT NULL,
+	"message_ids" int8[] NOT NULL,
+	"attachment_ids" int8[] NOT NULL,
+	"pattern_count" int4 NOT NULL,
+	"reason" TEXT,
+	"messages_affected" int4 NOT NULL,
+	"attachments_removed" int4 NOT NULL,
+	"created_at" timestamptz NOT NULL DEFAULT NOW(),
+	FOREIGN KEY("guild_id", "ticket_id") REFERENCES tickets("guild_id", "id") ON DELETE CASCADE,
+	PRIMARY KEY("id")
+);
+CREATE INDEX IF NOT EXISTS transcript_redactions_guild_ticket ON transcript_redactions("guild_id", "ticket_id");
+CREATE TABLE IF NOT EXISTS transcript_redaction_locks(
+	"guild_id" int8 NOT NULL,
+	"ticket_id" int4 NOT NULL,
+	"locked_until" timestamptz NOT NULL,
+	FOREIGN KEY("guild_id", "ticket_id") REFERENCES tickets("guild_id", "id") ON DELETE CASCADE,
+	PRIMARY KEY("guild_id", "ticket_id")
+);
+`
+}
+
+// Lock marks the ticket as being redacted until the lease expires, so that concurrent redactions of the same transcript
+// can't overwrite each other. Returns false if the ticket is already locked. The lock must be released with Unlock.
+func (t *TranscriptRedactionTable) Lock(ctx context.Context, guildId uint64, ticketId int, lease time.Duration) (bool, error) {
+	query := `
+INSERT INTO transcript_redaction_locks("guild_id", "ticket_id", "locked_until")
+VALUES($1, $2, NOW() + make_interval(secs => $3))
+ON CONFLICT("guild_id", "ticket_id") DO UPDATE SET "locked_until" = EXCLUDED.locked_until
+WHERE transcript_redaction_locks.locked_until < NOW();`
+
+	res, err := t.Exec(ctx, query, guildId, ticketId, lease.Seconds())
+	if err != nil {
+		return false, err
+	}
+
+	return res.RowsAffected() > 0, nil
+}
+
+func (t *TranscriptRedactionTable) Unlock(ctx context.Context, guildId uint64, ticketId int) (err error) {
+	_, err = t.Exec(ctx, `DELETE FROM transcript_redaction_locks WHERE "guild_id" = $1 AND "ticket_id" = $2;`, guildId, ticketId)
+	return
+}
+
+// Create records a redaction that has been applied to the archived transcript. The ticket row is locked while the
+// record is inserted, so that attachment mirroring, which holds a share lock while it completes, either finishes before
+// the redaction is recorded or sees it.
+func (t *TranscriptRedactionTable) Create(ctx context.Context, redaction TranscriptRedaction) (int, error) {
+	// Columns are non-nullable
+	if redaction.MessageIds == nil {
+		redaction.MessageIds = []uint64{}
+	}
+
+	if redaction.AttachmentIds == nil {
+		redaction.AttachmentIds = []uint64{}
+	}
+
+	messageIds := &pgtype.Int8Array{}
+	if err := messageIds.Set(redaction.MessageIds); err != nil {
+		return 0, err
+	}
+
+	attachmentIds := &pgtype.Int8Array{}
+	if err := attachmentIds.Set(redaction.AttachmentIds); err != nil {
+		return 0, err
+	}
+
+	query := `
+INSERT INTO transcript_redactions("guild_id", "ticket_id", "user_id", "message_ids", "attachment_ids", "pattern_count", "reason", "messages_affected", "attachments_removed")
+VALUES($1, $2, $3, $4, $5, $6, $7, $8, $9)
+RETURNING "id";`
+
+	var id int
+	err := t.BeginFunc(ctx, func(tx pgx.Tx) error {
+		if _, err := tx.Exec(ctx, `SELECT 1 FROM tickets WHERE "guild_id" = $1 AND "id" = $2 FOR UPDATE;`, redaction.GuildId, redaction.TicketId); err != nil {
+			return err
+		}
+
+		return tx.QueryRow(
+			ctx, query, redaction.GuildId, redaction.TicketId, redaction.UserId, messageIds, attachmentIds,
+			redaction.PatternCount, redaction.Reason, redaction.MessagesAffected, redaction.AttachmentsRemoved,
+		).Scan(&id)
+	})
+
+	return id, err
+}
+
+func (t *TranscriptRedactionTable) GetByTicket(ctx context.Context, guildId uint64, ticketId int) ([]TranscriptRedaction, error) {
+	query := `
+SELECT "id", "guild_id", "ticket_id", "user_id", "message_ids", "attachment_ids", "pattern_count", "reason", "messages_affected", "attachments_removed", "created_at"
+FROM transcript_redactions
+WHERE "guild_id" = $1 AND "ticket_id" = $2
+ORDER BY "id" DESC;`
+
+	rows, err := t.Query(ctx, query, guildId, ticketId)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	redactions := make([]TranscriptRedaction, 0)
+	for rows.Next() {
+		var redaction TranscriptRedaction
+		var messageIds, attachmentIds pgtype.Int8Array
+		if err := rows.Scan(
+			&redaction.Id, &redaction.GuildId, &redaction.TicketId, &redaction.UserId, &messageIds, &attachmentIds,
+			&redaction.PatternCount, &redaction.Reason, &redaction.MessagesAffected, &redaction.AttachmentsRemoved,
+			&redaction.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+
+		if err := messageIds.AssignTo(&redaction.MessageIds); err != nil {
+			return nil, err
+		}
+
+		if err := attachmentIds.AssignTo(&redaction.AttachmentIds); err != nil {
+			return nil, err
+		}
+
+		redactions = append(redactions, redaction)
+	}
+
+	return redactions, rows.Err()
+}
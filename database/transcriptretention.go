@@ -0,0 +1,253 @@
+package database
+
+import (
+	"context"
+	"time"
+
+	"github.com/jackc/pgx/v4"
+	"github.com/jackc/pgx/v4/pgxpool"
+)
+
+type (
+	// TranscriptRetention is a guild's transcript retention policy. A nil number of days keeps transcripts forever.
+	TranscriptRetention struct {
+		RetentionDays  *int
+		PanelOverrides map[int]*int // panel ID -> retention days, taking precedence over the guild policy
+		UpdatedAt      time.Time
+	}
+
+	ExpiredTranscript struct {
+		GuildId       uint64    `json:"-"`
+		TicketId      int       `json:"ticket_id"`
+		PanelId       *int      `json:"panel_id"`
+		CloseTime     time.Time `json:"close_time"`
+		RetentionDays int       `json:"retention_days"`
+	}
+)
+
+// TranscriptRetentionTable stores how long each guild's transcripts are kept for after the ticket is closed. A guild
+// row exists whenever the guild has any policy, including panel overrides, and records when the policy was changed.
+type TranscriptRetentionTable struct {
+	*pgxpool.Pool
+}
+
+func newTranscriptRetentionTable(db *pgxpool.Pool) *TranscriptRetentionTable {
+	return &TranscriptRetentionTable{
+		db,
+	}
+}
+
+func (t TranscriptRetentionTable) Schema() string {
+	return `
+CREATE TABLE IF NOT EXISTS transcript_retention(
+	"guild_id" int8 NOT NULL,
+	"retention_days" int4,
+	"updated_at" timestamptz NOT NULL DEFAULT NOW(),
+	PRIMARY KEY("guild_id")
+);
+CREATE TABLE IF NOT EXISTS transcript_retention_panels(
+	"panel_id" int NOT NULL,
+	"guild_id" int8 NOT NULL,
+	"retention_days" int4,
+	FOREIGN KEY("panel_id") REFERENCES panels("panel_id") ON DELETE CASCADE ON UPDATE CASCADE,
+	FOREIGN KEY("guild_id") REFERENCES transcript_retention("guild_id") ON DELETE CASCADE,
+	PRIMARY KEY("panel_id")
+);
+CREATE INDEX IF NOT EXISTS transcript_retention_panels_guild_id ON transcript_retention_panels("guild_id");
+CREATE TABLE IF NOT EXISTS transcript_retention_deletions(
+	"guild_id" int8 NOT NULL,
+	"ticket_id" int4 NOT NULL,
+	"attempts" int4 NOT NULL DEFAULT 1,
+	"retry_after" timestamptz NOT NULL,
+	FOREIGN KEY("guild_id", "ticket_id") REFERENCES tickets("guild_id", "id") ON DELETE CASCADE,
+	PRIMARY KEY("guild_id", "ticket_id")
+);
+`
+}
+
+// Get returns the guild's policy, or false if the guild keeps all transcripts forever
+func (t *TranscriptRetentionTable) Get(ctx context.Context, guildId uint64) (TranscriptRetention, bool, error) {
+	retention := TranscriptRetention{
+		PanelOverrides: make(map[int]*int),
+	}
+
+	query := `SELECT "retention_days", "updated_at" FROM transcript_retention WHERE "guild_id" = $1;`
+	if err := t.QueryRow(ctx, query, guildId).Scan(&retention.RetentionDays, &retention.UpdatedAt); err != nil {
+		if err == pgx.ErrNoRows {
+			return retention, false, nil
+		} else {
+			return TranscriptRetention{}, false, err
+		}
+	}
+
+	rows, err := t.Query(ctx, `SELECT "panel_id", "retention_days" FROM transcript_retention_panels WHERE "guild_id" = $1;`, guildId)
+	if err != nil {
+		return TranscriptRetention{}, false, err
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+		var panelId int
+		var days *int
+		if err := rows.Scan(&panelId, &days); err != nil {
+			return TranscriptRetention{}, false, err
+		}
+
+		retention.PanelOverrides[panelId] = days
+	}
+
+	return retention, true, rows.Err()
+}
+
+// Set replaces the guild's policy. The panels must belong to the guild.
+func (t *TranscriptRetentionTable) Set(ctx context.Context, guildId uint64, retention TranscriptRetention) error {
+	return t.BeginFunc(ctx, func(tx pgx.Tx) error {
+		if _, err := tx.Exec(ctx, `DELETE FROM transcript_retention WHERE "guild_id" = $1;`, guildId); err != nil {
+			return err
+		}
+
+		// Nothing is ever deleted
+		if retention.RetentionDays == nil && len(retention.PanelOverrides) == 0 {
+			return nil
+		}
+
+		query := `INSERT INTO transcript_retention("guild_id", "retention_days", "updated_at") VALUES($1, $2, NOW());`
+		if _, err := tx.Exec(ctx, query, guildId, retention.RetentionDays); err != nil {
+			return err
+		}
+
+		for panelId, days := range retention.PanelOverrides {
+			query := `INSERT INTO transcript_retention_panels("panel_id", "guild_id", "retention_days") VALUES($1, $2, $3);`
+			if _, err := tx.Exec(ctx, query, panelId, guildId, days); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
+
+const expiredTranscriptsQuery = `
+SELECT tickets.guild_id,
+	tickets.id,
+	tickets.panel_id,
+	tickets.close_time,
+	CASE WHEN transcript_retention_panels.panel_id IS NOT NULL THEN transcript_retention_panels.retention_days ELSE transcript_retention.retention_days END AS retention_days
+FROM transcript_retention
+INNER JOIN tickets ON tickets.guild_id = transcript_retention.guild_id
+LEFT OUTER JOIN transcript_retention_panels ON transcript_retention_panels.panel_id = tickets.panel_id
+WHERE tickets.open = false
+	AND tickets.has_transcript = true
+	AND tickets.close_time < NOW() - make_interval(days => CASE WHEN transcript_retention_panels.panel_id IS NOT NULL THEN transcript_retention_panels.retention_days ELSE transcript_retention.retention_days END)`
+
+// GetExpired returns the guild's transcripts that have outlived the guild's policy, oldest first, and the total
+// number of them
+func (t *TranscriptRetentionTable) GetExpired(ctx context.Context, guildId uint64, limit int) ([]ExpiredTranscript, int, error) {
+	var total int
+	countQuery := `SELECT COUNT(*) FROM (` + expiredTranscriptsQuery + ` AND tickets.guild_id = $1) expired;`
+	if err := t.QueryRow(ctx, countQuery, guildId).Scan(&total); err != nil {
+		return nil, 0, err
+	}
+
+	query := expiredTranscriptsQuery + ` AND tickets.guild_id = $1 ORDER BY tickets.close_time LIMIT $2;`
+
+	rows, err := t.Query(ctx, query, guildId, limit)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	defer rows.Close()
+
+	expired := make([]ExpiredTranscript, 0)
+	for rows.Next() {
+		var transcript ExpiredTranscript
+		if err := rows.Scan(
+			&transcript.GuildId, &transcript.TicketId, &transcript.PanelId, &transcript.CloseTime, &transcript.RetentionDays,
+		); err != nil {
+			return nil, 0, err
+		}
+
+		expired = append(expired, transcript)
+	}
+
+	return expired, total, rows.Err()
+}
+
+// ClaimExpired leases up to limit expired transcripts, from policies that have not been changed within the grace
+// period, and returns them. Transcripts stay hidden from other claims until the lease expires, or until they are
+// deleted with Complete. Transcripts that have failed to be deleted maxAttempts times are no longer claimed. If
+// multiple replicas claim concurrently, each ticket is only returned to one of them.
+func (t *TranscriptRetentionTable) ClaimExpired(ctx context.Context, gracePeriod, lease time.Duration, maxAttempts, limit int) ([]ExpiredTranscript, error) {
+	query := `
+WITH expired AS (` + expiredTranscriptsQuery + `
+		AND transcript_retention.updated_at < NOW() - make_interval(secs => $1)
+		AND NOT EXISTS (
+			SELECT 1
+			FROM transcript_retention_deletions
+			WHERE transcript_retention_deletions.guild_id = tickets.guild_id
+				AND transcript_retention_deletions.ticket_id = tickets.id
+				AND (transcript_retention_deletions.retry_after > NOW() OR transcript_retention_deletions.attempts >= $4)
+		)
+	ORDER BY tickets.close_time
+	LIMIT $2
+), claimed AS (
+	INSERT INTO transcript_retention_deletions("guild_id", "ticket_id", "retry_after")
+	SELECT expired.guild_id, expired.id, NOW() + make_interval(secs => $3)
+	FROM expired
+	ON CONFLICT("guild_id", "ticket_id") DO UPDATE
+		SET "attempts" = transcript_retention_deletions.attempts + 1, "retry_after" = EXCLUDED.retry_after
+		WHERE transcript_retention_deletions.retry_after <= NOW() AND transcript_retention_deletions.attempts < $4
+	RETURNING "guild_id", "ticket_id"
+)
+SELECT expired.guild_id, expired.id, expired.panel_id, expired.close_time, expired.retention_days
+FROM expired
+INNER JOIN claimed ON claimed.guild_id = expired.guild_id AND claimed.ticket_id = expired.id;`
+
+	rows, err := t.Query(ctx, query, gracePeriod.Seconds(), limit, lease.Seconds(), maxAttempts)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	var claimed []ExpiredTranscript
+	for rows.Next() {
+		var transcript ExpiredTranscript
+		if err := rows.Scan(
+			&transcript.GuildId, &transcript.TicketId, &transcript.PanelId, &transcript.CloseTime, &transcript.RetentionDays,
+		); err != nil {
+			return nil, err
+		}
+
+		claimed = append(claimed, transcript)
+	}
+
+	return claimed, rows.Err()
+}
+
+// Complete marks the ticket as no longer having a transcript, once the transcript has been deleted from the archive
+func (t *TranscriptRetentionTable) Complete(ctx context.Context, guildId uint64, ticketId int) error {
+	return t.BeginFunc(ctx, func(tx pgx.Tx) error {
+		query := `UPDATE tickets SET "has_transcript" = false WHERE "guild_id" = $1 AND "id" = $2;`
+		if _, err := tx.Exec(ctx, query, guildId, ticketId); err != nil {
+			return err
+		}
+
+		query = `DELETE FROM transcript_retention_deletions WHERE "guild_id" = $1 AND "ticket_id" = $2;`
+		_, err := tx.Exec(ctx, query, guildId, ticketId)
+		return err
+	})
+}
+
+// Fail releases the lease on a transcript that failed to be deleted, so that it is retried after retryDelay, doubling
+// with each failed attempt
+func (t *TranscriptRetentionTable) Fail(ctx context.Context, guildId uint64, ticketId int, retryDelay time.Duration) (err error) {
+	query := `
+UPDATE transcript_retention_deletions
+SET "retry_after" = NOW() + make_interval(secs => $3 * power(2, "attempts" - 1))
+WHERE "guild_id" = $1 AND "ticket_id" = $2;`
+
+	_, err = t.Exec(ctx, query, guildId, ticketId, retryDelay.Seconds())
+	return
+}
@@ -0,0 +1,307 @@
+package database
+
+import (
+	"context"
+	"time"
+
+	"github.com/jackc/pgx/v4"
+	"github.com/jackc/pgx/v4/pgxpool"
+)
+
+type (
+	TranscriptSearchMessage struct {
+		MessageId uint64
+		AuthorId  uint64
+		Content   string
+		Timestamp time.Time
+	}
+
+	TranscriptSearchResult struct {
+		TicketId  int       `json:"ticket_id"`
+		MessageId uint64    `json:"message_id,string"`
+		AuthorId  uint64    `json:"author_id,string"`
+		Snippet   string    `json:"snippet"`
+		Timestamp time.Time `json:"timestamp"`
+	}
+
+	TicketKey struct {
+		GuildId  uint64
+		TicketId int
+	}
+
+	// TicketViewFilter restricts a query to the tickets that a user may view. Unless All is set, these are the tickets
+	// that the user opened, tickets not opened from a panel if the user is on the default team, and tickets opened
+	// from one of PanelIds.
+	TicketViewFilter struct {
+		All         bool
+		UserId      uint64
+		DefaultTeam bool
+		PanelIds    []int
+	}
+)
+
+const (
+	SearchHighlightStart = "<mark>"
+	SearchHighlightStop  = "</mark>"
+)
+
+// TranscriptSearchTable stores a full-text index of the messages in archived transcripts, for guilds that have
+// enabled search. transcript_search_tickets records which tickets have been indexed, including those without any
+// messages, so that they are not fetched from the archiver again, and which are being indexed or have failed to be.
+type TranscriptSearchTable struct {
+	*pgxpool.Pool
+}
+
+func newTranscriptSearchTable(db *pgxpool.Pool) *TranscriptSearchTable {
+	return &TranscriptSearchTable{
+		db,
+	}
+}
+
+func (t TranscriptSearchTable) Schema() string {
+	return `
+CREATE TABLE IF NOT EXISTS transcript_search_guilds(
+	"guild_id" int8 NOT NULL,
+	"enabled_at" timestamptz NOT NULL DEFAULT NOW(),
+	PRIMARY KEY("guild_id")
+);
+CREATE TABLE IF NOT EXISTS transcript_search_tickets(
+	"guild_id" int8 NOT NULL,
+	"ticket_id" int4 NOT NULL,
+	"indexed_at" timestamptz,
+	"lease_until" timestamptz NOT NULL DEFAULT NOW(),
+	"attempts" int4 NOT NULL DEFAULT 0,
+	FOREIGN KEY("guild_id", "ticket_id") REFERENCES tickets("guild_id", "id") ON DELETE CASCADE,
+	PRIMARY KEY("guild_id", "ticket_id")
+);
+CREATE TABLE IF NOT EXISTS transcript_search_messages(
+	"guild_id" int8 NOT NULL,
+	"ticket_id" int4 NOT NULL,
+	"message_id" int8 NOT NULL,
+	"author_id" int8 NOT NULL,
+	"content" TEXT NOT NULL,
+	"timestamp" timestamptz NOT NULL,
+	"tsv" tsvector GENERATED ALWAYS AS (to_tsvector('simple', "content")) STORED,
+	FOREIGN KEY("guild_id", "ticket_id") REFERENCES transcript_search_tickets("guild_id", "ticket_id") ON DELETE CASCADE,
+	PRIMARY KEY("guild_id", "ticket_id", "message_id")
+);
+CREATE INDEX IF NOT EXISTS transcript_search_messages_tsv ON transcript_search_messages USING GIN("tsv");
+CREATE INDEX IF NOT EXISTS transcript_search_tickets_unindexed ON transcript_search_tickets("lease_until") WHERE "indexed_at" IS NULL;
+`
+}
+
+func (t *TranscriptSearchTable) IsEnabled(ctx context.Context, guildId uint64) (bool, error) {
+	query := `SELECT EXISTS(SELECT 1 FROM transcript_search_guilds WHERE "guild_id" = $1);`
+
+	var enabled bool
+	if err := t.QueryRow(ctx, query, guildId).Scan(&enabled); err != nil {
+		return false, err
+	}
+
+	return enabled, nil
+}
+
+// SetEnabled enables or disables search for the guild. Disabling search removes the guild's index.
+func (t *TranscriptSearchTable) SetEnabled(ctx context.Context, guildId uint64, enabled bool) error {
+	if enabled {
+		query := `INSERT INTO transcript_search_guilds("guild_id") VALUES($1) ON CONFLICT("guild_id") DO NOTHING;`
+		_, err := t.Exec(ctx, query, guildId)
+		return err
+	}
+
+	return t.BeginFunc(ctx, func(tx pgx.Tx) error {
+		if _, err := tx.Exec(ctx, `DELETE FROM transcript_search_guilds WHERE "guild_id" = $1;`, guildId); err != nil {
+			return err
+		}
+
+		_, err := tx.Exec(ctx, `DELETE FROM transcript_search_tickets WHERE "guild_id" = $1;`, guildId)
+		return err
+	})
+}
+
+func (t *TranscriptSearchTable) GetIndexedCount(ctx context.Context, guildId uint64) (int, error) {
+	query := `SELECT COUNT(*) FROM transcript_search_tickets WHERE "guild_id" = $1 AND "indexed_at" IS NOT NULL;`
+
+	var count int
+	if err := t.QueryRow(ctx, query, guildId).Scan(&count); err != nil {
+		return 0, err
+	}
+
+	return count, nil
+}
+
+// ClaimUnindexed returns up to limit closed tickets with transcripts, from guilds with search enabled, that have not
+// been indexed. Tickets that failed to be indexed fewer than maxAttempts times are retried once their backoff has
+// passed. Each ticket is leased for the given duration, so that other replicas skip it, and is claimed again once the
+// lease expires if Complete or Fail are not called.
+func (t *TranscriptSearchTable) ClaimUnindexed(ctx context.Context, limit, maxAttempts int, lease time.Duration) ([]TicketKey, error) {
+	retryQuery := `
+UPDATE transcript_search_tickets
+SET "lease_until" = NOW() + make_interval(secs => $3)
+WHERE ("guild_id", "ticket_id") IN (
+	SELECT "guild_id", "ticket_id"
+	FROM transcript_search_tickets
+	WHERE "indexed_at" IS NULL AND "lease_until" <= NOW() AND "attempts" < $2
+	ORDER BY "lease_until" ASC
+	LIMIT $1
+	FOR UPDATE SKIP LOCKED
+)
+RETURNING "guild_id", "ticket_id";
+`
+
+	claimed, err := t.claim(ctx, retryQuery, limit, maxAttempts, lease.Seconds())
+	if err != nil || len(claimed) >= limit {
+		return claimed, err
+	}
+
+	newQuery := `
+INSERT INTO transcript_search_tickets("guild_id", "ticket_id", "lease_until")
+SELECT tickets.guild_id, tickets.id, NOW() + make_interval(secs => $2)
+FROM tickets
+INNER JOIN transcript_search_guilds ON tickets.guild_id = transcript_search_guilds.guild_id
+WHERE tickets.open = false
+	AND tickets.has_transcript = true
+	AND NOT EXISTS (
+		SELECT 1
+		FROM transcript_search_tickets
+		WHERE transcript_search_tickets.guild_id = tickets.guild_id AND transcript_search_tickets.ticket_id = tickets.id
+	)
+ORDER BY tickets.close_time DESC NULLS LAST
+LIMIT $1
+ON CONFLICT("guild_id", "ticket_id") DO NOTHING
+RETURNING "guild_id", "ticket_id";
+`
+
+	fresh, err := t.claim(ctx, newQuery, limit-len(claimed), lease.Seconds())
+	if err != nil {
+		return nil, err
+	}
+
+	return append(claimed, fresh...), nil
+}
+
+func (t *TranscriptSearchTable) claim(ctx context.Context, query string, args ...interface{}) ([]TicketKey, error) {
+	rows, err := t.Query(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	var claimed []TicketKey
+	for rows.Next() {
+		var key TicketKey
+		if err := rows.Scan(&key.GuildId, &key.TicketId); err != nil {
+			return nil, err
+		}
+
+		claimed = append(claimed, key)
+	}
+
+	return claimed, rows.Err()
+}
+
+// Complete stores the messages of a claimed ticket and marks it as indexed. Any messages stored by a previous attempt,
+// whose lease expired, are replaced.
+func (t *TranscriptSearchTable) Complete(ctx context.Context, guildId uint64, ticketId int, messages []TranscriptSearchMessage) error {
+	return t.BeginFunc(ctx, func(tx pgx.Tx) error {
+		query := `DELETE FROM transcript_search_messages WHERE "guild_id" = $1 AND "ticket_id" = $2;`
+		if _, err := tx.Exec(ctx, query, guildId, ticketId); err != nil {
+			return err
+		}
+
+		if len(messages) > 0 {
+			rows := make([][]interface{}, len(messages))
+			for i, message := range messages {
+				rows[i] = []interface{}{guildId, ticketId, message.MessageId, message.AuthorId, message.Content, message.Timestamp}
+			}
+
+			if _, err := tx.CopyFrom(
+				ctx,
+				pgx.Identifier{"transcript_search_messages"},
+				[]string{"guild_id", "ticket_id", "message_id", "author_id", "content", "timestamp"},
+				pgx.CopyFromRows(rows),
+			); err != nil {
+				return err
+			}
+		}
+
+		query = `UPDATE transcript_search_tickets SET "indexed_at" = NOW() WHERE "guild_id" = $1 AND "ticket_id" = $2;`
+		_, err := tx.Exec(ctx, query, guildId, ticketId)
+		return err
+	})
+}
+
+// Fail records a failed attempt to index a claimed ticket. It is retried after retryDelay, doubling with each attempt.
+func (t *TranscriptSearchTable) Fail(ctx context.Context, guildId uint64, ticketId int, retryDelay time.Duration) (err error) {
+	query := `
+UPDATE transcript_search_tickets
+SET "attempts" = "attempts" + 1, "lease_until" = NOW() + make_interval(secs => $3 * power(2, "attempts"))
+WHERE "guild_id" = $1 AND "ticket_id" = $2 AND "indexed_at" IS NULL;
+`
+
+	_, err = t.Exec(ctx, query, guildId, ticketId, retryDelay.Seconds())
+	return
+}
+
+// Invalidate removes the ticket from the index, causing it to be indexed again
+func (t *TranscriptSearchTable) Invalidate(ctx context.Context, guildId uint64, ticketId int) (err error) {
+	query := `DELETE FROM transcript_search_tickets WHERE "guild_id" = $1 AND "ticket_id" = $2;`
+	_, err = t.Exec(ctx, query, guildId, ticketId)
+	return
+}
+
+// Search returns the messages matching the query in tickets that pass the filter, with matched terms in the snippet
+// wrapped in SearchHighlightStart and SearchHighlightStop. The rest of the snippet is not escaped.
+func (t *TranscriptSearchTable) Search(ctx context.Context, guildId uint64, searchQuery string, filter TicketViewFilter, limit, offset int) ([]TranscriptSearchResult, error) {
+	query, args := buildSearchQuery(guildId, searchQuery, filter, limit, offset)
+
+	rows, err := t.Query(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	results := make([]TranscriptSearchResult, 0)
+	for rows.Next() {
+		var result TranscriptSearchResult
+		if err := rows.Scan(&result.TicketId, &result.MessageId, &result.AuthorId, &result.Snippet, &result.Timestamp); err != nil {
+			return nil, err
+		}
+
+		results = append(results, result)
+	}
+
+	return results, rows.Err()
+}
+
+func buildSearchQuery(guildId uint64, searchQuery string, filter TicketViewFilter, limit, offset int) (string, []interface{}) {
+	options := `StartSel="` + SearchHighlightStart + `", StopSel="` + SearchHighlightStop + `", MinWords=10, MaxWords=30`
+	args := []interface{}{guildId, searchQuery, limit, offset, options}
+
+	var join, condition string
+	if !filter.All {
+		join = `INNER JOIN tickets ON tickets."guild_id" = messages."guild_id" AND tickets."id" = messages."ticket_id"`
+		condition = `AND (tickets."user_id" = $6 OR (tickets."panel_id" IS NULL AND $7) OR tickets."panel_id" = ANY($8))`
+
+		panelIds := filter.PanelIds
+		if panelIds == nil {
+			panelIds = make([]int, 0)
+		}
+
+		args = append(args, filter.UserId, filter.DefaultTeam, panelIds)
+	}
+
+	query := `
+SELECT messages."ticket_id", messages."message_id", messages."author_id", ts_headline('simple', messages."content", search_query, $5), messages."timestamp"
+FROM transcript_search_messages messages
+` + join + `
+CROSS JOIN websearch_to_tsquery('simple', $2) search_query
+WHERE messages."guild_id" = $1 AND messages."tsv" @@ search_query ` + condition + `
+ORDER BY ts_rank(messages."tsv", search_query) DESC, messages."timestamp" DESC
+LIMIT $3 OFFSET $4;
+`
+
+	return query, args
+}
@@ -0,0 +1,23 @@
+package database
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSearchQueryAll(t *testing.T) {
+	query, args := buildSearchQuery(1, "refund", TicketViewFilter{All: true}, 25, 0)
+	assert.Len(t, args, 5)
+	assert.NotContains(t, query, "tickets")
+}
+
+func TestSearchQueryRestricted(t *testing.T) {
+	// The user is on the default team and the team of panel 3, but not on the team of any other panel
+	query, args := buildSearchQuery(1, "refund", TicketViewFilter{UserId: 2, DefaultTeam: true, PanelIds: []int{3}}, 25, 0)
+	assert.Contains(t, query, `AND (tickets."user_id" = $6 OR (tickets."panel_id" IS NULL AND $7) OR tickets."panel_id" = ANY($8))`)
+	assert.Equal(t, []interface{}{uint64(2), true, []int{3}}, args[5:])
+
+	_, args = buildSearchQuery(1, "refund", TicketViewFilter{UserId: 2}, 25, 0)
+	assert.Equal(t, []interface{}{uint64(2), false, []int{}}, args[5:])
+}
@@ -0,0 +1,196 @@
+package database
+
+import (
+	"context"
+	"time"
+
+	"github.com/jackc/pgx/v4"
+	"github.com/jackc/pgx/v4/pgxpool"
+)
+
+type (
+	TranscriptShareLink struct {
+		Id             int        `json:"id"`
+		GuildId        uint64     `json:"guild_id,string"`
+		TicketId       int        `json:"ticket_id"`
+		CreatedBy      uint64     `json:"created_by,string"`
+		CreatedAt      time.Time  `json:"created_at"`
+		ExpiresAt      time.Time  `json:"expires_at"`
+		RevokedAt      *time.Time `json:"revoked_at"`
+		AccessCount    int        `json:"access_count"`
+		LastAccessedAt *time.Time `json:"last_accessed_at"`
+	}
+
+	TranscriptShareAccess struct {
+		AccessedAt time.Time `json:"accessed_at"`
+		IpAddress  string    `json:"ip_address"`
+		UserAgent  string    `json:"user_agent"`
+	}
+)
+
+// IsActive returns whether the link may currently be used to view the transcript
+func (l TranscriptShareLink) IsActive(now time.Time) bool {
+	return l.RevokedAt == nil && now.Before(l.ExpiresAt)
+}
+
+// TranscriptShareLinkTable stores links that allow a single transcript to be viewed without logging in to the
+// dashboard, and a log of every time each link has been used.
+type TranscriptShareLinkTable struct {
+	*pgxpool.Pool
+}
+
+func newTranscriptShareLinkTable(db *pgxpool.Pool) *TranscriptShareLinkTable {
+	return &TranscriptShareLinkTable{
+		db,
+	}
+}
+
+func (t TranscriptShareLinkTable) Schema() string {
+	return `
+CREATE TABLE IF NOT EXISTS transcript_share_links(
+	"id" SERIAL NOT NULL UNIQUE,
+	"guild_id" int8 NOT NULL,
+	"ticket_id" int4 NOT NULL,
+	"created_by" int8 NOT NULL,
+	"created_at" timestamptz NOT NULL DEFAULT NOW(),
+	"expires_at" timestamptz NOT NULL,
+	"revoked_at" timestamptz,
+	FOREIGN KEY("guild_id", "ticket_id") REFERENCES tickets("guild_id", "id") ON DELETE CASCADE,
+	PRIMARY KEY("id")
+);
+CREATE INDEX IF NOT EXISTS transcript_share_links_guild_id ON transcript_share_links("guild_id");
+CREATE TABLE IF NOT EXISTS transcript_share_link_accesses(
+	"link_id" int4 NOT NULL,
+	"accessed_at" timestamptz NOT NULL DEFAULT NOW(),
+	"ip_address" TEXT NOT NULL,
+	"user_agent" TEXT NOT NULL,
+	FOREIGN KEY("link_id") REFERENCES transcript_share_links("id") ON DELETE CASCADE
+);
+CREATE INDEX IF NOT EXISTS transcript_share_link_accesses_link_id ON transcript_share_link_accesses("link_id", "accessed_at");
+`
+}
+
+const transcriptShareLinkColumns = `
+	transcript_share_links.id,
+	transcript_share_links.guild_id,
+	transcript_share_links.ticket_id,
+	transcript_share_links.created_by,
+	transcript_share_links.created_at,
+	transcript_share_links.expires_at,
+	transcript_share_links.revoked_at,
+	(SELECT COUNT(*) FROM transcript_share_link_accesses WHERE transcript_share_link_accesses.link_id = transcript_share_links.id),
+	(SELECT MAX(accessed_at) FROM transcript_share_link_accesses WHERE transcript_share_link_accesses.link_id = transcript_share_links.id)`
+
+func scanTranscriptShareLink(row pgx.Row) (TranscriptShareLink, error) {
+	var link TranscriptShareLink
+	err := row.Scan(
+		&link.Id, &link.GuildId, &link.TicketId, &link.CreatedBy, &link.CreatedAt, &link.ExpiresAt, &link.RevokedAt,
+		&link.AccessCount, &link.LastAccessedAt,
+	)
+
+	return link, err
+}
+
+func (t *TranscriptShareLinkTable) Create(ctx context.Context, guildId uint64, ticketId int, createdBy uint64, expiresAt time.Time) (TranscriptShareLink, error) {
+	query := `
+INSERT INTO transcript_share_links("guild_id", "ticket_id", "created_by", "expires_at")
+VALUES($1, $2, $3, $4)
+RETURNING "id", "guild_id", "ticket_id", "created_by", "created_at", "expires_at", "revoked_at", 0, NULL::timestamptz;`
+
+	return scanTranscriptShareLink(t.QueryRow(ctx, query, guildId, ticketId, createdBy, expiresAt))
+}
+
+// Get returns the link with the given ID, from any guild
+func (t *TranscriptShareLinkTable) Get(ctx context.Context, id int) (TranscriptShareLink, bool, error) {
+	query := `SELECT ` + transcriptShareLinkColumns + ` FROM transcript_share_links WHERE transcript_share_links.id = $1;`
+
+	link, err := scanTranscriptShareLink(t.QueryRow(ctx, query, id))
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return TranscriptShareLink{}, false, nil
+		} else {
+			return TranscriptShareLink{}, false, err
+		}
+	}
+
+	return link, true, nil
+}
+
+// GetByGuild returns the guild's links, newest first, including links that have expired or been revoked
+func (t *TranscriptShareLinkTable) GetByGuild(ctx context.Context, guildId uint64, limit int) ([]TranscriptShareLink, error) {
+	query := `
+SELECT ` + transcriptShareLinkColumns + `
+FROM transcript_share_links
+WHERE transcript_share_links.guild_id = $1
+ORDER BY transcript_share_links.id DESC
+LIMIT $2;`
+
+	rows, err := t.Query(ctx, query, guildId, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	links := make([]TranscriptShareLink, 0)
+	for rows.Next() {
+		link, err := scanTranscriptShareLink(rows)
+		if err != nil {
+			return nil, err
+		}
+
+		links = append(links, link)
+	}
+
+	return links, rows.Err()
+}
+
+// Revoke revokes the link, returning false if the guild has no link with the given ID
+func (t *TranscriptShareLinkTable) Revoke(ctx context.Context, guildId uint64, id int) (bool, error) {
+	query := `
+UPDATE transcript_share_links
+SET "revoked_at" = COALESCE("revoked_at", NOW())
+WHERE "guild_id" = $1 AND "id" = $2;`
+
+	res, err := t.Exec(ctx, query, guildId, id)
+	if err != nil {
+		return false, err
+	}
+
+	return res.RowsAffected() > 0, nil
+}
+
+func (t *TranscriptShareLinkTable) LogAccess(ctx context.Context, linkId int, ipAddress, userAgent string) (err error) {
+	query := `INSERT INTO transcript_share_link_accesses("link_id", "ip_address", "user_agent") VALUES($1, $2, $3);`
+	_, err = t.Exec(ctx, query, linkId, ipAddress, userAgent)
+	return
+}
+
+// GetAccesses returns the most recent uses of the link, newest first
+func (t *TranscriptShareLinkTable) GetAccesses(ctx context.Context, linkId int, limit int) ([]TranscriptShareAccess, error) {
+	query := `
+SELECT "accessed_at", "ip_address", "user_agent"
+FROM transcript_share_link_accesses
+WHERE "link_id" = $1
+ORDER BY "accessed_at" DESC
+LIMIT $2;`
+
+	rows, err := t.Query(ctx, query, linkId, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	accesses := make([]TranscriptShareAccess, 0)
+	for rows.Next() {
+		var access TranscriptShareAccess
+		if err := rows.Scan(&access.AccessedAt, &access.IpAddress, &access.UserAgent); err != nil {
+			return nil, err
+		}
+
+		accesses = append(accesses, access)
+	}
+
+	return accesses, rows.Err()
+}
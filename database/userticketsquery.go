@@ -0,0 +1,120 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v4/pgxpool"
+)
+
+type (
+	UserTicket struct {
+		GuildId         uint64
+		TicketId        int
+		Open            bool
+		OpenTime        time.Time
+		CloseTime       *time.Time
+		HasTranscript   bool
+		PanelId         *int
+		Rating          *uint8
+		FeedbackEnabled bool
+	}
+
+	UserTicketQueryOptions struct {
+		UserId  uint64
+		GuildId uint64 // Optional
+		Open    *bool  // Optional
+		Limit   int
+		Offset  int
+	}
+)
+
+// UserTicketsQueryTable queries the tickets that a user has opened across all guilds, along with whether they are
+// able to leave feedback on each
+type UserTicketsQueryTable struct {
+	*pgxpool.Pool
+}
+
+func newUserTicketsQueryTable(db *pgxpool.Pool) *UserTicketsQueryTable {
+	return &UserTicketsQueryTable{
+		db,
+	}
+}
+
+// Schema indexes the shared tickets table by user, as GetByUser queries it across all guilds. The index is built
+// concurrently so that the table remains writable while it is created, which is only possible for a single statement.
+func (t UserTicketsQueryTable) Schema() string {
+	return `CREATE INDEX CONCURRENTLY IF NOT EXISTS tickets_user_id_open_time ON tickets("user_id", "open_time" DESC);`
+}
+
+// GetByUser returns the user's tickets, most recently opened first
+func (t *UserTicketsQueryTable) GetByUser(ctx context.Context, options UserTicketQueryOptions) ([]UserTicket, error) {
+	args := []interface{}{options.UserId}
+	conditions := []string{`tickets.user_id = $1`}
+
+	if options.GuildId != 0 {
+		args = append(args, options.GuildId)
+		conditions = append(conditions, fmt.Sprintf(`tickets.guild_id = $%d`, len(args)))
+	}
+
+	if options.Open != nil {
+		args = append(args, *options.Open)
+		conditions = append(conditions, fmt.Sprintf(`tickets.open = $%d`, len(args)))
+	}
+
+	args = append(args, options.Limit, options.Offset)
+
+	query := fmt.Sprintf(`
+SELECT tickets.guild_id,
+	tickets.id,
+	tickets.open,
+	tickets.open_time,
+	tickets.close_time,
+	tickets.has_transcript,
+	tickets.panel_id,
+	service_ratings.rating,
+	COALESCE(feedback_enabled.feedback_enabled, false)
+FROM tickets
+LEFT OUTER JOIN service_ratings ON tickets.guild_id = service_ratings.guild_id AND tickets.id = service_ratings.ticket_id
+LEFT OUTER JOIN feedback_enabled ON tickets.guild_id = feedback_enabled.guild_id
+WHERE %s
+ORDER BY tickets.open_time DESC, tickets.guild_id, tickets.id DESC
+LIMIT $%d OFFSET $%d;`, strings.Join(conditions, " AND "), len(args)-1, len(args))
+
+	rows, err := t.Query(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	tickets := make([]UserTicket, 0)
+	for rows.Next() {
+		var ticket UserTicket
+		var rating *int16
+		if err := rows.Scan(
+			&ticket.GuildId,
+			&ticket.TicketId,
+			&ticket.Open,
+			&ticket.OpenTime,
+			&ticket.CloseTime,
+			&ticket.HasTranscript,
+			&ticket.PanelId,
+			&rating,
+			&ticket.FeedbackEnabled,
+		); err != nil {
+			return nil, err
+		}
+
+		if rating != nil {
+			v := uint8(*rating)
+			ticket.Rating = &v
+		}
+
+		tickets = append(tickets, ticket)
+	}
+
+	return tickets, rows.Err()
+}
@@ -0,0 +1,174 @@
+package redis
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+const (
+	// Transcripts larger than this after compression are not cached
+	RenderedTranscriptMaxSize = 4 * 1024 * 1024
+
+	// Once the total compressed size of all cached transcripts exceeds the budget, the least recently used are evicted
+	RenderedTranscriptBudget = 512 * 1024 * 1024
+
+	renderedTranscriptIndexKey = "tickets:renderedtranscript:index"
+	renderedTranscriptSizesKey = "tickets:renderedtranscript:sizes"
+	renderedTranscriptTotalKey = "tickets:renderedtranscript:total"
+)
+
+// KEYS: entry, versions set, index, sizes, total
+// ARGV: data, ttl seconds, now, budget
+var setRenderedTranscriptScript = redis.NewScript(`
+-- Previous versions are never requested again, so are removed rather than left to expire
+local versions = redis.call('SMEMBERS', KEYS[2])
+for _, key in ipairs(versions) do
+	if key ~= KEYS[1] then
+		local size = tonumber(redis.call('HGET', KEYS[4], key) or '0')
+		redis.call('DEL', key)
+		redis.call('HDEL', KEYS[4], key)
+		redis.call('ZREM', KEYS[3], key)
+		redis.call('SREM', KEYS[2], key)
+		redis.call('DECRBY', KEYS[5], size)
+	end
+end
+
+local old = tonumber(redis.call('HGET', KEYS[4], KEYS[1]) or '0')
+local size = string.len(ARGV[1])
+
+redis.call('SET', KEYS[1], ARGV[1], 'EX', ARGV[2])
+redis.call('SADD', KEYS[2], KEYS[1])
+redis.call('EXPIRE', KEYS[2], ARGV[2])
+redis.call('HSET', KEYS[4], KEYS[1], size)
+redis.call('ZADD', KEYS[3], ARGV[3], KEYS[1])
+
+local total = redis.call('INCRBY', KEYS[5], size - old)
+while total > tonumber(ARGV[4]) do
+	local popped = redis.call('ZPOPMIN', KEYS[3], 1)
+	if #popped == 0 then
+		break
+	end
+
+	local key = popped[1]
+	local evictedSize = tonumber(redis.call('HGET', KEYS[4], key) or '0')
+	redis.call('DEL', key)
+	redis.call('HDEL', KEYS[4], key)
+	total = redis.call('DECRBY', KEYS[5], evictedSize)
+end
+
+return total
+`)
+
+// KEYS: versions set, index, sizes, total
+var invalidateRenderedTranscriptScript = redis.NewScript(`
+local keys = redis.call('SMEMBERS', KEYS[1])
+for _, key in ipairs(keys) do
+	local size = tonumber(redis.call('HGET', KEYS[3], key) or '0')
+	redis.call('DEL', key)
+	redis.call('HDEL', KEYS[3], key)
+	redis.call('ZREM', KEYS[2], key)
+	redis.call('DECRBY', KEYS[4], size)
+end
+
+redis.call('DEL', KEYS[1])
+return #keys
+`)
+
+func renderedTranscriptKey(guildId uint64, ticketId int, version string) string {
+	return fmt.Sprintf("tickets:renderedtranscript:%d:%d:%s", guildId, ticketId, version)
+}
+
+func renderedTranscriptVersionsKey(guildId uint64, ticketId int) string {
+	return fmt.Sprintf("tickets:renderedtranscript:%d:%d:versions", guildId, ticketId)
+}
+
+func (c *RedisClient) GetRenderedTranscript(ctx context.Context, guildId uint64, ticketId int, version string) ([]byte, bool, error) {
+	key := renderedTranscriptKey(guildId, ticketId, version)
+
+	compressed, err := c.Get(ctx, key).Bytes()
+	if err != nil {
+		if errors.Is(err, redis.Nil) {
+			return nil, false, nil
+		}
+
+		return nil, false, err
+	}
+
+	// Mark as recently used, so that it is not evicted
+	if err := c.ZAddXX(ctx, renderedTranscriptIndexKey, &redis.Z{
+		Score:  float64(time.Now().Unix()),
+		Member: key,
+	}).Err(); err != nil {
+		return nil, false, err
+	}
+
+	reader, err := gzip.NewReader(bytes.NewReader(compressed))
+	if err != nil {
+		return nil, false, err
+	}
+
+	defer reader.Close()
+
+	html, err := io.ReadAll(reader)
+	if err != nil {
+		return nil, false, err
+	}
+
+	return html, true, nil
+}
+
+// SetRenderedTranscript stores the rendered transcript until the ttl, after which the version is no longer used,
+// replacing any other versions of the ticket's transcript. The least recently used transcripts are evicted if the
+// cache is over budget. Transcripts that are too large are not stored.
+func (c *RedisClient) SetRenderedTranscript(ctx context.Context, guildId uint64, ticketId int, version string, html []byte, ttl time.Duration) error {
+	var buf bytes.Buffer
+	writer := gzip.NewWriter(&buf)
+	if _, err := writer.Write(html); err != nil {
+		return err
+	}
+
+	if err := writer.Close(); err != nil {
+		return err
+	}
+
+	if buf.Len() > RenderedTranscriptMaxSize {
+		return nil
+	}
+
+	keys := []string{
+		renderedTranscriptKey(guildId, ticketId, version),
+		renderedTranscriptVersionsKey(guildId, ticketId),
+		renderedTranscriptIndexKey,
+		renderedTranscriptSizesKey,
+		renderedTranscriptTotalKey,
+	}
+
+	return setRenderedTranscriptScript.Run(
+		ctx,
+		c.Client,
+		keys,
+		buf.Bytes(),
+		int(ttl.Seconds()),
+		time.Now().Unix(),
+		RenderedTranscriptBudget,
+	).Err()
+}
+
+// InvalidateRenderedTranscript removes every cached version of the ticket's transcript
+func (c *RedisClient) InvalidateRenderedTranscript(ctx context.Context, guildId uint64, ticketId int) error {
+	keys := []string{
+		renderedTranscriptVersionsKey(guildId, ticketId),
+		renderedTranscriptIndexKey,
+		renderedTranscriptSizesKey,
+		renderedTranscriptTotalKey,
+	}
+
+	return invalidateRenderedTranscriptScript.Run(ctx, c.Client, keys).Err()
+}
@@ -2,8 +2,10 @@ package cache
 
 import (
 	"context"
+	"encoding/json"
 
 	"github.com/TicketsBot-cloud/dashboard/config"
+	"github.com/jackc/pgtype"
 	"github.com/jackc/pgx/v4/pgxpool"
 	gdlcache "github.com/rxdn/gdl/cache"
 )
@@ -32,3 +34,116 @@ func NewCache() *Cache {
 		PgCache: &cache,
 	}
 }
+
+// GetGuildNames returns a map of guild ID -> name for the guilds that are present in the cache
+func (c *Cache) GetGuildNames(ctx context.Context, guildIds []uint64) (map[uint64]string, error) {
+	names := make(map[uint64]string)
+	if len(guildIds) == 0 {
+		return names, nil
+	}
+
+	guildIdArray := &pgtype.Int8Array{}
+	if err := guildIdArray.Set(guildIds); err != nil {
+		return nil, err
+	}
+
+	rows, err := c.Query(ctx, `SELECT "guild_id", "data"->>'name' FROM guilds WHERE "guild_id" = ANY($1);`, guildIdArray)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+		var guildId uint64
+		var name *string
+		if err := rows.Scan(&guildId, &name); err != nil {
+			return nil, err
+		}
+
+		if name != nil {
+			names[guildId] = *name
+		}
+	}
+
+	return names, rows.Err()
+}
+
+// GetMembersWithRoles returns the IDs of the guild's cached members who have any of the given roles. Members who are
+// not in the cache are omitted.
+func (c *Cache) GetMembersWithRoles(ctx context.Context, guildId uint64, roleIds []uint64) ([]uint64, error) {
+	userIds := make([]uint64, 0)
+	if len(roleIds) == 0 {
+		return userIds, nil
+	}
+
+	roleIdArray := &pgtype.Int8Array{}
+	if err := roleIdArray.Set(roleIds); err != nil {
+		return nil, err
+	}
+
+	query := `
+SELECT "user_id"
+FROM members
+WHERE "guild_id" = $1 AND EXISTS (
+	SELECT 1 FROM jsonb_array_elements_text("data"->'roles') role WHERE role::int8 = ANY($2)
+);`
+
+	rows, err := c.Query(ctx, query, guildId, roleIdArray)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+		var userId uint64
+		if err := rows.Scan(&userId); err != nil {
+			return nil, err
+		}
+
+		userIds = append(userIds, userId)
+	}
+
+	return userIds, rows.Err()
+}
+
+// GetMemberRoles returns a map of user ID -> role IDs for the given users who are present in the member cache. Users
+// who are not cached, including those who have left the guild, are omitted.
+func (c *Cache) GetMemberRoles(ctx context.Context, guildId uint64, userIds []uint64) (map[uint64][]uint64, error) {
+	roles := make(map[uint64][]uint64)
+	if len(userIds) == 0 {
+		return roles, nil
+	}
+
+	userIdArray := &pgtype.Int8Array{}
+	if err := userIdArray.Set(userIds); err != nil {
+		return nil, err
+	}
+
+	rows, err := c.Query(ctx, `SELECT "user_id", "data"->'roles' FROM members WHERE "guild_id" = $1 AND "user_id" = ANY($2);`, guildId, userIdArray)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+		var userId uint64
+		var raw []byte
+		if err := rows.Scan(&userId, &raw); err != nil {
+			return nil, err
+		}
+
+		var memberRoles []uint64
+		if len(raw) > 0 {
+			if err := json.Unmarshal(raw, &memberRoles); err != nil {
+				return nil, err
+			}
+		}
+
+		roles[userId] = memberRoles
+	}
+
+	return roles, rows.Err()
+}
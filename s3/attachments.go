@@ -0,0 +1,20 @@
+package s3
+
+import (
+	"fmt"
+
+	"github.com/TicketsBot-cloud/dashboard/config"
+)
+
+// AttachmentBucket returns the bucket that mirrored transcript attachments are stored in
+func AttachmentBucket() string {
+	if config.Conf.S3Import.AttachmentBucket != "" {
+		return config.Conf.S3Import.AttachmentBucket
+	}
+
+	return config.Conf.S3Import.DataBucket
+}
+
+func TranscriptAttachmentKey(guildId uint64, ticketId int, attachmentId uint64) string {
+	return fmt.Sprintf("transcript-attachments/%d/%d/%d", guildId, ticketId, attachmentId)
+}
@@ -0,0 +1,20 @@
+package s3
+
+import (
+	"fmt"
+
+	"github.com/TicketsBot-cloud/dashboard/config"
+)
+
+// ExportBucket returns the bucket that bulk transcript exports are written to
+func ExportBucket() string {
+	if config.Conf.S3Import.ExportBucket != "" {
+		return config.Conf.S3Import.ExportBucket
+	}
+
+	return config.Conf.S3Import.DataBucket
+}
+
+func TranscriptExportKey(guildId uint64, jobId int) string {
+	return fmt.Sprintf("transcript-exports/%d/%d.zip", guildId, jobId)
+}
@@ -1,5 +1,36 @@
 package utils
 
-import "github.com/TicketsBot-cloud/archiverclient"
+import (
+	"context"
+
+	"github.com/TicketsBot-cloud/archiverclient"
+	"github.com/TicketsBot-cloud/dashboard/chatreplica"
+	"github.com/TicketsBot-cloud/dashboard/config"
+	"github.com/TicketsBot/common/encryption"
+)
 
 var ArchiverClient *archiverclient.ArchiverClient
+
+// ArchiveRetriever is the retriever backing ArchiverClient, for operations that the client does not expose
+var ArchiveRetriever archiverclient.Retriever
+
+// GetTranscript fetches and decodes an archived transcript. Unlike ArchiverClient.Get, the message types, replies,
+// stickers and edits recorded in the archive are kept. Returns archiverclient.ErrNotFound if there is no transcript.
+func GetTranscript(ctx context.Context, guildId uint64, ticketId int) (chatreplica.Transcript, error) {
+	body, err := ArchiveRetriever.GetTicket(ctx, guildId, ticketId)
+	if err != nil {
+		return chatreplica.Transcript{}, err
+	}
+
+	body, err = encryption.Decompress(body)
+	if err != nil {
+		return chatreplica.Transcript{}, err
+	}
+
+	body, err = encryption.Decrypt([]byte(config.Conf.Bot.AesKey), body)
+	if err != nil {
+		return chatreplica.Transcript{}, err
+	}
+
+	return chatreplica.DecodeTranscript(body)
+}
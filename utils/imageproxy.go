@@ -1,6 +1,8 @@
 package utils
 
 import (
+	"fmt"
+	"net/url"
 	"strconv"
 	"time"
 
@@ -10,11 +12,25 @@ import (
 )
 
 func GenerateImageProxyToken(imageUrl string) (string, error) {
+	return GenerateImageProxyTokenWithExpiry(imageUrl, time.Second*30)
+}
+
+func GenerateImageProxyTokenWithExpiry(imageUrl string, validity time.Duration) (string, error) {
 	token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
 		"url":        imageUrl,
 		"request_id": uuid.New().String(),
-		"exp":        strconv.FormatInt(time.Now().Add(time.Second*30).Unix(), 10),
+		"exp":        strconv.FormatInt(time.Now().Add(validity).Unix(), 10),
 	})
 
 	return token.SignedString([]byte(config.Conf.Bot.ImageProxySecret))
 }
+
+// GenerateImageProxyUrl returns a link that serves the image through the image proxy, for the given duration
+func GenerateImageProxyUrl(imageUrl string, validity time.Duration) (string, error) {
+	token, err := GenerateImageProxyTokenWithExpiry(imageUrl, validity)
+	if err != nil {
+		return "", err
+	}
+
+	return fmt.Sprintf("%s/proxy?token=%s", config.Conf.Bot.ImageProxyUrl, url.QueryEscape(token)), nil
+}
@@ -57,69 +57,9 @@ func HasPermissionToViewTicket(ctx context.Context, guildId, userId uint64, tick
 		return true, nil
 	}
 
-	// Admin override
-	botContext, err := botcontext.ContextForGuild(guildId)
-	if err != nil {
-		return false, api.NewInternalServerError(err, "Error retrieving guild context")
-	}
-
-	if botContext.IsBotAdmin(ctx, userId) {
-		return true, nil
-	}
-
-	// Check staff override
-	staffOverride, err := dbclient.Client.StaffOverride.HasActiveOverride(ctx, guildId)
-	if err != nil {
-		return false, api.NewDatabaseError(err)
-	}
-
-	// If staff override enabled and the user is bot staff, grant admin permissions
-	if staffOverride {
-		isBotStaff, err := dbclient.Client.BotStaff.IsStaff(ctx, userId)
-		if err != nil {
-			return false, api.NewDatabaseError(err)
-		}
-
-		if isBotStaff {
-			return true, nil
-		}
-	}
-
-	// Check if server owner
-	guild, err := botContext.GetGuild(ctx, guildId)
-	if err != nil {
-		return false, api.NewInternalServerError(err, "Error retrieving guild object")
-	}
-
-	if guild.OwnerId == userId {
-		return true, nil
-	}
-
-	member, err := botContext.GetGuildMember(ctx, guildId, userId)
-	if err != nil {
-		return false, api.NewErrorWithMessage(http.StatusForbidden, err, "User not in server: are you logged into the correct account?")
-	}
-
-	// Admins should have access to all tickets
-	isAdmin, err := dbclient.Client.Permissions.IsAdmin(ctx, guildId, userId)
-	if err != nil {
-		return false, api.NewDatabaseError(err)
-	}
-
-	if isAdmin {
-		return true, nil
-	}
-
-	// TODO: Check in db
-	adminRoles, err := dbclient.Client.RolePermissions.GetAdminRoles(ctx, guildId)
-	if err != nil {
-		return false, api.NewDatabaseError(err)
-	}
-
-	for _, roleId := range adminRoles {
-		if member.HasRole(roleId) {
-			return true, nil
-		}
+	canViewAll, member, err := canViewAllTickets(ctx, guildId, userId)
+	if err != nil || canViewAll {
+		return canViewAll, err
 	}
 
 	// If ticket is not from a panel, we can use default team perms
@@ -184,6 +124,150 @@ func HasPermissionToViewTicket(ctx context.Context, guildId, userId uint64, tick
 	}
 }
 
+// GetTicketViewFilter returns which of the guild's tickets the user may view, following the same rules as
+// HasPermissionToViewTicket, so that a query over many tickets can be restricted without checking each one
+func GetTicketViewFilter(ctx context.Context, guildId, userId uint64) (dbclient.TicketViewFilter, *api.RequestError) {
+	canViewAll, member, requestErr := canViewAllTickets(ctx, guildId, userId)
+	if requestErr != nil {
+		return dbclient.TicketViewFilter{}, requestErr
+	}
+
+	if canViewAll {
+		return dbclient.TicketViewFilter{All: true}, nil
+	}
+
+	filter := dbclient.TicketViewFilter{UserId: userId}
+
+	filter.DefaultTeam, requestErr = isOnDefaultTeam(ctx, guildId, member)
+	if requestErr != nil {
+		return dbclient.TicketViewFilter{}, requestErr
+	}
+
+	memberTeams, err := dbclient.Client.SupportTeamMembers.GetAllTeamsForUser(ctx, guildId, userId)
+	if err != nil {
+		return dbclient.TicketViewFilter{}, api.NewDatabaseError(err)
+	}
+
+	roleTeams, err := dbclient.Client.SupportTeamRoles.GetAllTeamsForRoles(ctx, guildId, member.Roles)
+	if err != nil {
+		return dbclient.TicketViewFilter{}, api.NewDatabaseError(err)
+	}
+
+	teams := append(memberTeams, roleTeams...)
+	if !filter.DefaultTeam && len(teams) == 0 {
+		return filter, nil
+	}
+
+	panels, err := dbclient.Client.Panel.GetByGuild(ctx, guildId)
+	if err != nil {
+		return dbclient.TicketViewFilter{}, api.NewDatabaseError(err)
+	}
+
+	for _, panel := range panels {
+		// Only look up the panel's teams if the default team doesn't already grant access
+		var panelTeams []int
+		if !(panel.WithDefaultTeam && filter.DefaultTeam) && len(teams) > 0 {
+			panelTeams, err = dbclient.Client.PanelTeams.GetTeamIds(ctx, panel.PanelId)
+			if err != nil {
+				return dbclient.TicketViewFilter{}, api.NewDatabaseError(err)
+			}
+		}
+
+		if canViewPanel(panel, filter.DefaultTeam, teams, panelTeams) {
+			filter.PanelIds = append(filter.PanelIds, panel.PanelId)
+		}
+	}
+
+	return filter, nil
+}
+
+// canViewPanel returns whether a user may view tickets opened from the panel, either through the default team or
+// through one of the panel's support teams
+func canViewPanel(panel database.Panel, onDefaultTeam bool, userTeams, panelTeams []int) bool {
+	if panel.WithDefaultTeam && onDefaultTeam {
+		return true
+	}
+
+	for _, teamId := range panelTeams {
+		if Contains(userTeams, teamId) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// canViewAllTickets returns whether the user may view every ticket in the guild, regardless of the panel it was
+// opened from. If not, the user's member object is also returned.
+func canViewAllTickets(ctx context.Context, guildId, userId uint64) (bool, member.Member, *api.RequestError) {
+	botContext, err := botcontext.ContextForGuild(guildId)
+	if err != nil {
+		return false, member.Member{}, api.NewInternalServerError(err, "Error retrieving guild context")
+	}
+
+	// Admin override
+	if botContext.IsBotAdmin(ctx, userId) {
+		return true, member.Member{}, nil
+	}
+
+	// Check staff override
+	staffOverride, err := dbclient.Client.StaffOverride.HasActiveOverride(ctx, guildId)
+	if err != nil {
+		return false, member.Member{}, api.NewDatabaseError(err)
+	}
+
+	// If staff override enabled and the user is bot staff, grant admin permissions
+	if staffOverride {
+		isBotStaff, err := dbclient.Client.BotStaff.IsStaff(ctx, userId)
+		if err != nil {
+			return false, member.Member{}, api.NewDatabaseError(err)
+		}
+
+		if isBotStaff {
+			return true, member.Member{}, nil
+		}
+	}
+
+	// Check if server owner
+	guild, err := botContext.GetGuild(ctx, guildId)
+	if err != nil {
+		return false, member.Member{}, api.NewInternalServerError(err, "Error retrieving guild object")
+	}
+
+	if guild.OwnerId == userId {
+		return true, member.Member{}, nil
+	}
+
+	member, err := botContext.GetGuildMember(ctx, guildId, userId)
+	if err != nil {
+		return false, member, api.NewErrorWithMessage(http.StatusForbidden, err, "User not in server: are you logged into the correct account?")
+	}
+
+	// Admins should have access to all tickets
+	isAdmin, err := dbclient.Client.Permissions.IsAdmin(ctx, guildId, userId)
+	if err != nil {
+		return false, member, api.NewDatabaseError(err)
+	}
+
+	if isAdmin {
+		return true, member, nil
+	}
+
+	// TODO: Check in db
+	adminRoles, err := dbclient.Client.RolePermissions.GetAdminRoles(ctx, guildId)
+	if err != nil {
+		return false, member, api.NewDatabaseError(err)
+	}
+
+	for _, roleId := range adminRoles {
+		if member.HasRole(roleId) {
+			return true, member, nil
+		}
+	}
+
+	return false, member, nil
+}
+
 func isOnDefaultTeam(ctx context.Context, guildId uint64, member member.Member) (bool, *api.RequestError) {
 	// Admin perms are already checked straight away, so we don't need to check for them here
 	// Check user perms for support
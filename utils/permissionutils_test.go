@@ -0,0 +1,20 @@
+package utils
+
+import (
+	"testing"
+
+	"github.com/TicketsBot-cloud/database"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCanViewPanel(t *testing.T) {
+	defaultPanel := database.Panel{PanelId: 1, WithDefaultTeam: true}
+	restrictedPanel := database.Panel{PanelId: 2, WithDefaultTeam: false}
+
+	assert.True(t, canViewPanel(defaultPanel, true, nil, nil))
+	assert.False(t, canViewPanel(defaultPanel, false, nil, nil))
+
+	// A support member on the default team, but not on the panel's team, may not view the panel's tickets
+	assert.False(t, canViewPanel(restrictedPanel, true, []int{5}, []int{6}))
+	assert.True(t, canViewPanel(restrictedPanel, false, []int{5, 6}, []int{6}))
+}
@@ -0,0 +1,147 @@
+package placeholders
+
+import (
+	"fmt"
+	"regexp"
+
+	"github.com/TicketsBot-cloud/dashboard/utils"
+	"github.com/TicketsBot-cloud/dashboard/utils/types"
+)
+
+// Values maps placeholder names to the text they should be replaced with
+type Values map[string]string
+
+// Placeholders use the same %name% syntax as the bot, which substitutes them when a tag is used or a welcome message
+// is sent, so that a message renders the same whichever of the two sends it
+const (
+	User                = "user"
+	Username            = "username"
+	TicketId            = "ticket_id"
+	Channel             = "channel"
+	Server              = "server"
+	Time                = "time"
+	Date                = "date"
+	DateTime            = "datetime"
+	AccountCreationDate = "discord_account_creation_date"
+	AccountAge          = "discord_account_age"
+)
+
+var (
+	// Variables are the placeholders that the dashboard can render
+	Variables = []string{User, Username, TicketId, Channel, Server, Time, Date, DateTime, AccountCreationDate, AccountAge}
+
+	// BotVariables are substituted by the bot, but rely on statistics or integrations that the dashboard does not have
+	// access to, so are left as-is in messages that the dashboard sends
+	BotVariables = []string{
+		"open_tickets", "total_tickets", "user_open_tickets", "user_total_tickets", "ticket_limit",
+		"rating_count", "average_rating",
+		"first_response_time_weekly", "first_response_time_monthly", "first_response_time_all_time",
+		"roblox_username", "roblox_id", "roblox_display_name", "roblox_profile_url", "roblox_account_age",
+		"roblox_account_created",
+	}
+
+	pattern = regexp.MustCompile(`%([\w-]+)%`)
+)
+
+// Find returns the names of all placeholders used in the text, in the order they appear
+func Find(text string) []string {
+	var names []string
+	for _, match := range pattern.FindAllStringSubmatch(text, -1) {
+		names = append(names, match[1])
+	}
+
+	return names
+}
+
+// FindInEmbed returns the names of all placeholders used in the parts of the embed that are substituted
+func FindInEmbed(embed *types.CustomEmbed) []string {
+	if embed == nil {
+		return nil
+	}
+
+	var names []string
+	for _, text := range embedText(embed) {
+		names = append(names, Find(*text)...)
+	}
+
+	return names
+}
+
+// Validate returns an error if the text uses a placeholder that neither the bot nor the dashboard substitutes. The
+// names of any custom integration placeholders that may also be used are passed in extra.
+func Validate(text string, extra []string) error {
+	for _, name := range Find(text) {
+		if !IsKnown(name) && !utils.Contains(extra, name) {
+			return fmt.Errorf("Unknown placeholder: %%%s%%", name)
+		}
+	}
+
+	return nil
+}
+
+// ValidateEmbed validates the placeholders in the parts of the embed that are substituted
+func ValidateEmbed(embed *types.CustomEmbed, extra []string) error {
+	if embed == nil {
+		return nil
+	}
+
+	for _, text := range embedText(embed) {
+		if err := Validate(*text, extra); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// IsKnown returns whether the placeholder is substituted by the bot
+func IsKnown(name string) bool {
+	return utils.Contains(Variables, name) || utils.Contains(BotVariables, name)
+}
+
+// Render replaces all placeholders in the text with their values. Placeholders without a value are left as-is.
+func Render(text string, values Values) string {
+	return pattern.ReplaceAllStringFunc(text, func(match string) string {
+		if value, ok := values[match[1:len(match)-1]]; ok {
+			return value
+		}
+
+		return match
+	})
+}
+
+// RenderEmbed returns a copy of the embed with the placeholders rendered in the same parts of the embed that the bot
+// substitutes them in: the description and the field values
+func RenderEmbed(embed *types.CustomEmbed, values Values) *types.CustomEmbed {
+	if embed == nil {
+		return nil
+	}
+
+	rendered := *embed
+	rendered.Fields = make([]types.Field, len(embed.Fields))
+	copy(rendered.Fields, embed.Fields)
+
+	if rendered.Description != nil {
+		rendered.Description = utils.Ptr(*rendered.Description)
+	}
+
+	for _, text := range embedText(&rendered) {
+		*text = Render(*text, values)
+	}
+
+	return &rendered
+}
+
+// embedText returns pointers to the text of the embed that placeholders are substituted in
+func embedText(embed *types.CustomEmbed) []*string {
+	var text []*string
+	if embed.Description != nil {
+		text = append(text, embed.Description)
+	}
+
+	for i := range embed.Fields {
+		text = append(text, &embed.Fields[i].Value)
+	}
+
+	return text
+}
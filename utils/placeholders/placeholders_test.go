@@ -0,0 +1,67 @@
+package placeholders
+
+import (
+	"testing"
+
+	"github.com/TicketsBot-cloud/dashboard/utils"
+	"github.com/TicketsBot-cloud/dashboard/utils/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRender(t *testing.T) {
+	values := Values{User: "<@1>", TicketId: "5"}
+	rendered := Render("Hi %user%, ticket #%ticket_id%: %open_tickets% open, 100% sure", values)
+	assert.Equal(t, "Hi <@1>, ticket #5: %open_tickets% open, 100% sure", rendered)
+}
+
+func TestValidateKnownVariables(t *testing.T) {
+	assert.NoError(t, Validate("%user% %username% %ticket_id% %channel% %server% %roblox_username%", nil))
+}
+
+func TestValidateUnknownVariables(t *testing.T) {
+	assert.Error(t, Validate("%reason%", nil))
+	assert.NoError(t, Validate("%reason%", []string{"reason"}))
+}
+
+func TestValidateAllowsPercentages(t *testing.T) {
+	assert.NoError(t, Validate("Save 50% on 20% of orders", nil))
+}
+
+func TestRenderEmbedDoesNotModifyOriginal(t *testing.T) {
+	embed := &types.CustomEmbed{
+		Title:       utils.Ptr("Ticket %ticket_id%"),
+		Description: utils.Ptr("Welcome to %server%"),
+		Fields:      []types.Field{{Name: "%username%", Value: "%user%"}},
+	}
+
+	rendered := RenderEmbed(embed, Values{TicketId: "5", Server: "Guild", User: "<@1>", Username: "name"})
+	assert.Equal(t, "Welcome to Guild", *rendered.Description)
+	assert.Equal(t, "<@1>", rendered.Fields[0].Value)
+
+	// The bot only substitutes placeholders in the description and field values
+	assert.Equal(t, "Ticket %ticket_id%", *rendered.Title)
+	assert.Equal(t, "%username%", rendered.Fields[0].Name)
+
+	assert.Equal(t, "Welcome to %server%", *embed.Description)
+	assert.Equal(t, "%user%", embed.Fields[0].Value)
+}
+
+func TestValidateEmbed(t *testing.T) {
+	embed := &types.CustomEmbed{Fields: []types.Field{{Name: "Reason", Value: "%reason%"}}}
+	assert.Error(t, ValidateEmbed(embed, nil))
+	assert.NoError(t, ValidateEmbed(embed, []string{"reason"}))
+	assert.NoError(t, ValidateEmbed(nil, nil))
+}
+
+func TestNeeded(t *testing.T) {
+	names := FindInEmbed(&types.CustomEmbed{
+		Description: utils.Ptr("%user% %open_tickets%"),
+		Fields:      []types.Field{{Value: "%user% %server%"}},
+	})
+
+	assert.Equal(t, []string{User, Server}, Needed(names))
+}
+
+func TestSnowflakeTime(t *testing.T) {
+	assert.Equal(t, int64(1462015105), snowflakeTime(175928847299117063))
+}
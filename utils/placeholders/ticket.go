@@ -0,0 +1,74 @@
+package placeholders
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/TicketsBot-cloud/dashboard/botcontext"
+	"github.com/TicketsBot-cloud/dashboard/utils"
+	"github.com/TicketsBot-cloud/database"
+)
+
+const discordEpoch = 1420070400000
+
+// ForTicket returns the values of the given placeholders for the ticket. Only the placeholders that are used are
+// looked up, and, as the bot does, a failed lookup leaves the value empty rather than failing the message.
+// Placeholders that the dashboard can't render are not included.
+func ForTicket(ctx context.Context, botContext *botcontext.BotContext, ticket database.Ticket, names []string) Values {
+	values := make(Values)
+	now := time.Now().Unix()
+	for _, name := range names {
+		if _, ok := values[name]; ok {
+			continue
+		}
+
+		switch name {
+		case User:
+			values[name] = fmt.Sprintf("<@%d>", ticket.UserId)
+		case TicketId:
+			values[name] = strconv.Itoa(ticket.Id)
+		case Channel:
+			values[name] = ""
+			if ticket.ChannelId != nil {
+				values[name] = fmt.Sprintf("<#%d>", *ticket.ChannelId)
+			}
+		case Username:
+			user, _ := botContext.GetUser(ctx, ticket.UserId)
+			values[name] = user.Username
+		case Server:
+			guild, _ := botContext.GetGuild(ctx, ticket.GuildId)
+			values[name] = guild.Name
+		case Time:
+			values[name] = fmt.Sprintf("<t:%d:t>", now)
+		case Date:
+			values[name] = fmt.Sprintf("<t:%d:d>", now)
+		case DateTime:
+			values[name] = fmt.Sprintf("<t:%d:f>", now)
+		case AccountCreationDate:
+			values[name] = fmt.Sprintf("<t:%d:d>", snowflakeTime(ticket.UserId))
+		case AccountAge:
+			values[name] = fmt.Sprintf("<t:%d:R>", snowflakeTime(ticket.UserId))
+		}
+	}
+
+	return values
+}
+
+// Needed returns the placeholders in names that the dashboard renders, without duplicates
+func Needed(names []string) []string {
+	var needed []string
+	for _, name := range names {
+		if utils.Contains(Variables, name) && !utils.Contains(needed, name) {
+			needed = append(needed, name)
+		}
+	}
+
+	return needed
+}
+
+// snowflakeTime returns the unix timestamp, in seconds, that the snowflake was created at
+func snowflakeTime(snowflake uint64) int64 {
+	return int64((snowflake>>22)+discordEpoch) / 1000
+}
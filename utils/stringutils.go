@@ -48,3 +48,13 @@ func StringMax(str string, max int, suffix ...string) string {
 
 	return str
 }
+
+// CsvSafe prefixes values that spreadsheet software would otherwise interpret as a formula with a single quote, so
+// that user provided text can't inject formulas into CSV exports
+func CsvSafe(value string) string {
+	if len(value) > 0 && strings.ContainsRune("=+-@\t\r", rune(value[0])) {
+		return "'" + value
+	}
+
+	return value
+}
@@ -0,0 +1,158 @@
+package utils
+
+import (
+	"archive/zip"
+	"context"
+	"fmt"
+	"io"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/TicketsBot-cloud/dashboard/botcontext"
+	"github.com/TicketsBot-cloud/dashboard/chatreplica"
+	dbclient "github.com/TicketsBot-cloud/dashboard/database"
+	"github.com/TicketsBot-cloud/dashboard/log"
+	"github.com/TicketsBot-cloud/dashboard/s3"
+	"github.com/minio/minio-go/v7"
+	"go.uber.org/zap"
+)
+
+// MirroredAttachmentLinkValidity is how long the links to mirrored attachments in a payload remain valid for. Rendered
+// transcripts are also served through share links, so the links are kept short lived, to limit access to attachments
+// after a share link has been revoked. Transcripts that are downloaded are bundled with their attachments instead, see
+// WriteTranscriptBundle.
+const MirroredAttachmentLinkValidity = time.Hour * 2
+
+var attachmentExtensionRegex = regexp.MustCompile(`^\.[a-zA-Z0-9]{1,10}$`)
+
+// TranscriptPayload converts an archived transcript into a chatreplica payload, resolving the roles and channels that
+// it mentions. Resolution is best effort: if the guild's roles or channels cannot be fetched, the entity data that
+// was archived with the transcript is used instead. Attachments that have been mirrored to our own storage are linked
+// to there, rather than to the Discord CDN.
+func TranscriptPayload(ctx context.Context, transcript chatreplica.Transcript, guildId uint64, ticketId int) chatreplica.Payload {
+	payload := chatreplica.FromTranscript(transcript, ticketId)
+
+	if urls, err := mirroredAttachmentUrls(ctx, guildId, ticketId); err == nil {
+		payload.RewriteAttachmentUrls(urls)
+	} else {
+		log.Logger.Warn("Failed to get mirrored attachments", zap.Uint64("guild_id", guildId), zap.Int("ticket_id", ticketId), zap.Error(err))
+	}
+
+	resolveEntities(ctx, &payload, guildId)
+	return payload
+}
+
+// WriteTranscriptBundle writes the transcript to the ZIP archive under name, along with copies of its mirrored
+// attachments, which the transcript links to by their path within the archive. Unlike the signed links used by
+// TranscriptPayload, these links never expire, so the transcript can be kept after it has been downloaded.
+func WriteTranscriptBundle(
+	ctx context.Context,
+	archive *zip.Writer,
+	name string,
+	transcript chatreplica.Transcript,
+	mirrors []dbclient.AttachmentMirror,
+	guildId uint64,
+	ticketId int,
+	format chatreplica.Format,
+) error {
+	filenames := make(map[uint64]string)
+	for _, msg := range transcript.Messages {
+		for _, attachment := range msg.Attachments {
+			filenames[attachment.Id] = attachment.Filename
+		}
+	}
+
+	paths := make(map[uint64]string, len(mirrors))
+	for _, mirror := range mirrors {
+		extension := filepath.Ext(filenames[mirror.AttachmentId])
+		if !attachmentExtensionRegex.MatchString(extension) {
+			extension = ""
+		}
+
+		paths[mirror.AttachmentId] = fmt.Sprintf("attachments/%d/%d%s", ticketId, mirror.AttachmentId, extension)
+	}
+
+	payload := chatreplica.FromTranscript(transcript, ticketId)
+	payload.RewriteAttachmentUrls(paths)
+	resolveEntities(ctx, &payload, guildId)
+
+	data, err := chatreplica.Export(payload, format)
+	if err != nil {
+		return err
+	}
+
+	file, err := archive.Create(name)
+	if err != nil {
+		return err
+	}
+
+	if _, err := file.Write(data); err != nil {
+		return err
+	}
+
+	for _, mirror := range mirrors {
+		if err := writeMirroredAttachment(ctx, archive, paths[mirror.AttachmentId], mirror.ObjectKey); err != nil {
+			return fmt.Errorf("failed to copy attachment %d: %w", mirror.AttachmentId, err)
+		}
+	}
+
+	return nil
+}
+
+func writeMirroredAttachment(ctx context.Context, archive *zip.Writer, path, objectKey string) error {
+	object, err := s3.S3Client.GetObject(ctx, s3.AttachmentBucket(), objectKey, minio.GetObjectOptions{})
+	if err != nil {
+		return err
+	}
+
+	defer object.Close()
+
+	// Attachments are mostly images and videos, which are already compressed
+	file, err := archive.CreateHeader(&zip.FileHeader{Name: path, Method: zip.Store})
+	if err != nil {
+		return err
+	}
+
+	_, err = io.Copy(file, object)
+	return err
+}
+
+func resolveEntities(ctx context.Context, payload *chatreplica.Payload, guildId uint64) {
+	botContext, err := botcontext.ContextForGuild(guildId)
+	if err != nil {
+		return
+	}
+
+	_ = payload.ResolveEntities(ctx, botContext, guildId)
+}
+
+// mirroredAttachmentUrls returns signed links to the ticket's mirrored attachments, by attachment ID. Images are
+// served through the image proxy.
+func mirroredAttachmentUrls(ctx context.Context, guildId uint64, ticketId int) (map[uint64]string, error) {
+	mirrors, err := dbclient.Dashboard.AttachmentMirrors.GetByTicket(ctx, guildId, ticketId)
+	if err != nil {
+		return nil, err
+	}
+
+	urls := make(map[uint64]string, len(mirrors))
+	for _, mirror := range mirrors {
+		presigned, err := s3.S3Client.PresignedGetObject(ctx, s3.AttachmentBucket(), mirror.ObjectKey, MirroredAttachmentLinkValidity, nil)
+		if err != nil {
+			return nil, err
+		}
+
+		url := presigned.String()
+		if strings.HasPrefix(mirror.ContentType, "image/") {
+			url, err = GenerateImageProxyUrl(url, MirroredAttachmentLinkValidity)
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		urls[mirror.AttachmentId] = url
+	}
+
+	return urls, nil
+}